@@ -1,74 +1,245 @@
 package jwt_handlers
 
 import (
+	"crypto/rand"
 	"crypto/rsa"
-	"flag"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"log"
+	"math/big"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
-	privateKeyPath = "/auth_service/jwt_handlers/signature/private_key.pem"
-	publicKeyPath  = "/auth_service/jwt_handlers/signature/public_key.pem"
+	defaultKeysDir = "/jwt_keys"
+
+	// Files in keys directory:
+	//	<kid>.pem        - private key, can be used for signing and verification
+	//	<kid>.public.pem - public key only, can be used only for verification
+	privateKeySuffix = ".pem"
+	publicKeySuffix  = ".public.pem"
+
+	generatedKeyBits = 2048
 )
 
-type JWTHandlers struct {
-	JwtPrivate *rsa.PrivateKey
-	JwtPublic  *rsa.PublicKey
+var errUnknownKey = errors.New("token is signed by unknown or retired key")
+
+type Key struct {
+	ID      string
+	Private *rsa.PrivateKey // nil for verification-only keys
+	Public  *rsa.PublicKey
+	Retired bool
 }
 
-func NewAuthHandlers(jwtprivateFile string, jwtPublicFile string) *JWTHandlers {
-	fmt.Fprintln(os.Stderr, "Creating JWT handlers")
+// Set of keys which are used to sign and verify JWT tokens
+//
+//	Tokens are signed by active key only and have its id in `kid` header.
+//	Tokens are accepted if they are signed by any key which is not retired
+type KeyRing struct {
+	keys      map[string]*Key
+	activeKID string
+}
 
-	private, err := os.ReadFile(jwtprivateFile)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+// Load keys from `dir`
+//
+//	`activeKID` is id of key used for signing. If it's empty then the last key
+//	(in lexicographical order of ids) which has private part is used.
+//	Keys from `retiredKIDs` are not used for verification and are not published in JWKS.
+//	If directory has no keys, new key is generated and saved into it
+func LoadKeyRing(dir string, activeKID string, retiredKIDs []string) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]*Key)}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keys directory: %w", err)
 	}
-	public, err := os.ReadFile(jwtPublicFile)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to read keys directory: %w", err)
 	}
-	jwtPrivate, err := jwt.ParseRSAPrivateKeyFromPEM(private)
-	if err != nil {
-		fmt.Println("Hello")
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, privateKeySuffix) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(name, publicKeySuffix) {
+			kid := strings.TrimSuffix(name, publicKeySuffix)
+			public, err := jwt.ParseRSAPublicKeyFromPEM(content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse public key `%s`: %w", name, err)
+			}
+			if _, ok := ring.keys[kid]; !ok {
+				ring.keys[kid] = &Key{ID: kid, Public: public}
+			}
+			continue
+		}
+
+		kid := strings.TrimSuffix(name, privateKeySuffix)
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key `%s`: %w", name, err)
+		}
+		ring.keys[kid] = &Key{ID: kid, Private: private, Public: &private.PublicKey}
+	}
+
+	if len(ring.keys) == 0 {
+		key, err := generateKey(dir)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[key.ID] = key
+	}
+
+	for _, kid := range retiredKIDs {
+		if key, ok := ring.keys[kid]; ok {
+			key.Retired = true
+		}
+	}
+
+	if activeKID == "" {
+		for _, kid := range ring.sortedKIDs() {
+			if key := ring.keys[kid]; key.Private != nil && !key.Retired {
+				activeKID = kid
+			}
+		}
+	}
+	active, ok := ring.keys[activeKID]
+	if !ok || active.Private == nil || active.Retired {
+		return nil, fmt.Errorf("no usable private key for signing (active kid: `%s`)", activeKID)
 	}
-	jwtPublic, err := jwt.ParseRSAPublicKeyFromPEM(public)
+	ring.activeKID = activeKID
+
+	return ring, nil
+}
+
+// Generate new RSA key and save it into `dir`. Key's id is based on current time
+func generateKey(dir string) (*Key, error) {
+	private, err := rsa.GenerateKey(rand.Reader, generatedKeyBits)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to generate key: %w", err)
 	}
-	return &JWTHandlers{
-		JwtPrivate: jwtPrivate,
-		JwtPublic:  jwtPublic,
+
+	kid := time.Now().UTC().Format("20060102T150405Z")
+	encoded := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(private),
+	})
+	if err := os.WriteFile(filepath.Join(dir, kid+privateKeySuffix), encoded, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save generated key: %w", err)
 	}
+
+	log.Printf("No JWT signing keys found, new key `%s` has been generated in %s", kid, dir)
+	return &Key{ID: kid, Private: private, Public: &private.PublicKey}, nil
 }
 
-var h *JWTHandlers
+func (ring *KeyRing) sortedKIDs() []string {
+	kids := make([]string, 0, len(ring.keys))
+	for kid := range ring.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids
+}
+
+// Sign token by active key and put key's id into `kid` header
+func (ring *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	active := ring.keys[ring.activeKID]
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.Private)
+}
+
+// Function for `jwt.Parse` which chooses verification key by token's `kid` header
+func (ring *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, errors.New("token has no `kid` header")
+	}
+	key, ok := ring.keys[kid]
+	if !ok || key.Retired {
+		return nil, errUnknownKey
+	}
+	return key.Public, nil
+}
+
+// JSON Web Key (RFC 7517) with RSA public key
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
 
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Public keys which can be used to verify tokens (retired keys are not included)
+func (ring *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, kid := range ring.sortedKIDs() {
+		key := ring.keys[kid]
+		if key.Retired {
+			continue
+		}
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			KeyID:     key.ID,
+			Modulus:   base64.RawURLEncoding.EncodeToString(key.Public.N.Bytes()),
+			Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.Public.E)).Bytes()),
+		})
+	}
+	return set
+}
+
+var ring *KeyRing
+
+// Load key ring using configuration from environment:
+//
+//	JWT_KEYS_DIR     - directory with keys (default: /jwt_keys)
+//	JWT_ACTIVE_KID   - id of key used for signing (default: the last one)
+//	JWT_RETIRED_KIDS - comma separated ids of keys which should not be accepted anymore
 func InitJWTHandlers() error {
-	flag.Parse()
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		dir = defaultKeysDir
+	}
 
-	absoluteprivateFile, err := filepath.Abs(privateKeyPath)
-	if err != nil {
-		return err
+	var retiredKIDs []string
+	for _, kid := range strings.Split(os.Getenv("JWT_RETIRED_KIDS"), ",") {
+		if kid = strings.TrimSpace(kid); kid != "" {
+			retiredKIDs = append(retiredKIDs, kid)
+		}
 	}
 
-	absolutePublicFile, err := filepath.Abs(publicKeyPath)
+	var err error
+	ring, err = LoadKeyRing(dir, os.Getenv("JWT_ACTIVE_KID"), retiredKIDs)
 	if err != nil {
 		return err
 	}
-
-	h = NewAuthHandlers(absoluteprivateFile, absolutePublicFile)
+	log.Printf("JWT key ring loaded: %d key(s), active key `%s`", len(ring.keys), ring.activeKID)
 	return nil
 }
 
-func GetJWTHandlers() *JWTHandlers {
-	return h
+func GetKeyRing() *KeyRing {
+	return ring
 }
@@ -11,8 +11,9 @@ import (
 )
 
 var (
-	views *kafka.Writer
-	likes *kafka.Writer
+	views         *kafka.Writer
+	likes         *kafka.Writer
+	userDeletions *kafka.Writer
 )
 
 const (
@@ -36,20 +37,23 @@ func InitKafkaTopics() {
 
 	views = getKafkaWriter(kafkaURL, "views")
 	likes = getKafkaWriter(kafkaURL, "likes")
+	userDeletions = getKafkaWriter(kafkaURL, "user_deletions")
 }
 
-func CreateEmptyStatistics(taskID int32, taskAuthor string) error {
-	if err := Like(accountForCreatingEmptyStatistics, taskID, taskAuthor); err != nil {
+// Empty `workspaceID` means that task is outside of workspaces
+func CreateEmptyStatistics(taskID int32, taskAuthor string, workspaceID string) error {
+	if err := Like(accountForCreatingEmptyStatistics, taskID, taskAuthor, workspaceID); err != nil {
 		return err
 	}
-	return View(accountForCreatingEmptyStatistics, taskID, taskAuthor)
+	return View(accountForCreatingEmptyStatistics, taskID, taskAuthor, workspaceID)
 }
 
-func Like(liker string, taskID int32, taskAuthor string) error {
+func Like(liker string, taskID int32, taskAuthor string, workspaceID string) error {
 	encoded, err := json.Marshal(map[string]any{
-		"username":    liker,
-		"task_id":     taskID,
-		"task_author": taskAuthor, // for statistics
+		"username":     liker,
+		"task_id":      taskID,
+		"task_author":  taskAuthor,  // for statistics
+		"workspace_id": workspaceID, // for statistics of workspace
 	})
 	if err != nil {
 		return err
@@ -68,11 +72,12 @@ func Like(liker string, taskID int32, taskAuthor string) error {
 	}
 }
 
-func View(viewer string, taskID int32, taskAuthor string) error {
+func View(viewer string, taskID int32, taskAuthor string, workspaceID string) error {
 	encoded, err := json.Marshal(map[string]any{
-		"username":    viewer,
-		"task_id":     taskID,
-		"task_author": taskAuthor, // for statistics
+		"username":     viewer,
+		"task_id":      taskID,
+		"task_author":  taskAuthor,  // for statistics
+		"workspace_id": workspaceID, // for statistics of workspace
 	})
 	if err != nil {
 		return err
@@ -91,7 +96,52 @@ func View(viewer string, taskID int32, taskAuthor string) error {
 	}
 }
 
+// Notify statistics service that user's account was deleted, so his likes, views and statistics of tasks
+// deleted together with him should be deleted. Other tasks of user are kept in workspaces without author
+//
+//	Message's key is username, so repeated notifications about the same user are processed in order
+func UserDeleted(username string, deletedTaskIDs []int32) error {
+	encoded, err := json.Marshal(map[string]any{
+		"username":         username,
+		"deleted_task_ids": deletedTaskIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Send message (user deletion) to Kafka {Key: %s, Value: %s}", username, string(encoded))
+	for {
+		err = userDeletions.WriteMessages(context.Background(), kafka.Message{Key: []byte(username), Value: encoded})
+		if err == nil {
+			return nil
+		}
+		if err.Error() != kafkaLeadershipErrorMessage {
+			return err
+		}
+	}
+}
+
 func CloseKafkaTopics() {
 	views.Close()
 	likes.Close()
+	userDeletions.Close()
+}
+
+// Publisher of events to statistics service through Kafka. Topics should be initialized by `InitKafkaTopics`
+type Publisher struct{}
+
+func (Publisher) CreateEmptyStatistics(taskID int32, taskAuthor string, workspaceID string) error {
+	return CreateEmptyStatistics(taskID, taskAuthor, workspaceID)
+}
+
+func (Publisher) Like(liker string, taskID int32, taskAuthor string, workspaceID string) error {
+	return Like(liker, taskID, taskAuthor, workspaceID)
+}
+
+func (Publisher) View(viewer string, taskID int32, taskAuthor string, workspaceID string) error {
+	return View(viewer, taskID, taskAuthor, workspaceID)
+}
+
+func (Publisher) UserDeleted(username string, deletedTaskIDs []int32) error {
+	return UserDeleted(username, deletedTaskIDs)
 }
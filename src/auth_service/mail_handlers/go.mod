@@ -0,0 +1,5 @@
+module mail_handlers
+
+go 1.22.0
+
+require github.com/google/uuid v1.6.0
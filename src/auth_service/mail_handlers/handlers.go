@@ -0,0 +1,154 @@
+package mail_handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMailDir  = "/mail"
+	defaultMailFrom = "TaskTracker <no-reply@tasktracker.local>"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Something that can deliver emails. Implementation is chosen by MAIL_SENDER environment variable
+type Sender interface {
+	Send(message Message) error
+}
+
+// Writes emails into service's log. For local development only
+type LogSender struct{}
+
+func (LogSender) Send(message Message) error {
+	log.Printf("Mail to <%s>, subject %q:\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// Writes every email into separate `.eml` file in `Dir`. For local development and tests
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s FileSender) Send(message Message) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	name := fmt.Sprintf("%s_%s.eml", time.Now().UTC().Format("20060102T150405Z"), uuid.New().String())
+	return os.WriteFile(filepath.Join(s.Dir, name), formatMessage(s.From, message), 0600)
+}
+
+// Sends emails through SMTP server
+type SMTPSender struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPSender) Send(message Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("incorrect SMTP address: %w", err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	from := s.From
+	if address, err := parseAddress(from); err == nil {
+		from = address
+	}
+	return smtp.SendMail(s.Addr, auth, from, []string{message.To}, formatMessage(s.From, message))
+}
+
+func formatMessage(from string, message Message) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + message.To + "\r\n")
+	builder.WriteString("Subject: " + message.Subject + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(builder.String())
+}
+
+// Get bare address from `Name <address>` form
+func parseAddress(from string) (string, error) {
+	start := strings.LastIndex(from, "<")
+	end := strings.LastIndex(from, ">")
+	if start == -1 || end < start {
+		return "", errors.New("no address in angle brackets")
+	}
+	return from[start+1 : end], nil
+}
+
+var sender Sender = LogSender{}
+
+// Choose mail sender using configuration from environment:
+//
+//	MAIL_SENDER   - `log` (default), `file` or `smtp`
+//	MAIL_FROM     - sender's address
+//	MAIL_DIR      - directory for `file` sender (default: /mail)
+//	SMTP_ADDR     - host:port of SMTP server for `smtp` sender
+//	SMTP_USERNAME - SMTP user (optional)
+//	SMTP_PASSWORD - SMTP password (optional)
+func InitMailSender() error {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = defaultMailFrom
+	}
+
+	kind := os.Getenv("MAIL_SENDER")
+	switch kind {
+	case "", "log":
+		sender = LogSender{}
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = defaultMailDir
+		}
+		sender = FileSender{Dir: dir, From: from}
+	case "smtp":
+		addr := os.Getenv("SMTP_ADDR")
+		if addr == "" {
+			return errors.New("SMTP server is not configured (SMTP_ADDR environment variable)")
+		}
+		sender = SMTPSender{
+			Addr:     addr,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	default:
+		return fmt.Errorf("unknown MAIL_SENDER `%s`, should be `log`, `file` or `smtp`", kind)
+	}
+
+	log.Printf("Mail sender: %T", sender)
+	return nil
+}
+
+func GetSender() Sender {
+	return sender
+}
+
+// Replace mail sender (e.g. by fake one in tests)
+func SetSender(newSender Sender) {
+	sender = newSender
+}
@@ -4,30 +4,66 @@ import (
 	"kafka_handlers"
 	"log"
 	"net/http"
+	"os"
 
 	main_logic "auth_service/main_logic"
 	"jwt_handlers"
+	"mail_handlers"
 	"mongo_handlers"
+	"oidc_handlers"
 )
 
 func main() {
-	log.Printf("[0/3]: Server starting...")
+	log.Printf("[0/6]: Server starting...")
 
-	if err := mongo_handlers.InitMongoClient(); err != nil {
+	// AUTH_STORAGE=memory keeps all data in memory and is intended only for local runs without MongoDB
+	if os.Getenv("AUTH_STORAGE") == "memory" {
+		store := main_logic.NewMemoryStore()
+		main_logic.SetStores(store, store)
+		log.Printf("[1/6]: In-memory storage initialized. Data will be lost on restart")
+	} else {
+		if err := mongo_handlers.InitMongoClient(); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("[1/6]: Mongo client initialized")
+		defer mongo_handlers.CloseMongoClient()
+	}
+
+	// The first admin is taken from BOOTSTRAP_ADMIN, other roles are assigned by admins
+	if err := main_logic.BootstrapAdmin(); err != nil {
 		log.Fatal(err)
 	}
-	log.Printf("[1/3]: Mongo client initialized")
-	defer mongo_handlers.CloseMongoClient()
 
 	if err := jwt_handlers.InitJWTHandlers(); err != nil {
 		log.Fatal(err)
 	}
-	log.Printf("[2/3]: JWT Handlers initialized")
+	log.Printf("[2/6]: JWT Handlers initialized")
+
+	if err := oidc_handlers.InitOIDCProvider(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("[3/6]: OIDC provider initialized")
+
+	if err := mail_handlers.InitMailSender(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("[4/6]: Mail sender initialized")
 
 	kafka_handlers.InitKafkaTopics()
-	log.Printf("[3/3]: Kafka topics initialized")
+	log.Printf("[5/6]: Kafka topics initialized")
 	defer kafka_handlers.CloseKafkaTopics()
 
+	if err := main_logic.InitTaskServiceClient(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("[6/6]: Task service client initialized")
+	defer main_logic.CloseTaskServiceClient()
+
+	// Finish account deletions which were interrupted (e.g. when task service was unavailable)
+	main_logic.StartAccountDeletionWorker()
+	// Delete archives of expired personal data exports
+	main_logic.StartDataExportCleanupWorker()
+
 	router := main_logic.NewRouter()
 	log.Println("[Ready] Listen on :8080. You can send requests to main service")
 	log.Fatal(http.ListenAndServe(":8080", router))
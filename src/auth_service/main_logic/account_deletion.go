@@ -0,0 +1,254 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mongo_handlers"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	task_servicepb "task_service/proto"
+)
+
+// Account deletion
+//
+//	Deletion is stored as pending before anything is deleted and consists of steps:
+//	1. Hand over workspaces owned by user to their oldest admin (or the oldest member if there are no admins),
+//	   delete owned workspaces without other members. Then delete user, his sessions, tokens and data exports from Mongo
+//	2. Delete user's tasks outside of workspaces and tasks of deleted workspaces in task service.
+//	   User's tasks in remaining workspaces are kept without creator
+//	3. Notify statistics service through Kafka, so it deletes user's likes and views and statistics of deleted tasks.
+//	   Statistics of tasks kept in workspaces stay, only their author is removed
+//	Every step can be repeated safely. If some service is unavailable, deletion stays pending
+//	and is finished by `StartAccountDeletionWorker` later.
+//	Every replica runs the worker, so deletion is claimed before processing and only one replica works on it
+const (
+	accountDeletionRetryInterval = time.Minute
+	accountDeletionStepTimeout   = 10 * time.Second
+	// Claim of replica which crashed during deletion expires after this time. It's much longer than all steps take
+	accountDeletionLockTTL = 5 * time.Minute
+)
+
+// Start deletion of user's account and try to finish it at once
+//
+//	Returns true if every step is done, false if deletion will be finished later
+func DeleteAccount(username string) (completed bool, code int, err error) {
+	var deletion mongo_handlers.AccountDeletion
+	code, err = userStore.StartAccountDeletion(username, time.Now(), &deletion)
+	if err != nil {
+		return false, code, err
+	}
+	code, err = claimAccountDeletion(username, &deletion)
+	if err != nil {
+		if code == http.StatusNotFound {
+			// Another replica is processing deletion right now
+			return false, http.StatusOK, nil
+		}
+		return false, code, err
+	}
+
+	if err := ProcessAccountDeletion(&deletion); err != nil {
+		// User's local data should be deleted right away, only other services may be finished later
+		if !deletion.LocalDataDeleted {
+			return false, http.StatusInternalServerError, err
+		}
+		log.Printf("Deletion of account `%s` will be retried: %s", username, err.Error())
+		return false, http.StatusOK, nil
+	}
+	return true, http.StatusOK, nil
+}
+
+// Claim pending deletion for this replica, returns 404 (Status Not Found) if it's claimed by another replica
+func claimAccountDeletion(username string, deletion *mongo_handlers.AccountDeletion) (code int, err error) {
+	return userStore.ClaimAccountDeletion(username, uuid.New().String(), time.Now().Add(accountDeletionLockTTL), deletion)
+}
+
+// Do steps of account deletion which are not done yet, save progress and release claim
+//
+//	Deletion should be claimed by `claimAccountDeletion`
+func ProcessAccountDeletion(deletion *mongo_handlers.AccountDeletion) error {
+	err := processAccountDeletionSteps(deletion)
+	if err != nil {
+		deletion.Attempts++
+		deletion.LastError = err.Error()
+	} else {
+		now := time.Now()
+		deletion.LastError = ""
+		deletion.CompletedAt = &now
+		log.Printf("Account `%s` has been deleted", deletion.Username)
+	}
+	deletion.LockedUntil = nil
+
+	if _, updateErr := userStore.UpdateAccountDeletion(*deletion); updateErr != nil {
+		return errors.Join(err, updateErr)
+	}
+	return err
+}
+
+func processAccountDeletionSteps(deletion *mongo_handlers.AccountDeletion) error {
+	username := deletion.Username
+
+	if !deletion.LocalDataDeleted {
+		if err := handOverOwnedWorkspaces(deletion); err != nil {
+			return err
+		}
+		if _, err := sessionStore.DeleteUserSessions(username); err != nil {
+			return err
+		}
+		if err := deleteUserDataExports(username); err != nil {
+			return err
+		}
+		if _, err := userStore.DeleteUser(username); err != nil {
+			return err
+		}
+		if err := userStore.ResetLoginAttempts(userAttemptsKeyPrefix + username); err != nil {
+			return err
+		}
+		deletion.LocalDataDeleted = true
+	}
+
+	if !deletion.TasksDeleted {
+		ctx, cancel := context.WithTimeout(context.Background(), accountDeletionStepTimeout)
+		defer cancel()
+		deleted, err := taskServiceClient.DeleteUserTasks(ctx, &task_servicepb.UserRequest{
+			Username:            username,
+			DeletedWorkspaceIds: deletion.DeletedWorkspaceIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("grpc request `DeleteUserTasks` failed with error message: %w", err)
+		}
+		log.Printf("Deleted %d tasks of user `%s`, %d tasks in workspaces were kept without creator", deleted.Count, username, deleted.Anonymized)
+		// Repeated call doesn't return already deleted tasks, so they are saved at once
+		deletion.DeletedTaskIDs = deleted.DeletedTaskIds
+		deletion.TasksDeleted = true
+		if _, err := userStore.UpdateAccountDeletion(*deletion); err != nil {
+			return err
+		}
+	}
+
+	if !deletion.StatisticsNotified {
+		if err := eventPublisher.UserDeleted(username, deletion.DeletedTaskIDs); err != nil {
+			return fmt.Errorf("`user deletion` message sending caused a error: %w", err)
+		}
+		deletion.StatisticsNotified = true
+	}
+
+	return nil
+}
+
+// Workspace can't stay without owner, so every workspace owned by user gets a new owner or is deleted
+//
+//	Deleted workspace is saved in deletion before it's deleted, so its tasks are deleted even if deletion is retried
+func handOverOwnedWorkspaces(deletion *mongo_handlers.AccountDeletion) error {
+	var memberships []mongo_handlers.WorkspaceMember
+	if _, err := userStore.GetUserWorkspaceMembers(deletion.Username, &memberships); err != nil {
+		return err
+	}
+
+	for _, membership := range memberships {
+		if membership.Role != mongo_handlers.WorkspaceRoleOwner {
+			continue
+		}
+
+		var members []mongo_handlers.WorkspaceMember
+		if _, err := userStore.GetWorkspaceMembers(membership.WorkspaceID, &members); err != nil {
+			return err
+		}
+		successor := workspaceSuccessor(members, deletion.Username)
+		if successor != nil {
+			if _, err := userStore.SetWorkspaceOwner(membership.WorkspaceID, successor.Username); err != nil {
+				return err
+			}
+			log.Printf("Workspace `%s` of deleted user `%s` was handed over to `%s`", membership.WorkspaceID, deletion.Username, successor.Username)
+			continue
+		}
+
+		if !slices.Contains(deletion.DeletedWorkspaceIDs, membership.WorkspaceID) {
+			deletion.DeletedWorkspaceIDs = append(deletion.DeletedWorkspaceIDs, membership.WorkspaceID)
+			if _, err := userStore.UpdateAccountDeletion(*deletion); err != nil {
+				return err
+			}
+		}
+		if _, err := userStore.DeleteWorkspace(membership.WorkspaceID); err != nil {
+			return err
+		}
+		log.Printf("Workspace `%s` of deleted user `%s` had no other members and was deleted", membership.WorkspaceID, deletion.Username)
+	}
+	return nil
+}
+
+// The oldest admin of workspace or the oldest member if there are no admins. Returns nil if user is the only member
+func workspaceSuccessor(members []mongo_handlers.WorkspaceMember, username string) *mongo_handlers.WorkspaceMember {
+	var successor *mongo_handlers.WorkspaceMember
+	for i := range members {
+		member := &members[i]
+		if member.Username == username {
+			continue
+		}
+		if successor == nil {
+			successor = member
+			continue
+		}
+		isAdmin := member.Role == mongo_handlers.WorkspaceRoleAdmin
+		successorIsAdmin := successor.Role == mongo_handlers.WorkspaceRoleAdmin
+		if isAdmin != successorIsAdmin {
+			if isAdmin {
+				successor = member
+			}
+			continue
+		}
+		if member.JoinedAt.Before(successor.JoinedAt) {
+			successor = member
+		}
+	}
+	return successor
+}
+
+// Try to finish every pending account deletion
+func RetryAccountDeletions() {
+	var deletions []mongo_handlers.AccountDeletion
+	if _, err := userStore.GetPendingAccountDeletions(&deletions); err != nil {
+		log.Println("function `RetryAccountDeletions`:", err.Error())
+		return
+	}
+
+	for _, pending := range deletions {
+		var deletion mongo_handlers.AccountDeletion
+		code, err := claimAccountDeletion(pending.Username, &deletion)
+		if err != nil {
+			if code != http.StatusNotFound {
+				log.Println("function `RetryAccountDeletions`:", err.Error())
+			}
+			continue
+		}
+		if err := ProcessAccountDeletion(&deletion); err != nil {
+			log.Printf("function `RetryAccountDeletions`: deletion of account `%s` failed (attempt %d): %s", deletion.Username, deletion.Attempts, err.Error())
+		}
+	}
+}
+
+// Periodically finish pending account deletions (e.g. after task service was unavailable or auth service was restarted)
+func StartAccountDeletionWorker() {
+	go func() {
+		RetryAccountDeletions()
+		for range time.Tick(accountDeletionRetryInterval) {
+			RetryAccountDeletions()
+		}
+	}()
+}
+
+// Username can't be taken while deletion of account with this username is not finished,
+// otherwise new user's tasks would be deleted too
+func IsUsernameAvailable(username string) bool {
+	if userStore.CheckIfUserExists(username) {
+		return false
+	}
+	var deletion mongo_handlers.AccountDeletion
+	code, err := userStore.GetPendingAccountDeletion(username, &deletion)
+	return err != nil && code == http.StatusNotFound
+}
@@ -0,0 +1,102 @@
+package auth_service
+
+import (
+	"log"
+	"mongo_handlers"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outcomes of audited actions
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// Actions of users
+const (
+	AuditActionLogin                   = "auth.login"
+	AuditActionRegister                = "auth.register"
+	AuditActionTwoFactorChallenge      = "auth.2fa_challenge"
+	AuditActionLogout                  = "auth.logout"
+	AuditActionLogoutAll               = "auth.logout_all"
+	AuditActionRefreshTokenReuse       = "auth.refresh_token_reuse"
+	AuditActionChangePassword          = "profile.change_password"
+	AuditActionRequestPasswordReset    = "profile.request_password_reset"
+	AuditActionResetPasswordByEmail    = "profile.reset_password"
+	AuditActionUpdateProfile           = "profile.update"
+	AuditActionVerifyEmail             = "profile.verify_email"
+	AuditActionDeleteProfile           = "profile.delete"
+	AuditActionLinkIdentity            = "profile.link_identity"
+	AuditActionEnableTwoFactor         = "profile.enable_2fa"
+	AuditActionDisableTwoFactor        = "profile.disable_2fa"
+	AuditActionRegenerateRecoveryCodes = "profile.regenerate_recovery_codes"
+	AuditActionRevokeSession           = "session.revoke"
+	AuditActionCreateAccessToken       = "token.create"
+	AuditActionRevokeAccessToken       = "token.revoke"
+)
+
+// Actions of admins
+const (
+	AuditActionListUsers     = "admin.list_users"
+	AuditActionViewUser      = "admin.view_user"
+	AuditActionDisableUser   = "admin.disable_user"
+	AuditActionEnableUser    = "admin.enable_user"
+	AuditActionLogoutUser    = "admin.logout_user"
+	AuditActionResetPassword = "admin.reset_password"
+	AuditActionSetRole       = "admin.set_role"
+	AuditActionClearLockout  = "admin.clear_lockout"
+	AuditActionViewAuditLog  = "admin.view_audit_log"
+)
+
+// Append event to audit log. Outcome is success if `err` is nil, otherwise error is saved in details
+//
+//	Audit log is not critical for the request, so failure of writing it is only logged
+func RecordAuditEvent(r *http.Request, actor string, action string, target string, err error, details string) {
+	event := mongo_handlers.AuditEvent{
+		ID:        uuid.New().String(),
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		IP:        GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		Outcome:   AuditOutcomeSuccess,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if err != nil {
+		event.Outcome = AuditOutcomeFailure
+		if event.Details != "" {
+			event.Details += ": "
+		}
+		event.Details += err.Error()
+	}
+
+	if err := userStore.StoreAuditEvent(event); err != nil {
+		log.Printf("function `RecordAuditEvent`: event `%s` by `%s` is not saved: %s", action, actor, err.Error())
+	}
+}
+
+// Names of profile fields changed by `update`. Values are not written to audit log
+func profileUpdateDetails(update mongo_handlers.UserUpdate) string {
+	var fields []string
+	if update.FirstName != nil {
+		fields = append(fields, "firstName")
+	}
+	if update.LastName != nil {
+		fields = append(fields, "lastName")
+	}
+	if update.Birthday != nil {
+		fields = append(fields, "birthday")
+	}
+	if update.PhoneNumber != nil {
+		fields = append(fields, "phoneNumber")
+	}
+	if update.PendingEmail != nil {
+		fields = append(fields, "email")
+	}
+	return "fields: " + strings.Join(fields, ", ")
+}
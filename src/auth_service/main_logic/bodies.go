@@ -1,16 +1,135 @@
 package auth_service
 
+import (
+	"mongo_handlers"
+	"time"
+
+	task_servicepb "task_service/proto"
+)
+
 type AuthenticateBody struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
 
+type ChangePasswordBody struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+type DeleteProfileBody struct {
+	Password string `json:"password"`
+}
+
+type SetRoleBody struct {
+	Role string `json:"role"`
+}
+
+type PasswordResetRequestBody struct {
+	Username string `json:"username"`
+}
+
+type PasswordResetConfirmBody struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
 type ProfileInfo struct {
+	// Set only in responses
+	Username    string `json:"username,omitempty"`
 	FirstName   string `json:"firstName,omitempty"`
 	LastName    string `json:"lastName,omitempty"`
 	Birthday    string `json:"birthday,omitempty"`
 	Email       string `json:"email,omitempty"`
 	PhoneNumber string `json:"phoneNumber,omitempty"`
+	// Set only in responses. New email is pending until it's verified by link from email
+	EmailVerified bool   `json:"emailVerified"`
+	PendingEmail  string `json:"pendingEmail,omitempty"`
+	// Set only in responses
+	Role string `json:"role,omitempty"`
+}
+
+// Build profile from user stored in Mongo. Only profile fields are copied,
+// so password hash never gets into response
+func NewProfileInfo(user mongo_handlers.User) ProfileInfo {
+	return ProfileInfo{
+		Username:      user.Username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Birthday:      user.Birthday,
+		Email:         user.Email,
+		PhoneNumber:   user.PhoneNumber,
+		EmailVerified: user.EmailVerified,
+		PendingEmail:  user.PendingEmail,
+		Role:          user.GetRole(),
+	}
+}
+
+// Part of profile which is visible to other users
+type PublicProfileInfo struct {
+	Username  string `json:"username"`
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName,omitempty"`
+}
+
+func NewPublicProfileInfo(profile ProfileInfo) PublicProfileInfo {
+	return PublicProfileInfo{
+		Username:  profile.Username,
+		FirstName: profile.FirstName,
+		LastName:  profile.LastName,
+	}
+}
+
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
+
+func NewSessionInfo(session mongo_handlers.Session, currentSessionID string) SessionInfo {
+	return SessionInfo{
+		ID:         session.SessionID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		Current:    session.SessionID == currentSessionID,
+	}
+}
+
+type CreateAccessTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Zero means that token never expires
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+}
+
+type AccessTokenInfo struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	// Returned only once when token is created
+	Token string `json:"token,omitempty"`
+}
+
+func NewAccessTokenInfo(token mongo_handlers.PersonalAccessToken) AccessTokenInfo {
+	info := AccessTokenInfo{
+		ID:        token.TokenID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}
+	if !token.LastUsedAt.IsZero() {
+		info.LastUsedAt = &token.LastUsedAt
+	}
+	return info
 }
 
 type RegisterBody struct {
@@ -21,18 +140,34 @@ type RegisterBody struct {
 type TaskListRequest struct {
 	Offset   int32 `json:"offset"`
 	PageSize int32 `json:"page_size"`
+	// Empty means tasks outside of workspaces
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	// If set, only tasks with this status are returned
+	Status string `json:"status,omitempty"`
+	// If set, only unfinished tasks with deadline in the past are returned
+	Overdue bool `json:"overdue,omitempty"`
+	// If set, only unfinished tasks with deadline in the next `due_within_days` days are returned
+	DueWithinDays int32 `json:"due_within_days,omitempty"`
 }
 
 type CreateTaskRequest struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Status      string `json:"status"`
+	// Empty means that task is created outside of workspaces
+	WorkspaceID string     `json:"workspace_id,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
 }
 
 type UpdateTaskRequest struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Status      string `json:"status"`
+	// Empty means that priority is not changed
+	Priority string `json:"priority,omitempty"`
+	// Nil removes deadline of task
+	DueAt *time.Time `json:"due_at,omitempty"`
 }
 
 type LikeRequest struct {
@@ -48,7 +183,355 @@ type TaskID struct {
 }
 
 type TaskContent struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	WorkspaceID string     `json:"workspace_id,omitempty"`
+	Assignees   []string   `json:"assignees,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+type AssignTaskBody struct {
+	Username string `json:"username"`
+}
+
+// Task in lists of tasks. Names of fields follow JSON mapping of task service's protobuf messages
+type TaskInfo struct {
+	ID   int32           `json:"id,omitempty"`
+	Task TaskInfoContent `json:"task"`
+}
+
+type TaskInfoContent struct {
+	Title           string     `json:"title,omitempty"`
+	Description     string     `json:"description,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	CreatorUsername string     `json:"creatorUsername,omitempty"`
+	WorkspaceID     string     `json:"workspaceId,omitempty"`
+	Assignees       []string   `json:"assignees,omitempty"`
+	Priority        string     `json:"priority,omitempty"`
+	DueAt           *time.Time `json:"dueAt,omitempty"`
+	CreatedAt       *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt       *time.Time `json:"updatedAt,omitempty"`
+}
+
+func NewTaskInfo(task *task_servicepb.Task) TaskInfo {
+	return TaskInfo{
+		ID: task.Id,
+		Task: TaskInfoContent{
+			Title:           task.Task.GetTitle(),
+			Description:     task.Task.GetDescription(),
+			Status:          taskStatusName(task.Task.GetStatus()),
+			CreatorUsername: task.Task.GetCreatorUsername(),
+			WorkspaceID:     task.Task.GetWorkspaceId(),
+			Assignees:       task.Task.GetAssignees(),
+			Priority:        taskPriorityName(task.Task.GetPriority()),
+			DueAt:           timeOrNil(task.Task.GetDueAt()),
+			CreatedAt:       timeOrNil(task.Task.GetCreatedAt()),
+			UpdatedAt:       timeOrNil(task.Task.GetUpdatedAt()),
+		},
+	}
+}
+
+type TaskPage struct {
+	Tasks    []TaskInfo `json:"tasks,omitempty"`
+	PageSize int32      `json:"pageSize,omitempty"`
+}
+
+// Change of task's status. Creation of task has no `from` status
+type StatusTransitionInfo struct {
+	From           string    `json:"from,omitempty"`
+	To             string    `json:"to"`
+	Username       string    `json:"username,omitempty"`
+	TransitionedAt time.Time `json:"transitionedAt"`
+}
+
+func NewStatusTransitionInfo(transition *task_servicepb.StatusTransition) StatusTransitionInfo {
+	return StatusTransitionInfo{
+		From:           taskStatusName(transition.From),
+		To:             taskStatusName(transition.To),
+		Username:       transition.Username,
+		TransitionedAt: transition.TransitionedAt.AsTime(),
+	}
+}
+
+// Response of `Authenticate` when user has enabled two-factor authentication
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+	ExpiresIn         int    `json:"expiresIn"`
+}
+
+type TwoFactorLoginBody struct {
+	ChallengeToken string `json:"challengeToken"`
+	// TOTP code or recovery code
+	Code string `json:"code"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type TwoFactorCodeBody struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorBody struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type LockoutInfo struct {
+	ID          string     `json:"id"`
+	Key         string     `json:"key"`
+	Failures    int        `json:"failures"`
+	IP          string     `json:"ip"`
+	LockedAt    time.Time  `json:"lockedAt"`
+	LockedUntil time.Time  `json:"lockedUntil"`
+	ClearedBy   string     `json:"clearedBy,omitempty"`
+	ClearedAt   *time.Time `json:"clearedAt,omitempty"`
+}
+
+func NewLockoutInfo(event mongo_handlers.LockoutEvent) LockoutInfo {
+	return LockoutInfo{
+		ID:          event.ID,
+		Key:         event.Key,
+		Failures:    event.Failures,
+		IP:          event.IP,
+		LockedAt:    event.LockedAt,
+		LockedUntil: event.LockedUntil,
+		ClearedBy:   event.ClearedBy,
+		ClearedAt:   event.ClearedAt,
+	}
+}
+
+type DataExportInfo struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Set only if export has failed
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	// Set only when archive is ready
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+func NewDataExportInfo(export mongo_handlers.DataExport) DataExportInfo {
+	info := DataExportInfo{
+		ID:         export.ExportID,
+		Status:     export.Status,
+		Error:      export.Error,
+		CreatedAt:  export.CreatedAt,
+		FinishedAt: export.FinishedAt,
+		ExpiresAt:  export.ExpiresAt,
+	}
+	if isDataExportInterrupted(export) {
+		info.Status = DataExportFailed
+		info.Error = "export was interrupted, start a new one"
+	}
+	if info.Status == DataExportReady {
+		info.DownloadURL = "/profile/export/" + export.ExportID + "/download"
+	}
+	return info
+}
+
+// Profile in exported data. Unlike `ProfileInfo` it has time of registration and last update
+type ExportedProfile struct {
+	ProfileInfo
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// User as seen by admin. Unlike `ProfileInfo` it has state of account
+type AdminUserInfo struct {
+	ProfileInfo
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Users created by OIDC login have no password
+	HasPassword    bool       `json:"hasPassword"`
+	Disabled       bool       `json:"disabled"`
+	DisabledAt     *time.Time `json:"disabledAt,omitempty"`
+	DisabledBy     string     `json:"disabledBy,omitempty"`
+	DisabledReason string     `json:"disabledReason,omitempty"`
+	// Set only when single user is requested
+	Sessions []SessionInfo `json:"sessions,omitempty"`
+}
+
+func NewAdminUserInfo(user mongo_handlers.User) AdminUserInfo {
+	return AdminUserInfo{
+		ProfileInfo:    NewProfileInfo(user),
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		HasPassword:    user.Password != "",
+		Disabled:       user.Disabled,
+		DisabledAt:     user.DisabledAt,
+		DisabledBy:     user.DisabledBy,
+		DisabledReason: user.DisabledReason,
+	}
+}
+
+type AdminUserPage struct {
+	Users  []AdminUserInfo `json:"users"`
+	Offset int64           `json:"offset"`
+	Limit  int64           `json:"limit"`
+	Total  int64           `json:"total"`
+}
+
+type DisableUserBody struct {
+	Reason string `json:"reason"`
+}
+
+type TemporaryPassword struct {
+	TemporaryPassword string `json:"temporaryPassword"`
+}
+
+type AuditEventInfo struct {
+	ID        string    `json:"id"`
+	Actor     string    `json:"actor,omitempty"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Outcome   string    `json:"outcome"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewAuditEventInfo(event mongo_handlers.AuditEvent) AuditEventInfo {
+	return AuditEventInfo{
+		ID:        event.ID,
+		Actor:     event.Actor,
+		Action:    event.Action,
+		Target:    event.Target,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		Outcome:   event.Outcome,
+		Details:   event.Details,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+type AuditEventPage struct {
+	Events []AuditEventInfo `json:"events"`
+	Offset int64            `json:"offset"`
+	Limit  int64            `json:"limit"`
+}
+
+type CreateWorkspaceBody struct {
+	Name string `json:"name"`
+}
+
+type WorkspaceInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Role of requestor in workspace
+	Role string `json:"role"`
+	// Set only when single workspace is requested
+	Members []WorkspaceMemberInfo `json:"members,omitempty"`
+}
+
+func NewWorkspaceInfo(workspace mongo_handlers.Workspace, role string) WorkspaceInfo {
+	return WorkspaceInfo{
+		ID:        workspace.WorkspaceID,
+		Name:      workspace.Name,
+		Owner:     workspace.Owner,
+		CreatedAt: workspace.CreatedAt,
+		Role:      role,
+	}
+}
+
+type WorkspaceMemberInfo struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invitedBy,omitempty"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+func NewWorkspaceMemberInfo(member mongo_handlers.WorkspaceMember) WorkspaceMemberInfo {
+	return WorkspaceMemberInfo{
+		Username:  member.Username,
+		Role:      member.Role,
+		InvitedBy: member.InvitedBy,
+		JoinedAt:  member.JoinedAt,
+	}
+}
+
+type CreateInvitationBody struct {
+	// Empty username creates invitation by link
+	Username string `json:"username"`
+	// Member by default
+	Role string `json:"role"`
+}
+
+type InvitationInfo struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspaceId"`
+	// Set only in invitations of requestor
+	WorkspaceName string `json:"workspaceName,omitempty"`
+	// Set only in invitations of user
+	Username  string    `json:"username,omitempty"`
+	Role      string    `json:"role"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Returned only once when invitation by link is created
+	Token string `json:"token,omitempty"`
+	Link  string `json:"link,omitempty"`
+}
+
+func NewInvitationInfo(invitation mongo_handlers.WorkspaceInvitation) InvitationInfo {
+	return InvitationInfo{
+		ID:          invitation.InvitationID,
+		WorkspaceID: invitation.WorkspaceID,
+		Username:    invitation.Username,
+		Role:        invitation.Role,
+		CreatedBy:   invitation.CreatedBy,
+		CreatedAt:   invitation.CreatedAt,
+		ExpiresAt:   invitation.ExpiresAt,
+	}
+}
+
+type WorkflowTransitionBody struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Workflow of workspace's tasks. Statuses are names like `in_progress`
+type WorkflowBody struct {
+	InitialStatus    string                   `json:"initialStatus"`
+	Statuses         []string                 `json:"statuses"`
+	Transitions      []WorkflowTransitionBody `json:"transitions"`
+	TerminalStatuses []string                 `json:"terminalStatuses"`
+}
+
+func NewWorkflowBody(workflow *task_servicepb.Workflow) WorkflowBody {
+	body := WorkflowBody{
+		InitialStatus:    taskStatusName(workflow.InitialStatus),
+		Statuses:         []string{},
+		Transitions:      []WorkflowTransitionBody{},
+		TerminalStatuses: []string{},
+	}
+	for _, status := range workflow.Statuses {
+		body.Statuses = append(body.Statuses, taskStatusName(status))
+	}
+	for _, transition := range workflow.Transitions {
+		body.Transitions = append(body.Transitions, WorkflowTransitionBody{
+			From: taskStatusName(transition.From),
+			To:   taskStatusName(transition.To),
+		})
+	}
+	for _, status := range workflow.TerminalStatuses {
+		body.TerminalStatuses = append(body.TerminalStatuses, taskStatusName(status))
+	}
+	return body
 }
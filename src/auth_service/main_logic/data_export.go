@@ -0,0 +1,282 @@
+package auth_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mongo_handlers"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	task_servicepb "task_service/proto"
+)
+
+// Export of personal data
+//
+//	Export is started by user and built in background. Result is ZIP archive of JSON files:
+//	- profile.json: profile from Mongo
+//	- sessions.json: active sessions
+//	- tasks.json: user's tasks from task service
+//	- activity.json: tasks which were liked and viewed by user (from statistics service)
+//	- task_stats.json: statistics of user's tasks (from statistics service)
+//	Archives are stored in Mongo GridFS, so any replica of auth service can serve them,
+//	and are deleted together with export after `dataExportTTL`
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+
+	dataExportTTL = 24 * time.Hour
+	// Export which is still pending after this time was interrupted (e.g. auth service was restarted)
+	dataExportTimeout         = 10 * time.Minute
+	dataExportCleanupInterval = time.Hour
+	dataExportTasksPageSize   = 100
+	dataExportStepTimeout     = 10 * time.Second
+)
+
+func isDataExportInterrupted(export mongo_handlers.DataExport) bool {
+	return export.Status == DataExportPending && time.Since(export.CreatedAt) > dataExportTimeout
+}
+
+// Start export of user's data in background
+//
+//	If user already has pending export, it's returned instead of starting a new one (`created` is false)
+func StartDataExport(username string) (export mongo_handlers.DataExport, created bool, code int, err error) {
+	var exports []mongo_handlers.DataExport
+	code, err = userStore.GetUserDataExports(username, &exports)
+	if err != nil {
+		return export, false, code, err
+	}
+	for _, stored := range exports {
+		if stored.Status == DataExportPending && !isDataExportInterrupted(stored) {
+			return stored, false, http.StatusOK, nil
+		}
+	}
+
+	now := time.Now()
+	export = mongo_handlers.DataExport{
+		ExportID:  uuid.New().String(),
+		Username:  username,
+		Status:    DataExportPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(dataExportTTL),
+	}
+	code, err = userStore.CreateDataExport(export)
+	if err != nil {
+		return export, false, code, err
+	}
+
+	go RunDataExport(export)
+	return export, true, http.StatusOK, nil
+}
+
+// Build archive of export and save its status
+func RunDataExport(export mongo_handlers.DataExport) {
+	err := writeDataExport(export.Username, export.ExportID)
+
+	now := time.Now()
+	export.FinishedAt = &now
+	if err != nil {
+		log.Printf("Export %s of user `%s` failed: %s", export.ExportID, export.Username, err.Error())
+		export.Status = DataExportFailed
+		export.Error = err.Error()
+	} else {
+		export.Status = DataExportReady
+	}
+
+	if _, err := userStore.UpdateDataExport(export); err != nil {
+		log.Println("function `RunDataExport`:", err.Error())
+	}
+}
+
+// Collect user's data and store it as ZIP archive of export `exportID`
+//
+//	Archive is built in memory and stored at once, so incomplete archive is never downloaded
+func writeDataExport(username string, exportID string) error {
+	files, err := collectDataExportFiles(username)
+	if err != nil {
+		return err
+	}
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, file := range files {
+		fileWriter, err := writer.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("adding `%s` to export archive failed with error: %w", file.name, err)
+		}
+		if _, err := fileWriter.Write(file.content); err != nil {
+			return fmt.Errorf("writing `%s` to export archive failed with error: %w", file.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("writing export archive failed with error: %w", err)
+	}
+
+	_, err = userStore.StoreDataExportArchive(exportID, archive.Bytes())
+	return err
+}
+
+type dataExportFile struct {
+	name    string
+	content []byte
+}
+
+func collectDataExportFiles(username string) ([]dataExportFile, error) {
+	var files []dataExportFile
+	addFile := func(name string, data any) error {
+		content, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("json marshaler error: %w", err)
+		}
+		files = append(files, dataExportFile{name: name, content: content})
+		return nil
+	}
+
+	// Profile
+	var user mongo_handlers.User
+	if _, err := userStore.GetUser(username, &user); err != nil {
+		return nil, err
+	}
+	profile := ExportedProfile{
+		ProfileInfo: NewProfileInfo(user),
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+	if err := addFile("profile.json", profile); err != nil {
+		return nil, err
+	}
+
+	// Sessions
+	var sessions []mongo_handlers.Session
+	if _, err := sessionStore.GetUserSessions(username, &sessions); err != nil {
+		return nil, err
+	}
+	sessionsInfo := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		sessionsInfo = append(sessionsInfo, NewSessionInfo(session, ""))
+	}
+	if err := addFile("sessions.json", sessionsInfo); err != nil {
+		return nil, err
+	}
+
+	// Tasks
+	tasks, err := getUserTasks(username)
+	if err != nil {
+		return nil, err
+	}
+	tasksInfo := make([]TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		tasksInfo = append(tasksInfo, NewTaskInfo(task))
+	}
+	if err := addFile("tasks.json", tasksInfo); err != nil {
+		return nil, err
+	}
+
+	// Likes and views of user
+	activity, err := getStatisticsJSON("/users/" + url.PathEscape(username) + "/activity")
+	if err != nil {
+		return nil, err
+	}
+	if err := addFile("activity.json", activity); err != nil {
+		return nil, err
+	}
+
+	// Statistics of user's tasks
+	taskStats := make([]json.RawMessage, 0, len(tasks))
+	for _, task := range tasks {
+		stats, err := getStatisticsJSON(fmt.Sprintf("/tasks/%d/stats", task.Id))
+		if err != nil {
+			return nil, err
+		}
+		taskStats = append(taskStats, stats)
+	}
+	if err := addFile("task_stats.json", taskStats); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// Get every task of user (in every workspace) from task service page by page
+func getUserTasks(username string) ([]*task_servicepb.Task, error) {
+	var tasks []*task_servicepb.Task
+	for offset := int32(0); ; offset += dataExportTasksPageSize {
+		ctx, cancel := context.WithTimeout(context.Background(), dataExportStepTimeout)
+		page, err := taskServiceClient.GetTaskList(ctx, &task_servicepb.TaskPageRequest{
+			Offset:          offset,
+			PageSize:        dataExportTasksPageSize,
+			CreatorUsername: username,
+			AnyWorkspace:    true,
+		})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("grpc `GetTaskList` failed with message: %w", err)
+		}
+
+		tasks = append(tasks, page.Tasks...)
+		if len(page.Tasks) < dataExportTasksPageSize {
+			return tasks, nil
+		}
+	}
+}
+
+// Get JSON response of statistics service
+func getStatisticsJSON(path string) (json.RawMessage, error) {
+	resp, err := getStatistics(path)
+	if err != nil {
+		return nil, fmt.Errorf("statistics service cause a error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response of statistics service failed with error: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("statistics service responded to `%s` with status %d: %s", path, resp.StatusCode, body)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("statistics service responded to `%s` with invalid JSON", path)
+	}
+	return body, nil
+}
+
+// Delete archives of user's exports (when account is deleted)
+func deleteUserDataExports(username string) error {
+	var exports []mongo_handlers.DataExport
+	if _, err := userStore.GetUserDataExports(username, &exports); err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if _, err := userStore.DeleteDataExportArchive(export.ExportID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete archives which are older than `dataExportTTL`. Exports themselves are deleted by Mongo
+func CleanupDataExports() {
+	_, err := userStore.DeleteExpiredDataExportArchives(time.Now().Add(-dataExportTTL))
+	if err != nil {
+		log.Println("function `CleanupDataExports`:", err.Error())
+	}
+}
+
+// Periodically delete expired archives of exports
+func StartDataExportCleanupWorker() {
+	go func() {
+		CleanupDataExports()
+		for range time.Tick(dataExportCleanupInterval) {
+			CleanupDataExports()
+		}
+	}()
+}
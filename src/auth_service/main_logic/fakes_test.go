@@ -0,0 +1,648 @@
+package auth_service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mail_handlers"
+	"math/big"
+	"mongo_handlers"
+	"net/http"
+	"net/http/httptest"
+	"oidc_handlers"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"jwt_handlers"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	task_servicepb "task_service/proto"
+)
+
+func TestMain(m *testing.M) {
+	keysDir, err := os.MkdirTemp("", "jwt_keys")
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Setenv("JWT_KEYS_DIR", keysDir)
+	if err := jwt_handlers.InitJWTHandlers(); err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(keysDir)
+	os.Exit(code)
+}
+
+// Task service which keeps tasks in memory. Only authors and moderators can update and delete tasks and
+// changes of status should be allowed by workflow, as in real service
+type fakeTaskService struct {
+	mutex     sync.Mutex
+	tasks     map[int32]*task_servicepb.TaskContent
+	lastID    int32
+	workflows map[string]*task_servicepb.Workflow
+	history   map[int32][]*task_servicepb.StatusTransition
+	// If set, `DeleteUserTasks` fails as if service was unavailable
+	unavailable bool
+	// If set, `AssignTask` deletes task first as if it was deleted concurrently
+	deletedBeforeAssign bool
+}
+
+func newFakeTaskService() *fakeTaskService {
+	return &fakeTaskService{
+		tasks:     map[int32]*task_servicepb.TaskContent{},
+		workflows: map[string]*task_servicepb.Workflow{},
+		history:   map[int32][]*task_servicepb.StatusTransition{},
+	}
+}
+
+// Workflow of workspace. Default workflow is shorter than in real service: not taken -> in progress -> done
+func (s *fakeTaskService) workflow(workspaceID string) *task_servicepb.Workflow {
+	if workflow, ok := s.workflows[workspaceID]; ok {
+		return workflow
+	}
+	return &task_servicepb.Workflow{
+		WorkspaceId:   workspaceID,
+		InitialStatus: task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN,
+		Statuses: []task_servicepb.TaskStatus{
+			task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN,
+			task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS,
+			task_servicepb.TaskStatus_TASK_STATUS_DONE,
+		},
+		Transitions: []*task_servicepb.WorkflowTransition{
+			{From: task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN, To: task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS},
+			{From: task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS, To: task_servicepb.TaskStatus_TASK_STATUS_DONE},
+		},
+		TerminalStatuses: []task_servicepb.TaskStatus{task_servicepb.TaskStatus_TASK_STATUS_DONE},
+	}
+}
+
+func (s *fakeTaskService) recordTransition(taskID int32, from, to task_servicepb.TaskStatus, username string) {
+	s.history[taskID] = append(s.history[taskID], &task_servicepb.StatusTransition{
+		From:           from,
+		To:             to,
+		Username:       username,
+		TransitionedAt: timestamppb.Now(),
+	})
+}
+
+func (s *fakeTaskService) CreateTask(ctx context.Context, in *task_servicepb.TaskContent, opts ...grpc.CallOption) (*task_servicepb.TaskID, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	workflow := s.workflow(in.WorkspaceId)
+	taskStatus := in.Status
+	if taskStatus == task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		taskStatus = workflow.InitialStatus
+	}
+	if !slices.Contains(workflow.Statuses, taskStatus) {
+		return nil, status.Error(codes.FailedPrecondition, "status is not used by workflow")
+	}
+
+	priority := in.Priority
+	if priority == task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED {
+		priority = task_servicepb.TaskPriority_TASK_PRIORITY_P2
+	}
+
+	s.lastID++
+	s.tasks[s.lastID] = &task_servicepb.TaskContent{
+		Title:           in.Title,
+		Description:     in.Description,
+		Status:          taskStatus,
+		CreatorUsername: in.CreatorUsername,
+		WorkspaceId:     in.WorkspaceId,
+		Priority:        priority,
+		DueAt:           in.DueAt,
+		CreatedAt:       timestamppb.Now(),
+		UpdatedAt:       timestamppb.Now(),
+	}
+	s.recordTransition(s.lastID, task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED, taskStatus, in.CreatorUsername)
+	return &task_servicepb.TaskID{Id: s.lastID}, nil
+}
+
+func (s *fakeTaskService) UpdateTask(ctx context.Context, in *task_servicepb.Task, opts ...grpc.CallOption) (*task_servicepb.TaskID, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, ok := s.tasks[in.Id]
+	if !ok || (task.CreatorUsername != in.Task.CreatorUsername && in.RequestorRole < task_servicepb.UserRole_USER_ROLE_MODERATOR) {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+	if to := in.Task.Status; to != task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED && to != task.Status {
+		workflow := s.workflow(task.WorkspaceId)
+		allowed := false
+		for _, transition := range workflow.Transitions {
+			allowed = allowed || (transition.From == task.Status && transition.To == to)
+		}
+		if !allowed || slices.Contains(workflow.TerminalStatuses, task.Status) {
+			return nil, status.Error(codes.FailedPrecondition, "transition is not allowed by workflow")
+		}
+		s.recordTransition(in.Id, task.Status, to, in.Task.CreatorUsername)
+		task.Status = to
+	}
+	task.Title = in.Task.Title
+	task.Description = in.Task.Description
+	if in.Task.Priority != task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED {
+		task.Priority = in.Task.Priority
+	}
+	task.DueAt = in.Task.DueAt
+	task.UpdatedAt = timestamppb.Now()
+	return &task_servicepb.TaskID{Id: in.Id}, nil
+}
+
+func (s *fakeTaskService) DeleteTask(ctx context.Context, in *task_servicepb.RequestByID, opts ...grpc.CallOption) (*task_servicepb.TaskID, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, ok := s.tasks[in.Id]
+	if !ok || (task.CreatorUsername != in.RequestorUsername && in.RequestorRole < task_servicepb.UserRole_USER_ROLE_MODERATOR) {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+	delete(s.tasks, in.Id)
+	return &task_servicepb.TaskID{Id: in.Id}, nil
+}
+
+func (s *fakeTaskService) GetTaskById(ctx context.Context, in *task_servicepb.RequestByID, opts ...grpc.CallOption) (*task_servicepb.Task, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, ok := s.tasks[in.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+	return &task_servicepb.Task{Id: in.Id, Task: task}, nil
+}
+
+func (s *fakeTaskService) GetTaskList(ctx context.Context, in *task_servicepb.TaskPageRequest, opts ...grpc.CallOption) (*task_servicepb.TaskList, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if in.Overdue && in.DueWithinDays > 0 {
+		return nil, status.Error(codes.InvalidArgument, "overdue tasks and tasks due within days can't be requested together")
+	}
+	now := time.Now()
+	byDeadline := in.Overdue || in.DueWithinDays > 0
+	ids := make([]int32, 0, len(s.tasks))
+	for id, task := range s.tasks {
+		if byDeadline {
+			finished := slices.Contains(s.workflow(task.WorkspaceId).TerminalStatuses, task.Status)
+			if task.DueAt == nil || finished ||
+				(in.Overdue && !task.DueAt.AsTime().Before(now)) ||
+				(in.DueWithinDays > 0 && (task.DueAt.AsTime().Before(now) || task.DueAt.AsTime().After(now.AddDate(0, 0, int(in.DueWithinDays))))) {
+				continue
+			}
+		}
+		inWorkspace := in.AnyWorkspace || task.WorkspaceId == in.WorkspaceId
+		if len(in.WorkspaceIds) > 0 {
+			inWorkspace = slices.Contains(in.WorkspaceIds, task.WorkspaceId)
+		}
+		if (in.CreatorUsername == "" || task.CreatorUsername == in.CreatorUsername) && inWorkspace &&
+			(in.Status == task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED || task.Status == in.Status) &&
+			(in.InvolvedUsername == "" || task.CreatorUsername == in.InvolvedUsername || slices.Contains(task.Assignees, in.InvolvedUsername)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if byDeadline && !s.tasks[ids[i]].DueAt.AsTime().Equal(s.tasks[ids[j]].DueAt.AsTime()) {
+			return s.tasks[ids[i]].DueAt.AsTime().Before(s.tasks[ids[j]].DueAt.AsTime())
+		}
+		return ids[i] < ids[j]
+	})
+
+	list := &task_servicepb.TaskList{PageSize: in.PageSize}
+	for i := int(in.Offset); i < len(ids) && i < int(in.Offset+in.PageSize); i++ {
+		list.Tasks = append(list.Tasks, &task_servicepb.Task{Id: ids[i], Task: s.tasks[ids[i]]})
+	}
+	return list, nil
+}
+
+func (s *fakeTaskService) DeleteUserTasks(ctx context.Context, in *task_servicepb.UserRequest, opts ...grpc.CallOption) (*task_servicepb.DeletedTasks, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.unavailable {
+		return nil, status.Error(codes.Unavailable, "task service is unavailable")
+	}
+	var anonymized int32
+	var deletedTaskIDs []int32
+	for id, task := range s.tasks {
+		if (task.CreatorUsername == in.Username && task.WorkspaceId == "") || slices.Contains(in.DeletedWorkspaceIds, task.WorkspaceId) {
+			delete(s.tasks, id)
+			deletedTaskIDs = append(deletedTaskIDs, id)
+			continue
+		}
+		if task.CreatorUsername == in.Username {
+			task.CreatorUsername = ""
+			anonymized++
+		}
+		task.Assignees = slices.DeleteFunc(task.Assignees, func(assignee string) bool { return assignee == in.Username })
+	}
+	slices.Sort(deletedTaskIDs)
+	return &task_servicepb.DeletedTasks{Count: int32(len(deletedTaskIDs)), Anonymized: anonymized, DeletedTaskIds: deletedTaskIDs}, nil
+}
+
+func (s *fakeTaskService) GetWorkflow(ctx context.Context, in *task_servicepb.WorkflowRequest, opts ...grpc.CallOption) (*task_servicepb.Workflow, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.workflow(in.WorkspaceId), nil
+}
+
+func (s *fakeTaskService) SetWorkflow(ctx context.Context, in *task_servicepb.Workflow, opts ...grpc.CallOption) (*task_servicepb.Workflow, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !slices.Contains(in.Statuses, in.InitialStatus) {
+		return nil, status.Error(codes.InvalidArgument, "initial status is not a status of workflow")
+	}
+	for _, task := range s.tasks {
+		if task.WorkspaceId == in.WorkspaceId && !slices.Contains(in.Statuses, task.Status) {
+			return nil, status.Error(codes.FailedPrecondition, "some tasks have status which is not in workflow")
+		}
+	}
+	s.workflows[in.WorkspaceId] = in
+	return in, nil
+}
+
+func (s *fakeTaskService) GetStatusHistory(ctx context.Context, in *task_servicepb.RequestByID, opts ...grpc.CallOption) (*task_servicepb.StatusHistory, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.tasks[in.Id]; !ok {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+	return &task_servicepb.StatusHistory{Transitions: s.history[in.Id]}, nil
+}
+
+type publishedEvent struct {
+	Kind        string
+	Username    string
+	TaskID      int32
+	TaskAuthor  string
+	WorkspaceID string
+	// Formatted IDs of deleted tasks, so events stay comparable
+	TaskIDs string
+}
+
+func (s *fakeTaskService) AssignTask(ctx context.Context, in *task_servicepb.AssigneeRequest, opts ...grpc.CallOption) (*task_servicepb.TaskID, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.deletedBeforeAssign {
+		delete(s.tasks, in.TaskId)
+	}
+	task, ok := s.tasks[in.TaskId]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+	if task.CreatorUsername != in.RequestorUsername && in.RequestorRole < task_servicepb.UserRole_USER_ROLE_MODERATOR {
+		return nil, status.Error(codes.PermissionDenied, "requestor can't assign users")
+	}
+	if !slices.Contains(task.Assignees, in.Assignee) {
+		task.Assignees = append(task.Assignees, in.Assignee)
+		sort.Strings(task.Assignees)
+		task.UpdatedAt = timestamppb.Now()
+	}
+	return &task_servicepb.TaskID{Id: in.TaskId}, nil
+}
+
+func (s *fakeTaskService) UnassignTask(ctx context.Context, in *task_servicepb.AssigneeRequest, opts ...grpc.CallOption) (*task_servicepb.TaskID, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, ok := s.tasks[in.TaskId]
+	if !ok || !slices.Contains(task.Assignees, in.Assignee) ||
+		(in.Assignee != in.RequestorUsername && task.CreatorUsername != in.RequestorUsername && in.RequestorRole < task_servicepb.UserRole_USER_ROLE_MODERATOR) {
+		return nil, status.Error(codes.NotFound, "assignment not found")
+	}
+	task.Assignees = slices.DeleteFunc(task.Assignees, func(assignee string) bool { return assignee == in.Assignee })
+	task.UpdatedAt = timestamppb.Now()
+	return &task_servicepb.TaskID{Id: in.TaskId}, nil
+}
+
+// Remembers events instead of sending them to Kafka
+type fakeEventPublisher struct {
+	mutex  sync.Mutex
+	events []publishedEvent
+}
+
+func (p *fakeEventPublisher) record(event publishedEvent) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *fakeEventPublisher) CreateEmptyStatistics(taskID int32, taskAuthor string, workspaceID string) error {
+	return p.record(publishedEvent{Kind: "empty", TaskID: taskID, TaskAuthor: taskAuthor, WorkspaceID: workspaceID})
+}
+
+func (p *fakeEventPublisher) Like(liker string, taskID int32, taskAuthor string, workspaceID string) error {
+	return p.record(publishedEvent{Kind: "like", Username: liker, TaskID: taskID, TaskAuthor: taskAuthor, WorkspaceID: workspaceID})
+}
+
+func (p *fakeEventPublisher) View(viewer string, taskID int32, taskAuthor string, workspaceID string) error {
+	return p.record(publishedEvent{Kind: "view", Username: viewer, TaskID: taskID, TaskAuthor: taskAuthor, WorkspaceID: workspaceID})
+}
+
+func (p *fakeEventPublisher) UserDeleted(username string, deletedTaskIDs []int32) error {
+	return p.record(publishedEvent{Kind: "user_deleted", Username: username, TaskIDs: fmt.Sprint(deletedTaskIDs)})
+}
+
+// Remembers emails instead of sending them
+type fakeMailSender struct {
+	mutex    sync.Mutex
+	messages []mail_handlers.Message
+}
+
+func (s *fakeMailSender) Send(message mail_handlers.Message) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+// Statistics service which returns the same statistics for every task. Started by `newTestEnv`
+type fakeStatisticsService struct {
+	server *httptest.Server
+	// If set, every request fails with 500 (Status Internal Server Error)
+	failing atomic.Bool
+}
+
+func newFakeStatisticsService() *fakeStatisticsService {
+	service := &fakeStatisticsService{}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{username}/activity", func(w http.ResponseWriter, r *http.Request) {
+		if service.failing.Load() {
+			http.Error(w, "clickhouse is unavailable", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"username": mux.Vars(r)["username"],
+			"likes":    []map[string]any{{"task_id": 1, "task_author": "bob"}},
+			"views":    []map[string]any{},
+		})
+	})
+	router.HandleFunc("/tasks/{task_id}/stats", func(w http.ResponseWriter, r *http.Request) {
+		if service.failing.Load() {
+			http.Error(w, "clickhouse is unavailable", http.StatusInternalServerError)
+			return
+		}
+		taskID, _ := strconv.Atoi(mux.Vars(r)["task_id"])
+		json.NewEncoder(w).Encode(map[string]any{"task_id": taskID, "likes": 0, "views": 0})
+	})
+	// Tops are empty, requested workspace is returned to check that it's passed
+	router.HandleFunc("/top/tasks/{parameter}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"workspace_id": r.URL.Query().Get("workspace_id"), "tasks": []any{}})
+	})
+	router.HandleFunc("/top/users", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"workspace_id": r.URL.Query().Get("workspace_id"), "users": []any{}})
+	})
+	service.server = httptest.NewServer(router)
+	return service
+}
+
+// Router with all handlers which use in-memory storage and fake services
+type testEnv struct {
+	router *mux.Router
+	store  *MemoryStore
+	tasks  *fakeTaskService
+	events *fakeEventPublisher
+	mails  *fakeMailSender
+	stats  *fakeStatisticsService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	env := &testEnv{
+		router: NewRouter(),
+		store:  NewMemoryStore(),
+		tasks:  newFakeTaskService(),
+		events: &fakeEventPublisher{},
+		mails:  &fakeMailSender{},
+		stats:  newFakeStatisticsService(),
+	}
+	t.Cleanup(env.stats.server.Close)
+
+	SetStores(env.store, env.store)
+	SetTaskServiceClient(env.tasks)
+	SetEventPublisher(env.events)
+	mail_handlers.SetSender(env.mails)
+	SetStatisticsServiceURL(env.stats.server.URL)
+	return env
+}
+
+func newRequest(t *testing.T, method string, path string, body any) *http.Request {
+	t.Helper()
+
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+	}
+	return httptest.NewRequest(method, path, &reader)
+}
+
+func (env *testEnv) serve(request *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	env.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// Send request with JSON body (if `body` is not nil) and cookies to router
+func (env *testEnv) do(t *testing.T, method string, path string, body any, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := newRequest(t, method, path, body)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	return env.serve(request)
+}
+
+// Send request with JSON body (if `body` is not nil) authenticated by personal access token
+func (env *testEnv) doWithBearer(t *testing.T, method string, path string, body any, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := newRequest(t, method, path, body)
+	request.Header.Set("Authorization", "Bearer "+token)
+	return env.serve(request)
+}
+
+// Log in and return cookies of new session
+func (env *testEnv) login(t *testing.T, username string, password string) []*http.Cookie {
+	t.Helper()
+
+	resp := env.do(t, "POST", "/authenticate", AuthenticateBody{Username: username, Password: password}, nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("login `%s`: expected 200, got %d: %s", username, resp.Code, resp.Body.String())
+	}
+	return resp.Result().Cookies()
+}
+
+// Create admin `root` by BOOTSTRAP_ADMIN and return cookies of his session
+func (env *testEnv) loginAdmin(t *testing.T) []*http.Cookie {
+	t.Helper()
+
+	t.Setenv("BOOTSTRAP_ADMIN", "root")
+	t.Setenv("BOOTSTRAP_ADMIN_PASSWORD", "bootstrap password")
+	if err := BootstrapAdmin(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	return env.login(t, "root", "bootstrap password")
+}
+
+// Register user and return cookies of his session
+func (env *testEnv) register(t *testing.T, username string, password string) []*http.Cookie {
+	t.Helper()
+
+	resp := env.do(t, "POST", "/register", RegisterBody{Username: username, Password: password}, nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("register `%s`: expected 200, got %d: %s", username, resp.Code, resp.Body.String())
+	}
+	return resp.Result().Cookies()
+}
+
+func expectStatus(t *testing.T, resp *httptest.ResponseRecorder, expected int) {
+	t.Helper()
+
+	if resp.Code != expected {
+		t.Fatalf("expected status %d, got %d: %s", expected, resp.Code, resp.Body.String())
+	}
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// Identity provider which issues ID tokens signed by its own key. Every authorization code can be
+// exchanged once
+type fakeIdentityProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	codes  map[string]oidc_handlers.IDTokenClaims
+	issued int
+}
+
+const fakeOIDCClientID = "tasktracker"
+
+// Start identity provider and use it for OIDC login until the end of the test
+func newFakeIdentityProvider(t *testing.T) *fakeIdentityProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key of identity provider: %v", err)
+	}
+	idp := &fakeIdentityProvider{key: key, codes: map[string]oidc_handlers.IDTokenClaims{}}
+
+	router := http.NewServeMux()
+	router.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	router.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	router.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mutex.Lock()
+		claims, ok := idp.codes[r.Form.Get("code")]
+		delete(idp.codes, r.Form.Get("code"))
+		idp.mutex.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	idp.server = httptest.NewServer(router)
+	t.Cleanup(idp.server.Close)
+
+	oidc_handlers.SetProvider(oidc_handlers.NewProvider(oidc_handlers.Config{
+		IssuerURL:   idp.server.URL,
+		ClientID:    fakeOIDCClientID,
+		RedirectURL: "http://localhost:8080/oidc/callback",
+		Scopes:      []string{"openid"},
+	}))
+	t.Cleanup(func() { oidc_handlers.SetProvider(nil) })
+	return idp
+}
+
+// User logs in identity provider. Returns authorization code for login request with `nonce`
+func (idp *fakeIdentityProvider) login(nonce string, subject string, preferredUsername string) string {
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+
+	idp.issued++
+	code := fmt.Sprintf("code-%d", idp.issued)
+	idp.codes[code] = oidc_handlers.IDTokenClaims{
+		Nonce:             nonce,
+		PreferredUsername: preferredUsername,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    idp.server.URL,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{fakeOIDCClientID},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	return code
+}
+
+// User store which fails to link external identities
+type failingIdentityStore struct {
+	*MemoryStore
+}
+
+func (s failingIdentityStore) StoreExternalIdentity(identity mongo_handlers.ExternalIdentity) (code int, err error) {
+	return http.StatusInternalServerError, errors.New("failed to store external identity")
+}
@@ -1,51 +1,44 @@
 package auth_service
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
-	"kafka_handlers"
 	"log"
+	"mail_handlers"
 	"mongo_handlers"
 	"net/http"
+	"oidc_handlers"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"encoding/json"
+	"jwt_handlers"
 
-	"github.com/gogo/protobuf/jsonpb"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 
 	task_servicepb "task_service/proto"
 )
 
-var (
-	taskServiceGRPCConnection *grpc.ClientConn
-	taskServiceClient         task_servicepb.TaskServiceClient
-)
-
-func init() {
-	var err error
-	taskServiceURL, ok := os.LookupEnv("TASK_SERVICE_URL")
-	if !ok {
-		log.Fatal("No TASK_SERVICE_URL setted but should")
-	}
-	taskServiceGRPCConnection, err = grpc.NewClient(taskServiceURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	taskServiceClient = task_servicepb.NewTaskServiceClient(taskServiceGRPCConnection)
-}
-
 // Authentication handler
 //
 //	Method: POST
 //
-//	If password is incorrect returns 401 (Status Unauthorized)
+//	If user has enabled two-factor authentication, returns challenge token instead of
+//	starting session. Login should be finished by `AuthenticateTwoFactor`
+//
+//	If user doesn't exist or password is incorrect returns 401 (Status Unauthorized) with the same body in both cases
+//	If there were too many failed logins from the IP address returns 429 (Status Too Many Requests)
+//	If account is temporarily locked after too many failed logins returns 423 (Status Locked)
+//	If account is disabled by admin returns 403 (Status Forbidden)
 //	If internal error occurred returns 500 (Status Internal Server Error)
 //	If request body is not correct returns 400 (Status Bad Request)
 func Authenticate(w http.ResponseWriter, r *http.Request) {
@@ -59,46 +52,180 @@ func Authenticate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	storedUserData := make(map[string]string)
-	code, err := mongo_handlers.GetUserData(creds.Username, &storedUserData)
+	clientIP := GetClientIP(r)
+	lockedErr, code, err := CheckLoginAllowed(creds.Username, clientIP)
 	if err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
+	if lockedErr != nil {
+		RecordAuditEvent(r, "", AuditActionLogin, creds.Username, lockedErr, "")
+		lockedErr.Write(w)
+		return
+	}
 
-	storedPassword, ok := storedUserData["password"]
-	if !ok || storedPassword != HashPassword(creds.Password) {
-		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(creds.Username, &user)
+	if err != nil {
+		if code == http.StatusNotFound {
+			RecordFailedLogin(creds.Username, clientIP)
+			RecordAuditEvent(r, "", AuditActionLogin, creds.Username, err, "")
+			http.Error(w, errIncorrectCredentials.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, err.Error(), code)
 		return
 	}
 
-	tokenString, err := GenerateJWTToken(creds.Username)
+	// Users created by OIDC login have no password
+	if user.Password == "" {
+		RecordFailedLogin(creds.Username, clientIP)
+		RecordAuditEvent(r, "", AuditActionLogin, creds.Username, errors.New("user has no password"), "")
+		http.Error(w, errIncorrectCredentials.Error(), http.StatusUnauthorized)
+		return
+	}
+	matches, needsRehash, err := VerifyPassword(creds.Password, user.Password)
 	if err != nil {
+		err = fmt.Errorf("error in function `VerifyPassword` occurred: %w", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !matches {
+		RecordFailedLogin(creds.Username, clientIP)
+		RecordAuditEvent(r, "", AuditActionLogin, creds.Username, errors.New("incorrect password"), "")
+		http.Error(w, errIncorrectCredentials.Error(), http.StatusUnauthorized)
+		return
+	}
+	ResetFailedLogins(creds.Username)
+	if user.Disabled {
+		RecordAuditEvent(r, "", AuditActionLogin, creds.Username, errAccountDisabled, "")
+		http.Error(w, errAccountDisabled.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Upgrade legacy (md5) or outdated hash
+	if needsRehash {
+		code, err = SetUserPassword(creds.Username, creds.Password)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+	}
+
+	// With enabled two-factor authentication session is started only after `AuthenticateTwoFactor`
+	var twoFactor mongo_handlers.TwoFactor
+	code, err = userStore.GetTwoFactor(creds.Username, &twoFactor)
+	if err != nil && code != http.StatusNotFound {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if err == nil && twoFactor.Enabled {
+		challengeToken, err := GenerateTwoFactorChallenge(creds.Username)
+		// Password is correct, but user is authenticated only after second step
+		RecordAuditEvent(r, "", AuditActionTwoFactorChallenge, creds.Username, err, "")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http_resp_bytes, err := json.Marshal(TwoFactorChallenge{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresIn:         int(twoFactorChallengeTTL.Seconds()),
+		})
+		if err != nil {
+			err = fmt.Errorf("json marshaler error: %w", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(http_resp_bytes)
+		return
+	}
+
+	code, err = StartSession(w, r, creds.Username)
+	RecordAuditEvent(r, creds.Username, AuditActionLogin, creds.Username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// AuthenticateTwoFactor handler
+//
+//	Method: POST
+//
+//	Second step of login for users with enabled two-factor authentication.
+//	Accepts challenge token returned by `Authenticate` and TOTP code (or one-time recovery code)
+//
+//	If challenge token is invalid returns 400 (Status Bad Request)
+//	If challenge token has expired or code is incorrect returns 401 (Status Unauthorized)
+//	If there were too many wrong codes returns 429 (Status Too Many Requests)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+//	If request body is not correct returns 400 (Status Bad Request)
+func AuthenticateTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var creds TwoFactorLoginBody
+	err := json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var username string
+	code, err := ParseTwoFactorChallenge(creds.ChallengeToken, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
 
-	code, err = mongo_handlers.StoreUserToken(creds.Username, tokenString)
+	var twoFactor mongo_handlers.TwoFactor
+	code, err = userStore.GetTwoFactor(username, &twoFactor)
 	if err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
+	if !twoFactor.Enabled {
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
 
-	code, err = mongo_handlers.StoreUserData(creds.Username, storedUserData)
+	code, err = CheckTwoFactorCode(&twoFactor, creds.Code)
 	if err != nil {
+		RecordAuditEvent(r, "", AuditActionLogin, username, err, "two-factor code")
 		http.Error(w, err.Error(), code)
 		return
 	}
 
-	cookie := http.Cookie{
-		Name:  "token",
-		Value: tokenString,
+	code, err = StartSession(w, r, username)
+	RecordAuditEvent(r, username, AuditActionLogin, username, err, "two-factor code")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
 	}
-	http.SetCookie(w, &cookie)
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// JWKS handler
+//
+//	Method: GET
+//
+//	Returns public keys which can be used to verify tokens issued by this service
+func GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	http_resp_bytes, err := json.Marshal(jwt_handlers.GetKeyRing().JWKS())
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
 // Registration handler
 //
 //		Method: POST
@@ -107,6 +234,7 @@ func Authenticate(w http.ResponseWriter, r *http.Request) {
 //		If internal error occurred returns 500 (Status Internal Server Error)
 //		If request body is not correct returns 400 (Status Bad Request)
 //	 	If user with this username already exists returns 400 (Status Bad Request)
+//		If password is too short returns 400 (Status Bad Request)
 func Register(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
@@ -114,176 +242,3176 @@ func Register(w http.ResponseWriter, r *http.Request) {
 	var creds RegisterBody
 	err := json.NewDecoder(r.Body).Decode(&creds)
 	if err != nil {
+		RecordAuditEvent(r, "", AuditActionRegister, "", err, "malformed request body")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if mongo_handlers.CheckIfUserExists(creds.Username) {
+	if !IsUsernameAvailable(creds.Username) {
+		RecordAuditEvent(r, "", AuditActionRegister, creds.Username, errors.New("username is already taken"), "")
 		http.Error(w, "User with this Username does already exist", http.StatusBadRequest)
 		return
 	}
-
-	newUserData := map[string]string{
-		"username": creds.Username,
-		"password": HashPassword(creds.Password),
-	}
-	code, err := mongo_handlers.StoreUserData(creds.Username, newUserData)
-	if err != nil {
-		err = fmt.Errorf("error in function `StoreUserData` occurred: %w", err)
-		http.Error(w, err.Error(), code)
+	if err := ValidateNewPassword(creds.Password); err != nil {
+		RecordAuditEvent(r, "", AuditActionRegister, creds.Username, err, "")
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	tokenString, err := GenerateJWTToken(creds.Username)
+	hashedPassword, err := HashPassword(creds.Password)
 	if err != nil {
-		err = fmt.Errorf("error in function `GenerateJWTToken` occurred: %w", err)
+		RecordAuditEvent(r, "", AuditActionRegister, creds.Username, err, "")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	code, err = mongo_handlers.StoreUserToken(creds.Username, tokenString)
+
+	// Unique index on username protects from concurrent registrations with the same username
+	code, err := userStore.CreateUser(mongo_handlers.User{
+		Username: creds.Username,
+		Password: hashedPassword,
+	})
 	if err != nil {
-		err = fmt.Errorf("error in function `StoreUserToken` occurred: %w", err)
+		err = fmt.Errorf("error in function `CreateUser` occurred: %w", err)
+		RecordAuditEvent(r, "", AuditActionRegister, creds.Username, err, "")
 		http.Error(w, err.Error(), code)
 		return
 	}
 
-	// Make Cookie
-	cookie := http.Cookie{
-		Name:  "token",
-		Value: tokenString,
+	RecordAuditEvent(r, creds.Username, AuditActionRegister, creds.Username, nil, "")
+
+	// Make Cookies with access and refresh tokens
+	code, err = StartSession(w, r, creds.Username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
 	}
-	http.SetCookie(w, &cookie)
 
 	w.WriteHeader(http.StatusOK)
 }
 
-// UpdateMyProfile handler
+// OIDCLogin handler
 //
-//	Method: PUT
+//	Method: GET
 //
-//	If user is not authenticated returns 400 (Status Bad Request)
-//	If request body is not correct returns 400 (Status Bad Request)
+//	Redirects user to login page of external identity provider (authorization code flow with PKCE).
+//	With `?link=true` authenticated user links external identity to his account instead of logging in
+//
+//	If OIDC login is not configured returns 404 (Status Not Found)
+//	If `link=true` is set but user is not authenticated returns 400 (Status Bad Request)
+//	If identity provider is unavailable returns 502 (Status Bad Gateway)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func UpdateMyProfile(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+func OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider := oidc_handlers.GetProvider()
+	if provider == nil {
+		http.Error(w, "OIDC login is not configured", http.StatusNotFound)
+		return
+	}
 
-	var username string
-	code, err := CheckIfUserAuthenticated(r, &username)
+	var linkUsername string
+	if r.URL.Query().Get("link") == "true" {
+		code, err := CheckIfUserAuthenticated(r, &linkUsername)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+	}
+
+	state, err := oidc_handlers.GenerateRandomString()
 	if err != nil {
-		http.Error(w, err.Error(), code)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	nonce, err := oidc_handlers.GenerateRandomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	codeVerifier, codeChallenge, err := oidc_handlers.GeneratePKCE()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, codeChallenge)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
 
-	storedUserData := make(map[string]string)
-	code, err = mongo_handlers.GetUserData(username, &storedUserData)
+	code, err := sessionStore.StoreOIDCLoginState(mongo_handlers.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		LinkUsername: linkUsername,
+		ExpiresAt:    time.Now().Add(oidcLoginStateTTL),
+	})
 	if err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
 
-	// Decoding RequestBody
-	var creds ProfileInfo
-	err = json.NewDecoder(r.Body).Decode(&creds)
+	SetOIDCStateCookie(w, state)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback handler
+//
+//	Method: GET
+//
+//	Identity provider redirects user here after login. User with linked external identity is logged in,
+//	new user is created if identity is not linked to anyone yet.
+//	After login user is redirected to OIDC_POST_LOGIN_REDIRECT_URL if it's set
+//
+//	If OIDC login is not configured returns 404 (Status Not Found)
+//	If `state` is unknown, expired or already used returns 400 (Status Bad Request)
+//	If login was started in another browser (`state` doesn't match `oidc_state` cookie) returns 400 (Status Bad Request)
+//	If identity provider rejected login or returned invalid ID token returns 401 (Status Unauthorized)
+//	If identity is already linked to another user returns 409 (Status Conflict)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	provider := oidc_handlers.GetProvider()
+	if provider == nil {
+		http.Error(w, "OIDC login is not configured", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if errorCode := query.Get("error"); errorCode != "" {
+		http.Error(w, fmt.Sprintf("Identity provider returned error: %s %s", errorCode, query.Get("error_description")), http.StatusUnauthorized)
+		return
+	}
+	if query.Get("state") == "" || query.Get("code") == "" {
+		http.Error(w, "`state` and `code` parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	// Protection against login CSRF: callback should be opened in browser which started the login
+	stateCookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil || subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(query.Get("state"))) != 1 {
+		http.Error(w, "login was started in another browser, `state` doesn't match", http.StatusBadRequest)
+		return
+	}
+	ClearOIDCStateCookie(w)
+
+	var loginState mongo_handlers.OIDCLoginState
+	code, err := sessionStore.PopOIDCLoginState(query.Get("state"), &loginState)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
 
-	// Updating by new information
-	if creds.FirstName != "" {
-		storedUserData["firstName"] = creds.FirstName
+	claims, err := provider.Exchange(r.Context(), query.Get("code"), loginState.CodeVerifier)
+	if err != nil {
+		RecordAuditEvent(r, "", AuditActionLogin, loginState.LinkUsername, err, "oidc")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
-	if creds.LastName != "" {
-		storedUserData["lastName"] = creds.LastName
+	if claims.Nonce != loginState.Nonce {
+		RecordAuditEvent(r, "", AuditActionLogin, loginState.LinkUsername, errors.New("ID token has incorrect nonce"), "oidc")
+		http.Error(w, "ID token has incorrect nonce", http.StatusUnauthorized)
+		return
 	}
-	if creds.Birthday != "" {
-		storedUserData["birthday"] = creds.Birthday
+
+	identity := mongo_handlers.ExternalIdentity{
+		Issuer:   provider.Issuer(),
+		Subject:  claims.Subject,
+		Username: loginState.LinkUsername,
+		Email:    claims.Email,
+		LinkedAt: time.Now(),
 	}
-	if creds.Email != "" {
-		storedUserData["email"] = creds.Email
+
+	// Link identity to account of already authenticated user
+	if loginState.LinkUsername != "" {
+		code, err = userStore.StoreExternalIdentity(identity)
+		RecordAuditEvent(r, identity.Username, AuditActionLinkIdentity, identity.Username, err, "issuer: "+identity.Issuer)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		w.Write([]byte("External identity linked succesfully\n"))
+		return
 	}
-	if creds.PhoneNumber != "" {
-		storedUserData["phone"] = creds.PhoneNumber
+
+	var storedIdentity mongo_handlers.ExternalIdentity
+	code, err = userStore.GetExternalIdentity(identity.Issuer, identity.Subject, &storedIdentity)
+	switch {
+	case err == nil:
+		identity.Username = storedIdentity.Username
+	case code == http.StatusNotFound:
+		// First login, so user should be created
+		identity.Username, code, err = ProvisionExternalUser(claims)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		code, err = userStore.StoreExternalIdentity(identity)
+		if err != nil {
+			// User without linked identity can't log in, and the next login would create one more user
+			if _, deleteErr := userStore.DeleteUser(identity.Username); deleteErr != nil {
+				log.Printf("Failed to delete user `%s` whose external identity wasn't stored: %v", identity.Username, deleteErr)
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		RecordAuditEvent(r, identity.Username, AuditActionRegister, identity.Username, nil, "oidc issuer: "+identity.Issuer)
+		log.Printf("User `%s` created for external identity %s (issuer %s)", identity.Username, identity.Subject, identity.Issuer)
+	default:
+		http.Error(w, err.Error(), code)
+		return
 	}
 
-	// Store updated info into Mongo
-	code, err = mongo_handlers.StoreUserData(username, storedUserData)
+	code, err = StartSession(w, r, identity.Username)
+	RecordAuditEvent(r, identity.Username, AuditActionLogin, identity.Username, err, "oidc issuer: "+identity.Issuer)
 	if err != nil {
 		http.Error(w, err.Error(), code)
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if redirectURL := os.Getenv("OIDC_POST_LOGIN_REDIRECT_URL"); redirectURL != "" {
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+		return
+	}
+	w.Write([]byte(fmt.Sprintf("Logged in as %s\n", identity.Username)))
 }
 
-// CreateTask handler
+// ChangePassword handler
 //
-//	Method: POST
+//	Method: PUT
+//
+//	Sets new password. Every other session of the user is revoked
 //
 //	If user is not authenticated returns 400 (Status Bad Request)
-//	If request body is not correct returns 400 (Status Bad Request)
+//	If old password is incorrect returns 401 (Status Unauthorized)
+//	If new password is too weak returns 400 (Status Bad Request)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func CreateTask(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+//	If request body is not correct returns 400 (Status Bad Request)
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
-	var username string
-	code, err := CheckIfUserAuthenticated(r, &username)
+	var authInfo AuthInfo
+	code, err := GetAuthInfo(r, &authInfo)
 	if err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
 
-	// Decoding request body
-	var creds CreateTaskRequest
-	err = json.NewDecoder(r.Body).Decode(&creds)
+	var body ChangePasswordBody
+	err = json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Send request to Task Service by GRPC
-	IDHolder, err := taskServiceClient.CreateTask(context.Background(), &task_servicepb.TaskContent{
-		Title:           creds.Title,
-		Description:     creds.Description,
-		Status:          creds.Status,
-		CreatorUsername: username,
-	})
+	code, err = CheckUserPassword(authInfo.Username, body.OldPassword)
 	if err != nil {
-		err = fmt.Errorf("grpc `CreateTask` request failed with error: %w", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		RecordAuditEvent(r, authInfo.Username, AuditActionChangePassword, authInfo.Username, err, "")
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if err := ValidateNewPassword(body.NewPassword); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Send message to Kafka that new task was created so we need to create empty statistics ({likes: 0, views: 0})
-	err = kafka_handlers.CreateEmptyStatistics(IDHolder.Id, username)
+	code, err = SetUserPassword(authInfo.Username, body.NewPassword)
+	if err == nil {
+		code, err = RevokeAllSessions(authInfo.Username, authInfo.SessionID)
+	}
+	RecordAuditEvent(r, authInfo.Username, AuditActionChangePassword, authInfo.Username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Write([]byte("Password has been changed succesfully\n"))
+}
+
+// RequestPasswordReset handler
+//
+//	Method: POST
+//
+//	Sends single-use password reset token to user's email. Response is the same
+//	whether user exists or not, so it can't be used to find out registered usernames
+//
+//	If internal error occurred returns 500 (Status Internal Server Error)
+//	If request body is not correct returns 400 (Status Bad Request)
+func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var body PasswordResetRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Username == "" {
+		http.Error(w, "Request body should have `username`", http.StatusBadRequest)
+		return
+	}
+
+	const response = "If the user exists and has a verified email, password reset instructions have been sent to it\n"
+
+	var user mongo_handlers.User
+	code, err := userStore.GetUser(body.Username, &user)
+	if err != nil {
+		if code == http.StatusNotFound {
+			RecordAuditEvent(r, "", AuditActionRequestPasswordReset, body.Username, err, "")
+			w.Write([]byte(response))
+		} else {
+			http.Error(w, err.Error(), code)
+		}
+		return
+	}
+	// Unverified email could belong to someone else
+	email := user.Email
+	if email == "" || !user.EmailVerified {
+		log.Printf("Password reset for user `%s` is requested, but user has no verified email", body.Username)
+		w.Write([]byte(response))
+		return
+	}
+
+	token, err := generateRandomToken()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	now := time.Now()
+	code, err = userStore.StorePasswordReset(mongo_handlers.PasswordReset{
+		TokenHash: HashToken(token),
+		Username:  body.Username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(passwordResetTTL),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
 
-	// Write answer into http response
-	http_resp := TaskID{TaskID: IDHolder.Id}
-	http_resp_bytes, err := json.Marshal(http_resp)
+	err = mail_handlers.GetSender().Send(NewPasswordResetMessage(email, body.Username, token))
+	if err != nil {
+		err = fmt.Errorf("failed to send password reset email: %w", err)
+	}
+	RecordAuditEvent(r, "", AuditActionRequestPasswordReset, body.Username, err, "")
 	if err != nil {
-		err = fmt.Errorf("json marshaler failed to marshal new task's id but task was already created with id %v. Error message: %w", IDHolder.Id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Write(http_resp_bytes)
+
+	w.Write([]byte(response))
+}
+
+// ConfirmPasswordReset handler
+//
+//	Method: POST
+//
+//	Sets new password by token from `RequestPasswordReset`. Every session of the user is revoked
+//
+//	If token is unknown, already used or expired returns 400 (Status Bad Request)
+//	If new password is too weak returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+//	If request body is not correct returns 400 (Status Bad Request)
+func ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var body PasswordResetConfirmBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Check password before token is spent
+	if err := ValidateNewPassword(body.NewPassword); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reset mongo_handlers.PasswordReset
+	code, err := userStore.PopPasswordReset(HashToken(body.Token), &reset)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	code, err = SetUserPassword(reset.Username, body.NewPassword)
+	if err == nil {
+		code, err = RevokeAllSessions(reset.Username, "")
+	}
+	RecordAuditEvent(r, "", AuditActionResetPasswordByEmail, reset.Username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	ResetFailedLogins(reset.Username)
+
+	w.Write([]byte("Password has been reset succesfully, log in with the new password\n"))
+}
+
+// Refresh handler
+//
+//	Method: POST
+//
+//	Exchanges refresh token from Cookie for new access and refresh tokens.
+//	Every refresh token can be used only once. If used token is presented again
+//	then whole chain of tokens is revoked, because the token was probably stolen
+//
+//	If refresh token is missing, expired, revoked or reused returns 401 (Status Unauthorized)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func Refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	cookie, err := r.Cookie(refreshTokenCookieName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	tokenHash := HashToken(cookie.Value)
+
+	var storedToken mongo_handlers.RefreshToken
+	code, err := sessionStore.GetRefreshToken(tokenHash, &storedToken)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	if storedToken.Revoked {
+		http.Error(w, "Refresh token has been revoked", http.StatusUnauthorized)
+		return
+	}
+	if time.Now().After(storedToken.ExpiresAt) {
+		http.Error(w, "Refresh token has expired", http.StatusUnauthorized)
+		return
+	}
+
+	marked, err := sessionStore.MarkRefreshTokenUsed(tokenHash)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !marked {
+		// Token reuse: revoke whole family together with its session (and so its access tokens)
+		code, err = sessionStore.RevokeRefreshTokenFamily(storedToken.FamilyID)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		code, err = sessionStore.RevokeSession(storedToken.Username, storedToken.FamilyID)
+		if err != nil && code != http.StatusNotFound {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		log.Printf("Refresh token reuse detected for user `%s`, session %s has been revoked", storedToken.Username, storedToken.FamilyID)
+		RecordAuditEvent(r, "", AuditActionRefreshTokenReuse, storedToken.Username, errors.New("refresh token has already been used"), "session "+storedToken.FamilyID)
+		http.Error(w, "Refresh token has already been used", http.StatusUnauthorized)
+		return
+	}
+
+	code, err = IssueTokens(w, storedToken.Username, storedToken.FamilyID)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Logout handler
+//
+//	Method: POST
+//
+//	Revokes current session and clears Cookies with tokens
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var authInfo AuthInfo
+	code, err := GetAuthInfo(r, &authInfo)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	code, err = RevokeSession(authInfo.Username, authInfo.SessionID)
+	RecordAuditEvent(r, authInfo.Username, AuditActionLogout, authInfo.Username, err, "session "+authInfo.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	ClearAuthCookies(w)
+	w.Write([]byte("Logged out succesfully\n"))
+}
+
+// LogoutAll handler
+//
+//	Method: POST
+//
+//	Revokes every session of the user (on all devices) and clears Cookies with tokens
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func LogoutAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	code, err = RevokeAllSessions(username, "")
+	RecordAuditEvent(r, username, AuditActionLogoutAll, username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	ClearAuthCookies(w)
+	w.Write([]byte("Logged out from all devices succesfully\n"))
+}
+
+// CreatePersonalAccessToken handler
+//
+//	Method: POST
+//
+//	Creates named personal access token with given scopes. Token is returned only once,
+//	it should be sent in `Authorization: Bearer <token>` header
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If request is authenticated by personal access token returns 403 (Status Forbidden)
+//	If request body is not correct or scope is unknown returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func CreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var creds CreateAccessTokenRequest
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if creds.Name == "" {
+		http.Error(w, "Token's name should not be empty", http.StatusBadRequest)
+		return
+	}
+	if len(creds.Scopes) == 0 {
+		http.Error(w, "Token should have at least one scope", http.StatusBadRequest)
+		return
+	}
+	for _, scope := range creds.Scopes {
+		if !slices.Contains(allScopes, scope) {
+			http.Error(w, fmt.Sprintf("Unknown scope `%s`", scope), http.StatusBadRequest)
+			return
+		}
+	}
+	if creds.ExpiresInDays < 0 {
+		http.Error(w, "Token's lifetime should not be negative", http.StatusBadRequest)
+		return
+	}
+
+	tokenString, err := GeneratePersonalAccessToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token := mongo_handlers.PersonalAccessToken{
+		TokenID:   uuid.New().String(),
+		Username:  username,
+		Name:      creds.Name,
+		Scopes:    creds.Scopes,
+		TokenHash: HashToken(tokenString),
+		CreatedAt: time.Now(),
+	}
+	if creds.ExpiresInDays > 0 {
+		expiresAt := token.CreatedAt.AddDate(0, 0, creds.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	code, err = sessionStore.StorePersonalAccessToken(token)
+	RecordAuditEvent(r, username, AuditActionCreateAccessToken, username, err, "token "+token.TokenID)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := NewAccessTokenInfo(token)
+	http_resp.Token = tokenString
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// GetPersonalAccessTokens handler
+//
+//	Method: GET
+//
+//	Returns user's personal access tokens (without tokens themselves)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If request is authenticated by personal access token returns 403 (Status Forbidden)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var tokens []mongo_handlers.PersonalAccessToken
+	code, err = sessionStore.GetUserPersonalAccessTokens(username, &tokens)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := make([]AccessTokenInfo, 0, len(tokens))
+	for _, token := range tokens {
+		http_resp = append(http_resp, NewAccessTokenInfo(token))
+	}
+
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// DeletePersonalAccessToken handler
+//
+//	Method: DELETE
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If request is authenticated by personal access token returns 403 (Status Forbidden)
+//	If token doesn't exist or belongs to another user returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func DeletePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	tokenID := mux.Vars(r)["token_id"]
+	code, err = sessionStore.RevokePersonalAccessToken(username, tokenID)
+	RecordAuditEvent(r, username, AuditActionRevokeAccessToken, username, err, "token "+tokenID)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Write([]byte("Token has been revoked succesfully\n"))
+}
+
+// GetSessions handler
+//
+//	Method: GET
+//
+//	Returns list of user's active sessions (devices)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var authInfo AuthInfo
+	code, err := GetAuthInfo(r, &authInfo)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var sessions []mongo_handlers.Session
+	code, err = sessionStore.GetUserSessions(authInfo.Username, &sessions)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		http_resp = append(http_resp, NewSessionInfo(session, authInfo.SessionID))
+	}
+
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// DeleteSession handler
+//
+//	Method: DELETE
+//
+//	Revokes one of user's sessions, so its tokens can't be used anymore
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If session doesn't exist or belongs to another user returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func DeleteSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	sessionID := mux.Vars(r)["session_id"]
+	code, err = RevokeSession(username, sessionID)
+	RecordAuditEvent(r, username, AuditActionRevokeSession, username, err, "session "+sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Write([]byte("Session has been revoked succesfully\n"))
+}
+
+// EnrollTwoFactor handler
+//
+//	Method: POST
+//
+//	Generates new TOTP secret. Two-factor authentication is enabled only after
+//	the first code is confirmed by `ConfirmTwoFactor`
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If two-factor authentication is already enabled returns 409 (Status Conflict)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var twoFactor mongo_handlers.TwoFactor
+	code, err = userStore.GetTwoFactor(username, &twoFactor)
+	if err != nil && code != http.StatusNotFound {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if err == nil && twoFactor.Enabled {
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	code, err = userStore.StoreTwoFactor(mongo_handlers.TwoFactor{
+		Username:  username,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: TOTPProvisioningURI(username, secret),
+	})
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// ConfirmTwoFactor handler
+//
+//	Method: POST
+//
+//	Enables two-factor authentication if code matches secret from `EnrollTwoFactor`.
+//	Returns one-time recovery codes, they are shown only once
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If enrollment was not started returns 404 (Status Not Found)
+//	If two-factor authentication is already enabled returns 409 (Status Conflict)
+//	If code is incorrect returns 401 (Status Unauthorized)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+//	If request body is not correct returns 400 (Status Bad Request)
+func ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var body TwoFactorCodeBody
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var twoFactor mongo_handlers.TwoFactor
+	code, err = userStore.GetTwoFactor(username, &twoFactor)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if twoFactor.Enabled {
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	step, ok, err := VerifyTOTPCode(twoFactor.Secret, body.Code, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Incorrect two-factor code", http.StatusUnauthorized)
+		return
+	}
+
+	recoveryCodes, err := GenerateRecoveryCodes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	twoFactor.RecoveryCodeHashes = make([]string, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		twoFactor.RecoveryCodeHashes = append(twoFactor.RecoveryCodeHashes, HashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+	twoFactor.Enabled = true
+	twoFactor.EnabledAt = now
+	twoFactor.LastUsedStep = step
+
+	code, err = userStore.StoreTwoFactor(twoFactor)
+	RecordAuditEvent(r, username, AuditActionEnableTwoFactor, username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(RecoveryCodes{RecoveryCodes: recoveryCodes})
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// RegenerateRecoveryCodes handler
+//
+//	Method: POST
+//
+//	Replaces all recovery codes by new ones. Requires current TOTP code
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If two-factor authentication is not enabled returns 404 (Status Not Found)
+//	If code is incorrect returns 401 (Status Unauthorized)
+//	If there were too many wrong codes returns 429 (Status Too Many Requests)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+//	If request body is not correct returns 400 (Status Bad Request)
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var body TwoFactorCodeBody
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var twoFactor mongo_handlers.TwoFactor
+	code, err = userStore.GetTwoFactor(username, &twoFactor)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if !twoFactor.Enabled {
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusNotFound)
+		return
+	}
+
+	code, err = CheckTwoFactorCode(&twoFactor, body.Code)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	recoveryCodes, err := GenerateRecoveryCodes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Reload settings, because checking the code has changed them
+	code, err = userStore.GetTwoFactor(username, &twoFactor)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	twoFactor.RecoveryCodeHashes = make([]string, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		twoFactor.RecoveryCodeHashes = append(twoFactor.RecoveryCodeHashes, HashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+	code, err = userStore.StoreTwoFactor(twoFactor)
+	RecordAuditEvent(r, username, AuditActionRegenerateRecoveryCodes, username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(RecoveryCodes{RecoveryCodes: recoveryCodes})
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// DisableTwoFactor handler
+//
+//	Method: POST
+//
+//	Disables two-factor authentication. Requires password and TOTP code (or recovery code)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If two-factor authentication is not set up returns 404 (Status Not Found)
+//	If password or code is incorrect returns 401 (Status Unauthorized)
+//	If there were too many wrong codes returns 429 (Status Too Many Requests)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+//	If request body is not correct returns 400 (Status Bad Request)
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var body DisableTwoFactorBody
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, err = CheckUserPassword(username, body.Password)
+	if err != nil {
+		RecordAuditEvent(r, username, AuditActionDisableTwoFactor, username, err, "")
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var twoFactor mongo_handlers.TwoFactor
+	code, err = userStore.GetTwoFactor(username, &twoFactor)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	// Unconfirmed enrollment can be dropped without code
+	if twoFactor.Enabled {
+		code, err = CheckTwoFactorCode(&twoFactor, body.Code)
+		if err != nil {
+			RecordAuditEvent(r, username, AuditActionDisableTwoFactor, username, err, "")
+			http.Error(w, err.Error(), code)
+			return
+		}
+	}
+
+	code, err = userStore.DeleteTwoFactor(username)
+	RecordAuditEvent(r, username, AuditActionDisableTwoFactor, username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Write([]byte("Two-factor authentication has been disabled\n"))
+}
+
+// GetLockouts handler
+//
+//	Method: GET
+//
+//	Returns the latest lockout events. With `?active=true` returns only lockouts which are not over yet.
+//	Query parameters `offset` and `limit` select page (default limit: 100, maximal: 1000)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If user is not admin returns 403 (Status Forbidden)
+//	If query parameters are not correct returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetLockouts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	offset, limit, err := ParsePageQuery(r, defaultLockoutsPageSize, maxLockoutsPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var events []mongo_handlers.LockoutEvent
+	code, err := userStore.GetLockoutEvents(r.URL.Query().Get("active") == "true", offset, limit, &events)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	lockouts := make([]LockoutInfo, 0, len(events))
+	for _, event := range events {
+		lockouts = append(lockouts, NewLockoutInfo(event))
+	}
+	http_resp_bytes, err := json.Marshal(lockouts)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// ClearLockout handler
+//
+//	Method: DELETE
+//
+//	Unlocks account (`user:<username>`) or IP address (`ip:<address>`) and resets its failed logins counter
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If user is not admin returns 403 (Status Forbidden)
+//	If key is not correct returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func ClearLockout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	key := mux.Vars(r)["key"]
+	if err := validateLockoutKey(key); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, err := userStore.ClearLockout(key, username)
+	RecordAuditEvent(r, username, AuditActionClearLockout, key, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	log.Printf("Lockout of `%s` has been cleared by `%s`", key, username)
+	w.Write([]byte("Lockout has been cleared succesfully\n"))
+}
+
+// SetUserRole handler
+//
+//	Method: PUT
+//
+//	Sets role of user (`user`, `moderator` or `admin`). New role is applied when user's access token
+//	is renewed. Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If role is unknown or admin changes his own role returns 400 (Status Bad Request)
+//	If request body is not correct returns 400 (Status Bad Request)
+//	If user doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func SetUserRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	// Decoding request body
+	var creds SetRoleBody
+	err := json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := mongo_handlers.ValidateRole(creds.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Otherwise the only admin could leave service without admins
+	target := mux.Vars(r)["username"]
+	if target == username {
+		http.Error(w, "Admin can't change his own role", http.StatusBadRequest)
+		return
+	}
+
+	code, err := userStore.UpdateUser(target, mongo_handlers.UserUpdate{Role: &creds.Role})
+	RecordAuditEvent(r, username, AuditActionSetRole, target, err, "role: "+creds.Role)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	log.Printf("Role of `%s` has been set to `%s` by `%s`", target, creds.Role, username)
+	w.Write([]byte("Role has been changed succesfully\n"))
+}
+
+// GetUsers handler
+//
+//	Method: GET
+//
+//	Returns page of users. `query` query parameter searches users by substring of username, name or email
+//	(case insensitive). `offset` and `limit` set the page (default: 0 and 50, `limit` is at most 100).
+//	Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If query parameters are not correct returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	query := r.URL.Query().Get("query")
+	offset, limit, err := ParsePageQuery(r, defaultUsersPageSize, maxUsersPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var page mongo_handlers.UserPage
+	code, err := userStore.SearchUsers(query, offset, limit, &page)
+	RecordAuditEvent(r, username, AuditActionListUsers, "", err, "query: "+query)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	users := make([]AdminUserInfo, 0, len(page.Users))
+	for _, user := range page.Users {
+		users = append(users, NewAdminUserInfo(user))
+	}
+	http_resp_bytes, err := json.Marshal(AdminUserPage{
+		Users:  users,
+		Offset: offset,
+		Limit:  limit,
+		Total:  page.Total,
+	})
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// GetUser handler
+//
+//	Method: GET
+//
+//	Returns user's profile, state of account and active sessions. Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If requested user doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	target := mux.Vars(r)["username"]
+	var user mongo_handlers.User
+	code, err := userStore.GetUser(target, &user)
+	RecordAuditEvent(r, username, AuditActionViewUser, target, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var sessions []mongo_handlers.Session
+	code, err = sessionStore.GetUserSessions(target, &sessions)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	info := NewAdminUserInfo(user)
+	for _, session := range sessions {
+		info.Sessions = append(info.Sessions, NewSessionInfo(session, ""))
+	}
+	http_resp_bytes, err := json.Marshal(info)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// DisableUser handler
+//
+//	Method: POST
+//
+//	Disables user's account with optional reason and revokes all its sessions. Disabled user can't log in
+//	and his tokens are not accepted until account is enabled. Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If admin disables his own account returns 400 (Status Bad Request)
+//	If request body is not correct returns 400 (Status Bad Request)
+//	If user doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func DisableUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	// Body is optional
+	var creds DisableUserBody
+	if r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&creds)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Otherwise the only admin could lock himself out of service
+	target := mux.Vars(r)["username"]
+	if target == username {
+		http.Error(w, "Admin can't disable his own account", http.StatusBadRequest)
+		return
+	}
+
+	disabled := true
+	code, err := userStore.UpdateUser(target, mongo_handlers.UserUpdate{
+		Disabled:       &disabled,
+		DisabledBy:     &username,
+		DisabledReason: &creds.Reason,
+	})
+	if err == nil {
+		code, err = RevokeAllSessions(target, "")
+	}
+	RecordAuditEvent(r, username, AuditActionDisableUser, target, err, creds.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	log.Printf("User `%s` has been disabled by `%s`", target, username)
+	w.Write([]byte("User has been disabled succesfully\n"))
+}
+
+// EnableUser handler
+//
+//	Method: POST
+//
+//	Enables account which was disabled by `DisableUser`. Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If user doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func EnableUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	target := mux.Vars(r)["username"]
+	disabled := false
+	empty := ""
+	code, err := userStore.UpdateUser(target, mongo_handlers.UserUpdate{
+		Disabled:       &disabled,
+		DisabledBy:     &empty,
+		DisabledReason: &empty,
+	})
+	RecordAuditEvent(r, username, AuditActionEnableUser, target, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	log.Printf("User `%s` has been enabled by `%s`", target, username)
+	w.Write([]byte("User has been enabled succesfully\n"))
+}
+
+// LogoutUser handler
+//
+//	Method: POST
+//
+//	Revokes all sessions of user, so he has to log in again. Personal access tokens are not revoked.
+//	Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If user doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func LogoutUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	target := mux.Vars(r)["username"]
+	var user mongo_handlers.User
+	code, err := userStore.GetUser(target, &user)
+	if err == nil {
+		code, err = RevokeAllSessions(target, "")
+	}
+	RecordAuditEvent(r, username, AuditActionLogoutUser, target, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Write([]byte("All sessions of user have been revoked\n"))
+}
+
+// ResetUserPassword handler
+//
+//	Method: POST
+//
+//	Sets random temporary password for user and revokes all his sessions. Temporary password is returned
+//	only once, admin should pass it to user who should change it after login.
+//	Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If user doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	password, err := generateRandomToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	target := mux.Vars(r)["username"]
+	var user mongo_handlers.User
+	code, err := userStore.GetUser(target, &user)
+	if err == nil {
+		code, err = SetUserPassword(target, password)
+	}
+	if err == nil {
+		code, err = RevokeAllSessions(target, "")
+	}
+	RecordAuditEvent(r, username, AuditActionResetPassword, target, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	ResetFailedLogins(target)
+	log.Printf("Password of `%s` has been reset by `%s`", target, username)
+
+	http_resp_bytes, err := json.Marshal(TemporaryPassword{TemporaryPassword: password})
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// GetAuditLog handler
+//
+//	Method: GET
+//
+//	Returns events of security audit log, the latest first. Events are filtered by `actor`, `action`,
+//	`target`, `ip` and `outcome` query parameters and time range [`from`, `to`) in RFC 3339 format.
+//	`offset` and `limit` set the page (default: 0 and 100, `limit` is at most 1000).
+//	Only admins can perform this request (see routes)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If query parameters are not correct returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Request is authenticated by `RequireRole`
+	username := requestAuthInfo(r).Username
+
+	offset, limit, err := ParsePageQuery(r, defaultAuditPageSize, maxAuditPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	filter := mongo_handlers.AuditEventFilter{
+		Actor:   query.Get("actor"),
+		Action:  query.Get("action"),
+		Target:  query.Get("target"),
+		IP:      query.Get("ip"),
+		Outcome: query.Get("outcome"),
+	}
+	if filter.Outcome != "" && filter.Outcome != AuditOutcomeSuccess && filter.Outcome != AuditOutcomeFailure {
+		http.Error(w, fmt.Sprintf("`outcome` should be `%s` or `%s`", AuditOutcomeSuccess, AuditOutcomeFailure), http.StatusBadRequest)
+		return
+	}
+	filter.From, err = parseTimeQuery(r, "from")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.To, err = parseTimeQuery(r, "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var events []mongo_handlers.AuditEvent
+	code, err := userStore.GetAuditEvents(filter, offset, limit, &events)
+	RecordAuditEvent(r, username, AuditActionViewAuditLog, "", err, "query: "+r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	page := AuditEventPage{
+		Events: make([]AuditEventInfo, 0, len(events)),
+		Offset: offset,
+		Limit:  limit,
+	}
+	for _, event := range events {
+		page.Events = append(page.Events, NewAuditEventInfo(event))
+	}
+	http_resp_bytes, err := json.Marshal(page)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// GetMyProfile handler
+//
+//	Method: GET
+//
+//	Returns full profile of the authenticated user
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetMyProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(username, &user)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(NewProfileInfo(user))
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// GetUserProfile handler
+//
+//	Method: GET
+//
+//	Returns public part of another user's profile (username, first and last name)
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If requested user doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var requestor string
+	code, err := CheckIfUserAuthenticated(r, &requestor, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(mux.Vars(r)["username"], &user)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(NewPublicProfileInfo(NewProfileInfo(user)))
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// UpdateMyProfile handler
+//
+//	Method: PUT
+//
+//	Email is changed only after it's verified: verification link is sent to the new email
+//	and response has 202 (Status Accepted) code
+//
+//	If new email is not valid returns 400 (Status Bad Request)
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If request body is not correct returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func UpdateMyProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(username, &user)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Decoding RequestBody
+	var creds ProfileInfo
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Updating by new information
+	var update mongo_handlers.UserUpdate
+	if creds.FirstName != "" {
+		update.FirstName = &creds.FirstName
+	}
+	if creds.LastName != "" {
+		update.LastName = &creds.LastName
+	}
+	if creds.Birthday != "" {
+		update.Birthday = &creds.Birthday
+	}
+	if creds.PhoneNumber != "" {
+		update.PhoneNumber = &creds.PhoneNumber
+	}
+
+	// New email is not saved until user confirms it by link from verification email
+	newEmail := ""
+	if creds.Email != "" && creds.Email == user.Email {
+		update.PendingEmail = &newEmail
+	}
+	if creds.Email != "" && creds.Email != user.Email {
+		newEmail = creds.Email
+		update.PendingEmail = &newEmail
+	}
+
+	if err := update.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Store updated info into Mongo
+	code, err = userStore.UpdateUser(username, update)
+	RecordAuditEvent(r, username, AuditActionUpdateProfile, username, err, profileUpdateDetails(update))
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	if newEmail != "" {
+		code, err = SendEmailVerification(username, newEmail)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteMyProfile handler
+//
+//	Method: DELETE
+//
+//	Deletes user's account, sessions and tasks. Workspaces owned by user are handed over to their
+//	oldest admin (or the oldest member), workspaces without other members are deleted. User's tasks
+//	in workspaces are kept for other members without creator. Likes, views and statistics of user's tasks are
+//	deleted by statistics service. If task service or Kafka is unavailable, account is deleted
+//	but cleanup is finished later and response has 202 (Status Accepted) code.
+//	Users without password (created by OIDC login) should set password by reset first
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If request body is not correct returns 400 (Status Bad Request)
+//	If password is incorrect returns 401 (Status Unauthorized)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func DeleteMyProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Decoding request body
+	var creds DeleteProfileBody
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, err = CheckUserPassword(username, creds.Password)
+	if err != nil {
+		RecordAuditEvent(r, username, AuditActionDeleteProfile, username, err, "")
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	completed, code, err := DeleteAccount(username)
+	RecordAuditEvent(r, username, AuditActionDeleteProfile, username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	ClearAuthCookies(w)
+	if !completed {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("Account has been deleted. Tasks and statistics will be deleted later\n"))
+		return
+	}
+	w.Write([]byte("Account has been deleted\n"))
+}
+
+// ExportMyData handler
+//
+//	Method: POST
+//
+//	Starts export of everything what is stored about user: profile, sessions, tasks, likes, views
+//	and statistics of user's tasks. Export is built in background, response has 202 (Status Accepted)
+//	code and export which can be polled by GET /profile/export/{export_id}.
+//	If user already has pending export, it's returned instead of starting a new one
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func ExportMyData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	export, _, code, err := StartDataExport(username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(NewDataExportInfo(export))
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Location", "/profile/export/"+export.ExportID)
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(http_resp_bytes)
+}
+
+// GetMyDataExport handler
+//
+//	Method: GET
+//
+//	Returns status of export. When status is `ready`, archive can be downloaded by `downloadUrl`
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If export doesn't exist, has expired or belongs to another user returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetMyDataExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var export mongo_handlers.DataExport
+	code, err = userStore.GetDataExport(username, mux.Vars(r)["export_id"], &export)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(NewDataExportInfo(export))
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// DownloadMyDataExport handler
+//
+//	Method: GET
+//
+//	Returns ZIP archive with exported data
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If export doesn't exist, has expired or belongs to another user returns 404 (Status Not Found)
+//	If export is not ready returns 409 (Status Conflict)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func DownloadMyDataExport(w http.ResponseWriter, r *http.Request) {
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var export mongo_handlers.DataExport
+	code, err = userStore.GetDataExport(username, mux.Vars(r)["export_id"], &export)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if info := NewDataExportInfo(export); info.Status != DataExportReady {
+		http.Error(w, "export is "+info.Status, http.StatusConflict)
+		return
+	}
+
+	var archive []byte
+	code, err = userStore.GetDataExportArchive(export.ExportID, &archive)
+	if err != nil {
+		if code == http.StatusNotFound {
+			http.Error(w, "export archive not found, start a new export", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"tasktracker-export-%s.zip\"", export.CreatedAt.Format("2006-01-02")))
+	http.ServeContent(w, r, "", *export.FinishedAt, bytes.NewReader(archive))
+}
+
+// VerifyEmail handler
+//
+//	Method: GET
+//
+//	Opened by link from verification email. Makes pending email the user's email
+//
+//	If token is invalid returns 400 (Status Bad Request)
+//	If link has expired returns 401 (Status Unauthorized)
+//	If email has been changed again after the link was sent returns 409 (Status Conflict)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var username, email string
+	code, err := ParseEmailVerificationToken(r.URL.Query().Get("token"), &username, &email)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(username, &user)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	if user.Email == email && user.EmailVerified {
+		w.Write([]byte("Email has already been verified\n"))
+		return
+	}
+	if user.PendingEmail != email {
+		http.Error(w, "Email has been changed after this link was sent, use the link from the latest email", http.StatusConflict)
+		return
+	}
+
+	verified := true
+	noPendingEmail := ""
+	code, err = userStore.UpdateUser(username, mongo_handlers.UserUpdate{
+		Email:         &email,
+		EmailVerified: &verified,
+		PendingEmail:  &noPendingEmail,
+	})
+	RecordAuditEvent(r, username, AuditActionVerifyEmail, username, err, "")
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Write([]byte("Email has been verified succesfully\n"))
+}
+
+// CreateTask handler
+//
+//	Method: POST
+//
+//	Task is created in workspace `workspace_id` if it's set, only members of workspace can create tasks there.
+//	Without `status` task gets initial status of workspace's workflow, without `priority` task gets `P2`.
+//	`due_at` is optional deadline of task
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:write` scope returns 403 (Status Forbidden)
+//	If request body is not correct, status or priority is unknown returns 400 (Status Bad Request)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If status is not used by workflow of workspace returns 409 (Status Conflict)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func CreateTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksWrite)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Decoding request body
+	var creds CreateTaskRequest
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	taskStatus, err := parseTaskStatus(creds.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	priority, err := parseTaskPriority(creds.Priority)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if creds.WorkspaceID != "" {
+		var member mongo_handlers.WorkspaceMember
+		code, err = checkWorkspaceRole(creds.WorkspaceID, username, mongo_handlers.WorkspaceRoleMember, &member)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+	}
+
+	// Send request to Task Service by GRPC
+	IDHolder, err := taskServiceClient.CreateTask(context.Background(), &task_servicepb.TaskContent{
+		Title:           creds.Title,
+		Description:     creds.Description,
+		Status:          taskStatus,
+		CreatorUsername: username,
+		WorkspaceId:     creds.WorkspaceID,
+		Priority:        priority,
+		DueAt:           timestampOrNil(creds.DueAt),
+	})
+	if err != nil {
+		code = workflowErrorCode(err)
+		err = fmt.Errorf("grpc `CreateTask` request failed with error: %w", err)
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Send message to Kafka that new task was created so we need to create empty statistics ({likes: 0, views: 0})
+	err = eventPublisher.CreateEmptyStatistics(IDHolder.Id, username, creds.WorkspaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Write answer into http response
+	http_resp := TaskID{TaskID: IDHolder.Id}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler failed to marshal new task's id but task was already created with id %v. Error message: %w", IDHolder.Id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// UpdateTask handler
+//
+//	Method: PUT
+//
+//	Admins of task's workspace can update every task of workspace. Without `status` task keeps
+//	its status, new status should be reachable from the current one by workflow of workspace.
+//	Without `priority` task keeps its priority, without `due_at` task has no deadline anymore.
+//	Assignees can't update task (even its status) unless they can edit it by other rights
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:write` scope returns 403 (Status Forbidden)
+//	If task with this ID doesn't exist, requestor is not a member of task's workspace or
+//	requestor is neither an author of the task nor moderator returns 400 (Status Bad Request)
+//	If request body is not correct, status or priority is unknown returns 400 (Status Bad Request)
+//	If workflow doesn't allow transition to new status returns 409 (Status Conflict)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func UpdateTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Check if user is authenticated and get his username and role
+	var authInfo AuthInfo
+	code, err := GetAuthInfo(r, &authInfo, ScopeTasksWrite)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Decoding request body
+	var creds UpdateTaskRequest
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	taskStatus, err := parseTaskStatus(creds.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	priority, err := parseTaskPriority(creds.Priority)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get variable from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+
+	var member mongo_handlers.WorkspaceMember
+	_, code, err = getVisibleTask(taskID, authInfo.Username, &member)
+	if err != nil {
+		if code == http.StatusNotFound {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var task task_servicepb.Task
+	task.Id = taskID
+	task.Task = &task_servicepb.TaskContent{
+		Title:           creds.Title,
+		Description:     creds.Description,
+		Status:          taskStatus,
+		CreatorUsername: authInfo.Username,
+		Priority:        priority,
+		DueAt:           timestampOrNil(creds.DueAt),
+	}
+	task.RequestorRole = taskEditorRole(authInfo, member)
+
+	// Send request to Task Service by GRPC
+	// If requestor is not author of task (and not moderator) then request returns error `NotFound`
+	_, err = taskServiceClient.UpdateTask(context.Background(), &task)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = fmt.Errorf("grpc request `UpdateTask` failed because task with id=%v doesn't exists or requestor is not an author. Error message: %w", task.Id, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else if status.Code(err) == codes.FailedPrecondition {
+			err = fmt.Errorf("grpc request `UpdateTask` failed because workflow doesn't allow new status. Error message: %w", err)
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else {
+			err = fmt.Errorf("grpc request `UpdateTask` failed with error message: %w", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Write([]byte("Task has been updated succesfully\n"))
+}
+
+// DeleteTask handler
+//
+//	Method: DELETE
+//
+//	Admins of task's workspace can delete every task of workspace
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:write` scope returns 403 (Status Forbidden)
+//	If task with this ID doesn't exist, requestor is not a member of task's workspace or
+//	requestor is neither an author of the task nor moderator returns 400 (Status Bad Request)
+//	If request body is not correct returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func DeleteTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Check if user is authenticated and get his username and role
+	var authInfo AuthInfo
+	code, err := GetAuthInfo(r, &authInfo, ScopeTasksWrite)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+
+	var member mongo_handlers.WorkspaceMember
+	_, code, err = getVisibleTask(taskID, authInfo.Username, &member)
+	if err != nil {
+		if code == http.StatusNotFound {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Send request to Task Service by GRPC
+	// If requestor is not author of task (and not moderator) then request returns error `NotFound`
+	_, err = taskServiceClient.DeleteTask(context.Background(), &task_servicepb.RequestByID{
+		Id:                taskID,
+		RequestorUsername: authInfo.Username,
+		RequestorRole:     taskEditorRole(authInfo, member),
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = fmt.Errorf("requestor is not an author of the task. GRPC's error message: %w", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			err = fmt.Errorf("grpc request `DeleteTask` failed with error message: %w", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Write([]byte("Task has been deleted succesfully"))
+}
+
+// GetTask handler
+//
+//	Method: GET
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If request body is not correct returns 400 (Status Bad Request)
+//	If task doesn't exist or requestor is not a member of task's workspace returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+
+	// Send request to Task Service by GRPC
+	var member mongo_handlers.WorkspaceMember
+	grpc_resp, code, err := getVisibleTask(taskID, username, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := TaskContent{
+		Title:       grpc_resp.Task.Title,
+		Description: grpc_resp.Task.Description,
+		Status:      taskStatusName(grpc_resp.Task.Status),
+		WorkspaceID: grpc_resp.Task.WorkspaceId,
+		Assignees:   grpc_resp.Task.Assignees,
+		Priority:    taskPriorityName(grpc_resp.Task.Priority),
+		DueAt:       timeOrNil(grpc_resp.Task.DueAt),
+		CreatedAt:   timeOrNil(grpc_resp.Task.CreatedAt),
+		UpdatedAt:   timeOrNil(grpc_resp.Task.UpdatedAt),
+	}
+
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(http_resp_bytes)
+}
+
+// GetTaskStatusHistory handler
+//
+//	Method: GET
+//
+//	Returns changes of task's status, the oldest first. The first change is creation of task
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If task's ID is not correct returns 400 (Status Bad Request)
+//	If task doesn't exist or requestor is not a member of task's workspace returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetTaskStatusHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+
+	var member mongo_handlers.WorkspaceMember
+	_, code, err = getVisibleTask(taskID, username, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	grpc_resp, err := taskServiceClient.GetStatusHistory(context.Background(), &task_servicepb.RequestByID{
+		Id:                taskID,
+		RequestorUsername: username,
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			http.Error(w, "task with this id doesn't exist", http.StatusNotFound)
+		} else {
+			err = fmt.Errorf("grpc `GetStatusHistory` failed with message: %w", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http_resp := []StatusTransitionInfo{}
+	for _, transition := range grpc_resp.Transitions {
+		http_resp = append(http_resp, NewStatusTransitionInfo(transition))
+	}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// AssignTask handler
+//
+//	Method: POST
+//
+//	Assigns user to task. Author of task, moderators and admins of task's workspace can assign users.
+//	Tasks of workspace can be assigned only to its members. Assigning user twice is not an error
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:write` scope returns 403 (Status Forbidden)
+//	If request body is not correct returns 400 (Status Bad Request)
+//	If assignee is not a member of task's workspace returns 400 (Status Bad Request)
+//	If requestor can't assign users to the task returns 403 (Status Forbidden)
+//	If task doesn't exist or requestor is not a member of task's workspace returns 404 (Status Not Found)
+//	If assignee doesn't exist returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func AssignTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Check if user is authenticated and get his username and role
+	var authInfo AuthInfo
+	code, err := GetAuthInfo(r, &authInfo, ScopeTasksWrite)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+
+	// Decoding request body
+	var creds AssignTaskBody
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var member mongo_handlers.WorkspaceMember
+	task, code, err := getVisibleTask(taskID, authInfo.Username, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Assignee should exist and see the task
+	var assignee mongo_handlers.User
+	code, err = userStore.GetUser(creds.Username, &assignee)
+	if err != nil {
+		if code == http.StatusNotFound {
+			err = fmt.Errorf("user `%s` doesn't exist", creds.Username)
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if workspaceID := task.Task.GetWorkspaceId(); workspaceID != "" {
+		var assigneeMember mongo_handlers.WorkspaceMember
+		code, err = userStore.GetWorkspaceMember(workspaceID, creds.Username, &assigneeMember)
+		if err != nil {
+			if code == http.StatusNotFound {
+				code = http.StatusBadRequest
+				err = fmt.Errorf("user `%s` is not a member of task's workspace", creds.Username)
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+	}
+
+	// Send request to Task Service by GRPC
+	// If requestor is not author of task (and not moderator or admin of workspace) then request returns
+	// error `PermissionDenied`, if task has been deleted meanwhile it returns `NotFound`
+	_, err = taskServiceClient.AssignTask(context.Background(), &task_servicepb.AssigneeRequest{
+		TaskId:            taskID,
+		Assignee:          creds.Username,
+		RequestorUsername: authInfo.Username,
+		RequestorRole:     taskEditorRole(authInfo, member),
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.PermissionDenied:
+			http.Error(w, "only author of the task, moderators and admins of task's workspace can assign users", http.StatusForbidden)
+		case codes.NotFound:
+			http.Error(w, "task not found", http.StatusNotFound)
+		default:
+			err = fmt.Errorf("grpc request `AssignTask` failed with error message: %w", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Write([]byte("User has been assigned to the task\n"))
+}
+
+// UnassignTask handler
+//
+//	Method: DELETE
+//
+//	Removes user from assignees of task. Author of task, moderators, admins of task's workspace and
+//	assignee himself can perform this request
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:write` scope returns 403 (Status Forbidden)
+//	If task doesn't exist or requestor is not a member of task's workspace returns 404 (Status Not Found)
+//	If user is not assigned to the task or requestor can't unassign him returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func UnassignTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Check if user is authenticated and get his username and role
+	var authInfo AuthInfo
+	code, err := GetAuthInfo(r, &authInfo, ScopeTasksWrite)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variables from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+	assignee := mux.Vars(r)["username"]
+
+	var member mongo_handlers.WorkspaceMember
+	_, code, err = getVisibleTask(taskID, authInfo.Username, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Send request to Task Service by GRPC
+	_, err = taskServiceClient.UnassignTask(context.Background(), &task_servicepb.AssigneeRequest{
+		TaskId:            taskID,
+		Assignee:          assignee,
+		RequestorUsername: authInfo.Username,
+		RequestorRole:     taskEditorRole(authInfo, member),
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = fmt.Errorf("user `%s` is not assigned to the task or requestor can't unassign him", assignee)
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			err = fmt.Errorf("grpc request `UnassignTask` failed with error message: %w", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Write([]byte("User has been unassigned from the task\n"))
+}
+
+// GetTaskPage handler
+//
+//	Method: GET
+//
+//	Returns tasks of workspace `workspace_id`. Without `workspace_id` returns tasks outside of workspaces.
+//	If `status` is set, only tasks with this status are returned. `overdue` returns unfinished tasks with
+//	deadline in the past, `due_within_days` returns unfinished tasks with deadline in the next days,
+//	both views are sorted by deadline
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If request body is not correct or status is unknown returns 400 (Status Bad Request)
+//	If `overdue` and `due_within_days` are used together returns 400 (Status Bad Request)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetTaskPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Decoding request body
+	var creds TaskListRequest
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	taskStatus, err := parseTaskStatus(creds.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = checkDeadlineView(creds.Overdue, creds.DueWithinDays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if creds.WorkspaceID != "" {
+		var member mongo_handlers.WorkspaceMember
+		code, err = checkWorkspaceRole(creds.WorkspaceID, username, mongo_handlers.WorkspaceRoleMember, &member)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+	}
+
+	// Send requset to Task Service by GRPC
+	grpc_resp, err := taskServiceClient.GetTaskList(context.Background(), &task_servicepb.TaskPageRequest{
+		Offset:        creds.Offset,
+		PageSize:      creds.PageSize,
+		WorkspaceId:   creds.WorkspaceID,
+		Status:        taskStatus,
+		Overdue:       creds.Overdue,
+		DueWithinDays: creds.DueWithinDays,
+	})
+	if err != nil {
+		err = fmt.Errorf("grpc `GetTaskList` failed with message: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http_resp := TaskPage{PageSize: grpc_resp.PageSize}
+	for _, task := range grpc_resp.Tasks {
+		http_resp.Tasks = append(http_resp.Tasks, NewTaskInfo(task))
+	}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(http_resp_bytes)
+}
+
+// GetMyTasks handler
+//
+//	Method: GET
+//
+//	Returns tasks created by requestor or assigned to him from every workspace where requestor is a member
+//	and outside of workspaces. `offset` and `limit` set the page (default: 0 and 50, `limit` is at most 100),
+//	`status` filters tasks by status, `overdue` and `due_within_days` work as in `GetTaskPage`
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If query parameters are not correct or status is unknown returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetMyTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	offset, limit, err := ParsePageQuery(r, defaultMyTasksPageSize, maxMyTasksPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	taskStatus, err := parseTaskStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	overdue, dueWithinDays, err := parseDeadlineQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Tasks of workspaces which requestor has left are not visible to him anymore
+	var members []mongo_handlers.WorkspaceMember
+	code, err = userStore.GetUserWorkspaceMembers(username, &members)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	workspaceIDs := []string{""}
+	for _, member := range members {
+		workspaceIDs = append(workspaceIDs, member.WorkspaceID)
+	}
+
+	// Send requset to Task Service by GRPC
+	grpc_resp, err := taskServiceClient.GetTaskList(context.Background(), &task_servicepb.TaskPageRequest{
+		Offset:           int32(offset),
+		PageSize:         int32(limit),
+		Status:           taskStatus,
+		InvolvedUsername: username,
+		WorkspaceIds:     workspaceIDs,
+		Overdue:          overdue,
+		DueWithinDays:    dueWithinDays,
+	})
+	if err != nil {
+		err = fmt.Errorf("grpc `GetTaskList` failed with message: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http_resp := TaskPage{PageSize: grpc_resp.PageSize}
+	for _, task := range grpc_resp.Tasks {
+		http_resp.Tasks = append(http_resp.Tasks, NewTaskInfo(task))
+	}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(http_resp_bytes)
+}
+
+// View handler
+//
+//	Method: POST
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If task doesn't exist or requestor is not a member of task's workspace returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func View(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+
+	// Send requset to Task Service by GRPC to get task's author name and workspace
+	// If task doesn't exists or requestor is not a member of its workspace returns 404
+	var member mongo_handlers.WorkspaceMember
+	grpc_resp, code, err := getVisibleTask(taskID, username, &member)
+	if err != nil {
+		if code == http.StatusNotFound {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Send view to Kafka
+	err = eventPublisher.View(username, taskID, grpc_resp.Task.CreatorUsername, grpc_resp.Task.WorkspaceId)
+	if err != nil {
+		err = fmt.Errorf("`view` message sending caused a error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write([]byte("Task has been viewed succesfully\n"))
+}
+
+// Like handler
+//
+//	Method: POST
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:write` scope returns 403 (Status Forbidden)
+//	If task doesn't exist or requestor is not a member of task's workspace returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func LikeTaskPost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksWrite)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	taskIdString := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(taskIdString)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+	taskID := int32(taskIDInt)
+
+	// Send requset to Task Service by GRPC to get task's author name and workspace
+	// If task doesn't exists or requestor is not a member of its workspace returns 404
+	var member mongo_handlers.WorkspaceMember
+	grpc_resp, code, err := getVisibleTask(taskID, username, &member)
+	if err != nil {
+		if code == http.StatusNotFound {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Send like to Kafka
+	err = eventPublisher.Like(username, taskID, grpc_resp.Task.CreatorUsername, grpc_resp.Task.WorkspaceId)
+	if err != nil {
+		err = fmt.Errorf("`like` message sending caused a error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write([]byte("Task has been liked succesfully\n"))
+}
+
+// GetTaskStats handler
+//
+//	Method: GET
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `stats:read` scope returns 403 (Status Forbidden)
+//	If task's id is not correct returns 400 (Status Bad Request)
+//	If task doesn't exist or requestor is not a member of task's workspace returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetTaskStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeStatsRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	task_id := mux.Vars(r)["task_id"]
+	taskIDInt, err := strconv.Atoi(task_id)
+	if err != nil {
+		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		return
+	}
+
+	// Statistics of task is visible only to those who can see the task
+	var member mongo_handlers.WorkspaceMember
+	_, code, err = getVisibleTask(int32(taskIDInt), username, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get statistics for task from Statistics Service
+	resp, err := getStatistics("/tasks/" + task_id + "/stats")
+	if err != nil {
+		err = fmt.Errorf("statistics service cause a error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	CopyResponseToWriter(w, resp)
+}
+
+// GetTopTasks handler
+//
+//	Method: GET
+//
+//	Top is built of tasks of workspace `workspace_id` query parameter. Without it top is built of tasks
+//	outside of workspaces
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `stats:read` scope returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetTopTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his username
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeStatsRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get variable from URL
+	parameter := mux.Vars(r)["parameter"]
+
+	query, code, err := workspaceStatisticsQuery(r, username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get top of tasks by parameter from Statistics Service
+	resp, err := getStatistics("/top/tasks/" + parameter + query)
+	if err != nil {
+		err = fmt.Errorf("statistics service cause a error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	CopyResponseToWriter(w, resp)
+}
+
+// GetTopUsers handler
+//
+//	Method: GET
+//
+//	Top is built of likes of tasks of workspace `workspace_id` query parameter. Without it top is built
+//	of likes of tasks outside of workspaces
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `stats:read` scope returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetTopUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	// Check if user is authenticated and get his info
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeStatsRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	query, code, err := workspaceStatisticsQuery(r, username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Get top of users by likes from Statistics Service
+	resp, err := getStatistics("/top/users" + query)
+	if err != nil {
+		err = fmt.Errorf("statistics service cause a error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	CopyResponseToWriter(w, resp)
+}
+
+// CreateWorkspace handler
+//
+//	Method: POST
+//
+//	Creates workspace. Requestor becomes its owner
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If request body is not correct or name is empty returns 400 (Status Bad Request)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Decoding request body
+	var creds CreateWorkspaceBody
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateWorkspaceName(creds.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	workspace := mongo_handlers.Workspace{
+		WorkspaceID: uuid.New().String(),
+		Name:        strings.TrimSpace(creds.Name),
+		Owner:       username,
+		CreatedAt:   now,
+	}
+	code, err = userStore.CreateWorkspace(workspace)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	code, err = userStore.AddWorkspaceMember(mongo_handlers.WorkspaceMember{
+		WorkspaceID: workspace.WorkspaceID,
+		Username:    username,
+		Role:        mongo_handlers.WorkspaceRoleOwner,
+		JoinedAt:    now,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp_bytes, err := json.Marshal(NewWorkspaceInfo(workspace, mongo_handlers.WorkspaceRoleOwner))
+	if err != nil {
+		err = fmt.Errorf("json marshaler failed to marshal workspace but it was already created with id %v. Error message: %w", workspace.WorkspaceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(http_resp_bytes)
+}
+
+// GetMyWorkspaces handler
+//
+//	Method: GET
+//
+//	Returns workspaces where requestor is a member, sorted by name
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetMyWorkspaces(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var members []mongo_handlers.WorkspaceMember
+	code, err = userStore.GetUserWorkspaceMembers(username, &members)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	roles := map[string]string{}
+	workspaceIDs := []string{}
+	for _, member := range members {
+		roles[member.WorkspaceID] = member.Role
+		workspaceIDs = append(workspaceIDs, member.WorkspaceID)
+	}
+
+	var workspaces []mongo_handlers.Workspace
+	code, err = userStore.GetWorkspaces(workspaceIDs, &workspaces)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := []WorkspaceInfo{}
+	for _, workspace := range workspaces {
+		http_resp = append(http_resp, NewWorkspaceInfo(workspace, roles[workspace.WorkspaceID]))
+	}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// GetWorkspace handler
+//
+//	Method: GET
+//
+//	Returns workspace with its members. Only members of workspace can perform this request
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	workspaceID := mux.Vars(r)["workspace_id"]
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleMember, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var workspace mongo_handlers.Workspace
+	code, err = userStore.GetWorkspace(workspaceID, &workspace)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	var members []mongo_handlers.WorkspaceMember
+	code, err = userStore.GetWorkspaceMembers(workspaceID, &members)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := NewWorkspaceInfo(workspace, member.Role)
+	for _, workspaceMember := range members {
+		http_resp.Members = append(http_resp.Members, NewWorkspaceMemberInfo(workspaceMember))
+	}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// CreateWorkspaceInvitation handler
+//
+//	Method: POST
+//
+//	Invites user with `username` to workspace. Without `username` creates invitation by link which
+//	can be used by anyone until it expires or is revoked. Token of link is returned only once.
+//	Only admins and owner of workspace can perform this request
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If request body is not correct or role is not `member` or `admin` returns 400 (Status Bad Request)
+//	If requestor is not an admin of workspace returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If invited user doesn't exist returns 404 (Status Not Found)
+//	If invited user is already a member of workspace returns 409 (Status Conflict)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func CreateWorkspaceInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	workspaceID := mux.Vars(r)["workspace_id"]
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleAdmin, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// Decoding request body
+	var creds CreateInvitationBody
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if creds.Role == "" {
+		creds.Role = mongo_handlers.WorkspaceRoleMember
+	}
+	if creds.Role == mongo_handlers.WorkspaceRoleOwner {
+		http.Error(w, "Workspace has only one owner", http.StatusBadRequest)
+		return
+	}
+	if err := mongo_handlers.ValidateWorkspaceRole(creds.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	invitation := mongo_handlers.WorkspaceInvitation{
+		InvitationID: uuid.New().String(),
+		WorkspaceID:  workspaceID,
+		Username:     creds.Username,
+		Role:         creds.Role,
+		CreatedBy:    username,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(workspaceInvitationTTL),
+	}
+
+	var token string
+	if creds.Username != "" {
+		if !userStore.CheckIfUserExists(creds.Username) {
+			http.Error(w, "User with this username doesn't exist", http.StatusNotFound)
+			return
+		}
+		var invited mongo_handlers.WorkspaceMember
+		code, err = userStore.GetWorkspaceMember(workspaceID, creds.Username, &invited)
+		if err == nil {
+			http.Error(w, "User is already a member of workspace", http.StatusConflict)
+			return
+		}
+		if code != http.StatusNotFound {
+			http.Error(w, err.Error(), code)
+			return
+		}
+	} else {
+		token, err = generateRandomToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		invitation.TokenHash = HashToken(token)
+	}
+
+	code, err = userStore.CreateWorkspaceInvitation(invitation)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := NewInvitationInfo(invitation)
+	if token != "" {
+		http_resp.Token = token
+		http_resp.Link = workspaceInvitationLink(token)
+	}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler failed to marshal invitation but it was already created with id %v. Error message: %w", invitation.InvitationID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(http_resp_bytes)
+}
+
+// GetWorkspaceInvitations handler
+//
+//	Method: GET
+//
+//	Returns not expired invitations to workspace, the newest first. Tokens of links are not returned.
+//	Only admins and owner of workspace can perform this request
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If requestor is not an admin of workspace returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func GetWorkspaceInvitations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	workspaceID := mux.Vars(r)["workspace_id"]
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleAdmin, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var invitations []mongo_handlers.WorkspaceInvitation
+	code, err = userStore.GetWorkspaceInvitations(workspaceID, &invitations)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http_resp := []InvitationInfo{}
+	for _, invitation := range invitations {
+		http_resp = append(http_resp, NewInvitationInfo(invitation))
+	}
+	http_resp_bytes, err := json.Marshal(http_resp)
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
+}
+
+// RevokeWorkspaceInvitation handler
+//
+//	Method: DELETE
+//
+//	Revokes invitation to workspace. Only admins and owner of workspace can perform this request
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If requestor is not an admin of workspace returns 403 (Status Forbidden)
+//	If workspace or invitation doesn't exist or requestor is not a member of workspace returns 404 (Status Not Found)
+//	If internal error occurred returns 500 (Status Internal Server Error)
+func RevokeWorkspaceInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	var username string
+	code, err := CheckIfUserAuthenticated(r, &username)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	workspaceID := mux.Vars(r)["workspace_id"]
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleAdmin, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var invitation mongo_handlers.WorkspaceInvitation
+	code, err = userStore.GetWorkspaceInvitation(mux.Vars(r)["invitation_id"], &invitation)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if invitation.WorkspaceID != workspaceID {
+		http.Error(w, "invitation not found", http.StatusNotFound)
+		return
+	}
+
+	code, err = userStore.DeleteWorkspaceInvitation(invitation.InvitationID)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Write([]byte("Invitation has been revoked succesfully\n"))
 }
 
-// CreateTask handler
+// SetWorkspaceMemberRole handler
 //
 //	Method: PUT
 //
+//	Sets role of workspace member (`member` or `admin`). Only admins and owner of workspace can perform this request
+//
 //	If user is not authenticated returns 400 (Status Bad Request)
-//	If task with this ID doesn't exist or requestor is not an author of the task returns 400 (Status Bad Request)
-//	If request body is not correct returns 400 (Status Bad Request)
+//	If request body is not correct or role is not `member` or `admin` returns 400 (Status Bad Request)
+//	If requestor changes his own role or role of owner returns 400 (Status Bad Request)
+//	If requestor is not an admin of workspace returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor or user is not its member returns 404 (Status Not Found)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func UpdateTask(w http.ResponseWriter, r *http.Request) {
+func SetWorkspaceMemberRole(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
 	code, err := CheckIfUserAuthenticated(r, &username)
 	if err != nil {
@@ -291,61 +3419,69 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	workspaceID := mux.Vars(r)["workspace_id"]
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleAdmin, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
 	// Decoding request body
-	var creds UpdateTaskRequest
+	var creds SetRoleBody
 	err = json.NewDecoder(r.Body).Decode(&creds)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if creds.Role == mongo_handlers.WorkspaceRoleOwner {
+		http.Error(w, "Workspace has only one owner", http.StatusBadRequest)
+		return
+	}
+	if err := mongo_handlers.ValidateWorkspaceRole(creds.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Get variable from URL
-	taskIdString := mux.Vars(r)["task_id"]
-	taskIDInt, err := strconv.Atoi(taskIdString)
+	target := mux.Vars(r)["username"]
+	if target == username {
+		http.Error(w, "Member can't change his own role", http.StatusBadRequest)
+		return
+	}
+	var targetMember mongo_handlers.WorkspaceMember
+	code, err = userStore.GetWorkspaceMember(workspaceID, target, &targetMember)
 	if err != nil {
-		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
-	taskID := int32(taskIDInt)
-
-	var task task_servicepb.Task
-	task.Id = taskID
-	task.Task = &task_servicepb.TaskContent{
-		Title:           creds.Title,
-		Description:     creds.Description,
-		Status:          creds.Status,
-		CreatorUsername: username,
+	if targetMember.Role == mongo_handlers.WorkspaceRoleOwner {
+		http.Error(w, "Role of workspace owner can't be changed", http.StatusBadRequest)
+		return
 	}
 
-	// Send request to Task Service by GRPC
-	// If requestor is not author of task then request returns error `NotFound`
-	_, err = taskServiceClient.UpdateTask(context.Background(), &task)
+	code, err = userStore.SetWorkspaceMemberRole(workspaceID, target, creds.Role)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			err = fmt.Errorf("grpc request `UpdateTask` failed because task with id=%v doesn't exists or requestor is not an author. Error message: %w", task.Id, err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			err = fmt.Errorf("grpc request `UpdateTask` failed with error message: %w", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		http.Error(w, err.Error(), code)
 		return
 	}
-
-	w.Write([]byte("Task has been updated succesfully\n"))
+	w.Write([]byte("Role has been changed succesfully\n"))
 }
 
-// DeleteTask handler
+// RemoveWorkspaceMember handler
 //
 //	Method: DELETE
 //
+//	Removes member from workspace. Every member can leave workspace by removing himself,
+//	other members can be removed only by admins and owner of workspace. Owner can't leave workspace
+//
 //	If user is not authenticated returns 400 (Status Bad Request)
-//	If task with this ID doesn't exist or requestor is not an author of the task returns 400 (Status Bad Request)
-//	If request body is not correct returns 400 (Status Bad Request)
+//	If owner of workspace is removed returns 400 (Status Bad Request)
+//	If requestor removes another member and is not an admin of workspace returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor or user is not its member returns 404 (Status Not Found)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func DeleteTask(w http.ResponseWriter, r *http.Request) {
+func RemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
 	code, err := CheckIfUserAuthenticated(r, &username)
 	if err != nil {
@@ -353,46 +3489,49 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get variable from URL
-	taskIdString := mux.Vars(r)["task_id"]
-	taskIDInt, err := strconv.Atoi(taskIdString)
+	workspaceID := mux.Vars(r)["workspace_id"]
+	target := mux.Vars(r)["username"]
+	requiredRole := mongo_handlers.WorkspaceRoleAdmin
+	if target == username {
+		requiredRole = mongo_handlers.WorkspaceRoleMember
+	}
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, requiredRole, &member)
 	if err != nil {
-		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
-	taskID := int32(taskIDInt)
 
-	// Send request to Task Service by GRPC
-	// If requestor is not author of task then request returns error `NotFound`
-	_, err = taskServiceClient.DeleteTask(context.Background(), &task_servicepb.RequestByID{
-		Id:                taskID,
-		RequestorUsername: username,
-	})
+	var targetMember mongo_handlers.WorkspaceMember
+	code, err = userStore.GetWorkspaceMember(workspaceID, target, &targetMember)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			err = fmt.Errorf("requestor is not an author of the task. GRPC's error message: %w", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			err = fmt.Errorf("grpc request `DeleteTask` failed with error message: %w", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if targetMember.Role == mongo_handlers.WorkspaceRoleOwner {
+		http.Error(w, "Owner can't be removed from workspace", http.StatusBadRequest)
 		return
 	}
 
-	w.Write([]byte("Task has been deleted succesfully"))
+	code, err = userStore.DeleteWorkspaceMember(workspaceID, target)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Write([]byte("Member has been removed from workspace succesfully\n"))
 }
 
-// GetTask handler
+// GetMyInvitations handler
 //
 //	Method: GET
 //
+//	Returns not expired invitations addressed to requestor, the newest first
+//
 //	If user is not authenticated returns 400 (Status Bad Request)
-//	If request body is not correct returns 400 (Status Bad Request)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func GetTask(w http.ResponseWriter, r *http.Request) {
+func GetMyInvitations(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
 	code, err := CheckIfUserAuthenticated(r, &username)
 	if err != nil {
@@ -400,58 +3539,56 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get variable from URL
-	taskIdString := mux.Vars(r)["task_id"]
-	taskIDInt, err := strconv.Atoi(taskIdString)
+	var invitations []mongo_handlers.WorkspaceInvitation
+	code, err = userStore.GetUserWorkspaceInvitations(username, &invitations)
 	if err != nil {
-		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
-	taskID := int32(taskIDInt)
-
-	// Send request to Task Service by GRPC
-	grpc_resp, err := taskServiceClient.GetTaskById(context.Background(), &task_servicepb.RequestByID{
-		Id:                taskID,
-		RequestorUsername: username,
-	})
+	workspaceIDs := []string{}
+	for _, invitation := range invitations {
+		workspaceIDs = append(workspaceIDs, invitation.WorkspaceID)
+	}
+	var workspaces []mongo_handlers.Workspace
+	code, err = userStore.GetWorkspaces(workspaceIDs, &workspaces)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			err = fmt.Errorf("task with this id doesn't exist: %w", err)
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
-			err = fmt.Errorf("grpc request `GetTaskById` failed with error message: %w", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		http.Error(w, err.Error(), code)
 		return
 	}
-
-	http_resp := TaskContent{
-		Title:       grpc_resp.Task.Title,
-		Description: grpc_resp.Task.Description,
-		Status:      grpc_resp.Task.Status,
+	names := map[string]string{}
+	for _, workspace := range workspaces {
+		names[workspace.WorkspaceID] = workspace.Name
 	}
 
+	http_resp := []InvitationInfo{}
+	for _, invitation := range invitations {
+		info := NewInvitationInfo(invitation)
+		info.WorkspaceName = names[invitation.WorkspaceID]
+		http_resp = append(http_resp, info)
+	}
 	http_resp_bytes, err := json.Marshal(http_resp)
 	if err != nil {
 		err = fmt.Errorf("json marshaler error: %w", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
 	w.Write(http_resp_bytes)
 }
 
-// GetTaskPage handler
+// AcceptInvitation handler
 //
-//	Method: GET
+//	Method: POST
+//
+//	Accepts invitation addressed to requestor and makes him a member of workspace
 //
 //	If user is not authenticated returns 400 (Status Bad Request)
-//	If request body is not correct returns 400 (Status Bad Request)
+//	If invitation doesn't exist or is addressed to another user returns 404 (Status Not Found)
+//	If user is already a member of workspace returns 409 (Status Conflict)
+//	If invitation has expired returns 410 (Status Gone)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func GetTaskPage(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+func AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
 	code, err := CheckIfUserAuthenticated(r, &username)
 	if err != nil {
@@ -459,47 +3596,45 @@ func GetTaskPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Decoding request body
-	var creds TaskListRequest
-	err = json.NewDecoder(r.Body).Decode(&creds)
+	var invitation mongo_handlers.WorkspaceInvitation
+	code, err = userStore.GetWorkspaceInvitation(mux.Vars(r)["invitation_id"], &invitation)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
-
-	// Send requset to Task Service by GRPC
-	grpc_resp, err := taskServiceClient.GetTaskList(context.Background(), &task_servicepb.TaskPageRequest{
-		Offset:   creds.Offset,
-		PageSize: creds.PageSize,
-	})
-	if err != nil {
-		err = fmt.Errorf("grpc `GetTaskList` failed with message: %w", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if invitation.Username != username {
+		http.Error(w, "invitation not found", http.StatusNotFound)
 		return
 	}
 
-	// Marshal Protobuf to JSON
-	marshaler := jsonpb.Marshaler{}
-	jsonStr, err := marshaler.MarshalToString(grpc_resp)
+	code, err = joinWorkspace(invitation, username)
+	if err != nil && code != http.StatusConflict {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	// Invitation of user can be used only once. It's dropped even if user has already joined by link
+	if _, deleteErr := userStore.DeleteWorkspaceInvitation(invitation.InvitationID); deleteErr != nil {
+		log.Printf("Failed to delete accepted invitation `%s`: %v", invitation.InvitationID, deleteErr)
+	}
 	if err != nil {
-		err = fmt.Errorf("protobuf to json marshaler failed with message: %w", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), code)
 		return
 	}
-
-	w.Write([]byte(jsonStr))
+	w.Write([]byte("Invitation has been accepted succesfully\n"))
 }
 
-// View handler
+// DeclineInvitation handler
 //
-//	Method: POST
+//	Method: DELETE
+//
+//	Declines invitation addressed to requestor
 //
 //	If user is not authenticated returns 400 (Status Bad Request)
+//	If invitation doesn't exist or is addressed to another user returns 404 (Status Not Found)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func View(w http.ResponseWriter, r *http.Request) {
+func DeclineInvitation(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
 	code, err := CheckIfUserAuthenticated(r, &username)
 	if err != nil {
@@ -507,53 +3642,40 @@ func View(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get variable from URL
-	taskIdString := mux.Vars(r)["task_id"]
-	taskIDInt, err := strconv.Atoi(taskIdString)
+	var invitation mongo_handlers.WorkspaceInvitation
+	code, err = userStore.GetWorkspaceInvitation(mux.Vars(r)["invitation_id"], &invitation)
 	if err != nil {
-		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
-	taskID := int32(taskIDInt)
-
-	// Send requset to Task Service by GRPC to get task's author name
-	// If task doesn't exists returns error `NotFound`
-	grpc_resp, err := taskServiceClient.GetTaskById(context.Background(), &task_servicepb.RequestByID{
-		Id:                taskID,
-		RequestorUsername: username, // ?? not neccessary, maybe remove?
-	})
-	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			err = fmt.Errorf("task with this id doesn't exist: %w", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			err = fmt.Errorf("grpc `GetTaskById` failed with message: %w", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+	if invitation.Username != username {
+		http.Error(w, "invitation not found", http.StatusNotFound)
 		return
 	}
 
-	// Send view to Kafka
-	err = kafka_handlers.View(username, taskID, grpc_resp.Task.CreatorUsername)
+	code, err = userStore.DeleteWorkspaceInvitation(invitation.InvitationID)
 	if err != nil {
-		err = fmt.Errorf("`view` message sending caused a error: %w", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), code)
 		return
 	}
-
-	w.Write([]byte("Task has been viewed succesfully\n"))
+	w.Write([]byte("Invitation has been declined succesfully\n"))
 }
 
-// Like handler
+// JoinWorkspace handler
 //
 //	Method: POST
 //
+//	Makes requestor a member of workspace by token from invitation link (`token` query parameter)
+//
 //	If user is not authenticated returns 400 (Status Bad Request)
+//	If token is empty returns 400 (Status Bad Request)
+//	If invitation with this token doesn't exist returns 404 (Status Not Found)
+//	If user is already a member of workspace returns 409 (Status Conflict)
+//	If invitation has expired returns 410 (Status Gone)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func LikeTaskPost(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+func JoinWorkspace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
 	code, err := CheckIfUserAuthenticated(r, &username)
 	if err != nil {
@@ -561,84 +3683,100 @@ func LikeTaskPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get variable from URL
-	taskIdString := mux.Vars(r)["task_id"]
-	taskIDInt, err := strconv.Atoi(taskIdString)
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "No token in request", http.StatusBadRequest)
+		return
+	}
+
+	var invitation mongo_handlers.WorkspaceInvitation
+	code, err = userStore.GetWorkspaceInvitationByTokenHash(HashToken(token), &invitation)
 	if err != nil {
-		http.Error(w, "Task's Id should has type int32", http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
-	taskID := int32(taskIDInt)
 
-	// Send requset to Task Service by GRPC to get task's author name
-	// If task doesn't exists returns error `NotFound`
-	grpc_resp, err := taskServiceClient.GetTaskById(context.Background(), &task_servicepb.RequestByID{
-		Id:                taskID,
-		RequestorUsername: username, // ?? not neccessary, maybe remove?
-	})
+	code, err = joinWorkspace(invitation, username)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			err = fmt.Errorf("task with this id doesn't exist: %w", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			err = fmt.Errorf("grpc `GetTaskById` failed with message: %w", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		http.Error(w, err.Error(), code)
 		return
 	}
 
-	// Send like to Kafka
-	err = kafka_handlers.Like(username, taskID, grpc_resp.Task.CreatorUsername)
+	var workspace mongo_handlers.Workspace
+	code, err = userStore.GetWorkspace(invitation.WorkspaceID, &workspace)
 	if err != nil {
-		err = fmt.Errorf("`like` message sending caused a error: %w", err)
+		http.Error(w, err.Error(), code)
+		return
+	}
+	http_resp_bytes, err := json.Marshal(NewWorkspaceInfo(workspace, invitation.Role))
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	w.Write([]byte("Task has been liked succesfully\n"))
+	w.Write(http_resp_bytes)
 }
 
-// GetTaskStats handler
+// GetWorkspaceWorkflow handler
 //
 //	Method: GET
 //
+//	Returns workflow of workspace's tasks: statuses, allowed transitions between them and terminal statuses.
+//	Workspaces without configured workflow use the default one
+//
 //	If user is not authenticated returns 400 (Status Bad Request)
+//	If personal access token has no `tasks:read` scope returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func GetTaskStats(w http.ResponseWriter, r *http.Request) {
+func GetWorkspaceWorkflow(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
-	code, err := CheckIfUserAuthenticated(r, &username)
+	code, err := CheckIfUserAuthenticated(r, &username, ScopeTasksRead)
 	if err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
 
-	// Get variable from URL
-	task_id := mux.Vars(r)["task_id"]
+	workspaceID := mux.Vars(r)["workspace_id"]
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleMember, &member)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
 
-	// Get statistics for task from Statistics Service
-	resp, err := http.Get("http://statistics_service:8090/tasks/" + task_id + "/stats")
+	workflow, err := taskServiceClient.GetWorkflow(context.Background(), &task_servicepb.WorkflowRequest{WorkspaceId: workspaceID})
 	if err != nil {
-		err = fmt.Errorf("statistics service cause a error: %w", err)
+		err = fmt.Errorf("grpc `GetWorkflow` failed with message: %w", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	CopyResponseToWriter(w, resp)
+	http_resp_bytes, err := json.Marshal(NewWorkflowBody(workflow))
+	if err != nil {
+		err = fmt.Errorf("json marshaler error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(http_resp_bytes)
 }
 
-// GetTopTasks handler
+// SetWorkspaceWorkflow handler
 //
-//	Method: GET
+//	Method: PUT
+//
+//	Replaces workflow of workspace's tasks. Only admins and owner of workspace can perform this request
 //
 //	If user is not authenticated returns 400 (Status Bad Request)
+//	If request body is not correct, status is unknown or workflow is not consistent returns 400 (Status Bad Request)
+//	If requestor is not an admin of workspace returns 403 (Status Forbidden)
+//	If workspace doesn't exist or requestor is not its member returns 404 (Status Not Found)
+//	If some tasks of workspace have status which is not in the new workflow returns 409 (Status Conflict)
 //	If internal error occurred returns 500 (Status Internal Server Error)
-func GetTopTasks(w http.ResponseWriter, r *http.Request) {
+func SetWorkspaceWorkflow(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	// Check if user is authenticated and get his username
 	var username string
 	code, err := CheckIfUserAuthenticated(r, &username)
 	if err != nil {
@@ -646,44 +3784,40 @@ func GetTopTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get variable from URL
-	parameter := mux.Vars(r)["parameter"]
-
-	// Get top of tasks by parameter from Statistics Service
-	resp, err := http.Get("http://statistics_service:8090/top/tasks/" + parameter)
+	workspaceID := mux.Vars(r)["workspace_id"]
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleAdmin, &member)
 	if err != nil {
-		err = fmt.Errorf("statistics service cause a error: %w", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), code)
 		return
 	}
 
-	CopyResponseToWriter(w, resp)
-}
-
-// GetTopUsers handler
-//
-//	Method: GET
-//
-//	If user is not authenticated returns 400 (Status Bad Request)
-//	If internal error occurred returns 500 (Status Internal Server Error)
-func GetTopUsers(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	// Decoding request body
+	var creds WorkflowBody
+	err = json.NewDecoder(r.Body).Decode(&creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	workflow, err := workflowFromBody(workspaceID, creds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Check if user is authenticated and get his info
-	var username string
-	code, err := CheckIfUserAuthenticated(r, &username)
+	workflow, err = taskServiceClient.SetWorkflow(context.Background(), workflow)
 	if err != nil {
+		code = workflowErrorCode(err)
+		err = fmt.Errorf("grpc `SetWorkflow` failed with message: %w", err)
 		http.Error(w, err.Error(), code)
 		return
 	}
 
-	// Get top of users by likes from Statistics Service
-	resp, err := http.Get("http://statistics_service:8090/top/users")
+	http_resp_bytes, err := json.Marshal(NewWorkflowBody(workflow))
 	if err != nil {
-		err = fmt.Errorf("statistics service cause a error: %w", err)
+		err = fmt.Errorf("json marshaler error: %w", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	CopyResponseToWriter(w, resp)
+	w.Write(http_resp_bytes)
 }
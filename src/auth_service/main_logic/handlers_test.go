@@ -0,0 +1,1602 @@
+package auth_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mongo_handlers"
+
+	task_servicepb "task_service/proto"
+)
+
+func TestRegister(t *testing.T) {
+	env := newTestEnv(t)
+
+	cookies := env.register(t, "alice", "correct horse")
+	if findCookie(cookies, "token") == nil {
+		t.Fatal("register should set `token` cookie")
+	}
+	if findCookie(cookies, refreshTokenCookieName) == nil {
+		t.Fatalf("register should set `%s` cookie", refreshTokenCookieName)
+	}
+
+	var user mongo_handlers.User
+	if _, err := env.store.GetUser("alice", &user); err != nil {
+		t.Fatalf("user should be stored: %v", err)
+	}
+	if user.Password == "" || user.Password == "correct horse" {
+		t.Fatal("password should be stored hashed")
+	}
+
+	// Session cookie of new user is accepted
+	resp := env.do(t, "GET", "/profile", nil, cookies)
+	expectStatus(t, resp, http.StatusOK)
+}
+
+func TestRegisterDuplicateUsername(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "POST", "/register", RegisterBody{Username: "alice", Password: "another password"}, nil)
+	expectStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestRegisterShortPassword(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp := env.do(t, "POST", "/register", RegisterBody{Username: "alice", Password: "a"}, nil)
+	expectStatus(t, resp, http.StatusBadRequest)
+	if env.store.CheckIfUserExists("alice") {
+		t.Fatal("user with too short password should not be created")
+	}
+}
+
+func TestRegisterMalformedBody(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp := env.do(t, "POST", "/register", "not an object", nil)
+	expectStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestAuthenticate(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "correct horse"}, nil)
+	expectStatus(t, resp, http.StatusOK)
+	cookies := resp.Result().Cookies()
+	if findCookie(cookies, "token") == nil {
+		t.Fatal("authenticate should set `token` cookie")
+	}
+
+	// Both sessions (after register and after login) are active
+	var sessions []mongo_handlers.Session
+	env.store.GetUserSessions("alice", &sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	resp = env.do(t, "GET", "/profile", nil, cookies)
+	expectStatus(t, resp, http.StatusOK)
+}
+
+func TestAuthenticateWrongCredentials(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "wrong"}, nil)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	if findCookie(resp.Result().Cookies(), "token") != nil {
+		t.Fatal("failed login should not set `token` cookie")
+	}
+	wrongPasswordBody := resp.Body.String()
+
+	// Unknown user gets the same response as wrong password
+	resp = env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "bob", Password: "wrong"}, nil)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	if body := resp.Body.String(); body != wrongPasswordBody {
+		t.Fatalf("unknown user should get the same response as wrong password %q, got %q", wrongPasswordBody, body)
+	}
+}
+
+func TestAuthenticateLockout(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice", "correct horse")
+
+	for i := 0; i < userLockoutThreshold; i++ {
+		resp := env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "wrong"}, nil)
+		expectStatus(t, resp, http.StatusUnauthorized)
+	}
+
+	// Even correct password is rejected while account is locked
+	resp := env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "correct horse"}, nil)
+	expectStatus(t, resp, http.StatusLocked)
+	if resp.Header().Get("Retry-After") == "" {
+		t.Fatal("locked login should have `Retry-After` header")
+	}
+
+	var events []mongo_handlers.LockoutEvent
+	env.store.GetLockoutEvents(true, 0, 10, &events)
+	if len(events) != 1 || events[0].Key != userAttemptsKeyPrefix+"alice" {
+		t.Fatalf("expected one active lockout of alice, got %+v", events)
+	}
+}
+
+func TestVerifyPasswordRejectsMalformedHashes(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString(make([]byte, argon2SaltLen))
+	key := base64.RawStdEncoding.EncodeToString(make([]byte, argon2KeyLen))
+	hash := func(params string, salt string, key string) string {
+		return "$argon2id$v=19$" + params + "$" + salt + "$" + key
+	}
+
+	for _, storedHash := range []string{
+		// argon2 panics on zero time or threads
+		hash("m=65536,t=0,p=2", salt, key),
+		hash("m=65536,t=3,p=0", salt, key),
+		// Too expensive to check
+		hash("m=65536,t=1000,p=2", salt, key),
+		hash("m=4294967295,t=3,p=2", salt, key),
+		hash("m=8,t=3,p=2", salt, key),
+		// Empty hash would match any password
+		hash("m=65536,t=3,p=2", salt, ""),
+		hash("m=65536,t=3,p=2", "", key),
+		hash("m=65536,t=3", salt, key),
+	} {
+		matches, _, err := VerifyPassword("password", storedHash)
+		if !errors.Is(err, errMalformedPasswordHash) || matches {
+			t.Errorf("hash %q: expected errMalformedPasswordHash, got matches = %v, err = %v", storedHash, matches, err)
+		}
+	}
+
+	storedHash, err := HashPassword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matches, _, err := VerifyPassword("password", storedHash); !matches || err != nil {
+		t.Fatalf("fresh hash should match, got matches = %v, err = %v", matches, err)
+	}
+}
+
+func TestUpdateMyProfile(t *testing.T) {
+	env := newTestEnv(t)
+	cookies := env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "PUT", "/profile", ProfileInfo{
+		FirstName:   "Alice",
+		LastName:    "Liddell",
+		Birthday:    "2000-05-04",
+		PhoneNumber: "+79991234567",
+	}, cookies)
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = env.do(t, "GET", "/profile", nil, cookies)
+	expectStatus(t, resp, http.StatusOK)
+	var profile ProfileInfo
+	if err := json.Unmarshal(resp.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("profile is not JSON: %v", err)
+	}
+	expected := ProfileInfo{
+		Username:    "alice",
+		FirstName:   "Alice",
+		LastName:    "Liddell",
+		Birthday:    "2000-05-04",
+		PhoneNumber: "+79991234567",
+		Role:        mongo_handlers.RoleUser,
+	}
+	if profile != expected {
+		t.Fatalf("expected profile %+v, got %+v", expected, profile)
+	}
+}
+
+func TestUpdateMyProfileValidation(t *testing.T) {
+	env := newTestEnv(t)
+	cookies := env.register(t, "alice", "correct horse")
+
+	for _, update := range []ProfileInfo{
+		{Birthday: "04.05.2000"},
+		{Birthday: "2999-01-01"},
+		{PhoneNumber: "89991234567"},
+		{Email: "Alice <alice@example.com>"},
+	} {
+		resp := env.do(t, "PUT", "/profile", update, cookies)
+		expectStatus(t, resp, http.StatusBadRequest)
+	}
+
+	var user mongo_handlers.User
+	env.store.GetUser("alice", &user)
+	if user.Birthday != "" || user.PhoneNumber != "" || user.PendingEmail != "" {
+		t.Fatalf("invalid fields should not be stored, got %+v", user)
+	}
+}
+
+func TestUpdateMyProfileUnauthenticated(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp := env.do(t, "PUT", "/profile", ProfileInfo{FirstName: "Alice"}, nil)
+	expectStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestUpdateMyProfileEmailVerification(t *testing.T) {
+	env := newTestEnv(t)
+	cookies := env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "PUT", "/profile", ProfileInfo{Email: "alice@example.com"}, cookies)
+	expectStatus(t, resp, http.StatusAccepted)
+
+	var user mongo_handlers.User
+	env.store.GetUser("alice", &user)
+	if user.Email != "" || user.PendingEmail != "alice@example.com" {
+		t.Fatalf("email should be pending until verification, got %+v", user)
+	}
+
+	if len(env.mails.messages) != 1 || env.mails.messages[0].To != "alice@example.com" {
+		t.Fatalf("expected verification email to alice@example.com, got %+v", env.mails.messages)
+	}
+	link := regexp.MustCompile(`/profile/email/verify\?token=\S+`).FindString(env.mails.messages[0].Body)
+	if link == "" {
+		t.Fatalf("verification email has no link: %s", env.mails.messages[0].Body)
+	}
+
+	resp = env.do(t, "GET", link, nil, nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	env.store.GetUser("alice", &user)
+	if user.Email != "alice@example.com" || !user.EmailVerified || user.PendingEmail != "" {
+		t.Fatalf("email should be verified, got %+v", user)
+	}
+}
+
+func TestDeleteMyProfile(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Alice's task"}, alice)
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Bob's task"}, bob)
+
+	resp := env.do(t, "DELETE", "/profile", DeleteProfileBody{Password: "wrong"}, alice)
+	expectStatus(t, resp, http.StatusUnauthorized)
+
+	resp = env.do(t, "DELETE", "/profile", DeleteProfileBody{Password: "correct horse"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+
+	if env.store.CheckIfUserExists("alice") {
+		t.Fatal("user should be deleted")
+	}
+	if len(env.tasks.tasks) != 1 || env.tasks.tasks[2] == nil {
+		t.Fatalf("only alice's tasks should be deleted, got %+v", env.tasks.tasks)
+	}
+	last := env.events.events[len(env.events.events)-1]
+	if last != (publishedEvent{Kind: "user_deleted", Username: "alice", TaskIDs: "[1]"}) {
+		t.Fatalf("statistics service should be notified, got %+v", env.events.events)
+	}
+
+	// Old session is not accepted anymore
+	resp = env.do(t, "GET", "/profile", nil, alice)
+	expectStatus(t, resp, http.StatusUnauthorized)
+
+	// Username is free after deletion is finished
+	env.register(t, "alice", "new password")
+}
+
+func TestDeleteMyProfileWhenTaskServiceIsUnavailable(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Alice's task"}, alice)
+	env.tasks.unavailable = true
+
+	resp := env.do(t, "DELETE", "/profile", DeleteProfileBody{Password: "correct horse"}, alice)
+	expectStatus(t, resp, http.StatusAccepted)
+	if env.store.CheckIfUserExists("alice") {
+		t.Fatal("user should be deleted even if task service is unavailable")
+	}
+
+	// Username can't be taken until tasks are deleted
+	resp = env.do(t, "POST", "/register", RegisterBody{Username: "alice", Password: "new password"}, nil)
+	expectStatus(t, resp, http.StatusBadRequest)
+
+	// Deletion claimed by another replica is not processed until claim expires
+	var claimed mongo_handlers.AccountDeletion
+	if _, err := env.store.ClaimAccountDeletion("alice", "another replica", time.Now().Add(time.Minute), &claimed); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	env.tasks.unavailable = false
+	RetryAccountDeletions()
+	if len(env.tasks.tasks) != 1 {
+		t.Fatalf("deletion claimed by another replica should not be processed, got %+v", env.tasks.tasks)
+	}
+	expired := time.Now().Add(-time.Second)
+	claimed.LockedUntil = &expired
+	env.store.UpdateAccountDeletion(claimed)
+	RetryAccountDeletions()
+
+	if len(env.tasks.tasks) != 0 {
+		t.Fatalf("alice's tasks should be deleted by retry, got %+v", env.tasks.tasks)
+	}
+	var deletion mongo_handlers.AccountDeletion
+	if _, err := env.store.GetPendingAccountDeletion("alice", &deletion); err == nil {
+		t.Fatalf("deletion should be completed, got %+v", deletion)
+	}
+	env.register(t, "alice", "new password")
+}
+
+func TestDeleteMyProfileHandsOverWorkspaces(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+	carol := env.register(t, "carol", "correct horse")
+
+	createWorkspace := func(name string) string {
+		t.Helper()
+		resp := env.do(t, "POST", "/workspaces", CreateWorkspaceBody{Name: name}, alice)
+		expectStatus(t, resp, http.StatusCreated)
+		var workspace WorkspaceInfo
+		json.Unmarshal(resp.Body.Bytes(), &workspace)
+		return workspace.ID
+	}
+	team := createWorkspace("Team")
+	solo := createWorkspace("Solo")
+	for _, member := range []struct {
+		username string
+		cookies  []*http.Cookie
+		role     string
+	}{
+		{"bob", bob, mongo_handlers.WorkspaceRoleMember},
+		{"carol", carol, mongo_handlers.WorkspaceRoleAdmin},
+	} {
+		resp := env.do(t, "POST", "/workspaces/"+team+"/invitations", CreateInvitationBody{Username: member.username, Role: member.role}, alice)
+		expectStatus(t, resp, http.StatusCreated)
+		var invitation InvitationInfo
+		json.Unmarshal(resp.Body.Bytes(), &invitation)
+		resp = env.do(t, "POST", "/invitations/"+invitation.ID+"/accept", nil, member.cookies)
+		expectStatus(t, resp, http.StatusOK)
+	}
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Shared", WorkspaceID: team}, alice)
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Solo", WorkspaceID: solo}, alice)
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Private"}, alice)
+
+	resp := env.do(t, "DELETE", "/profile", DeleteProfileBody{Password: "correct horse"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+
+	// Admin gets workspace even though member joined earlier
+	resp = env.do(t, "GET", "/workspaces/"+team, nil, carol)
+	expectStatus(t, resp, http.StatusOK)
+	var workspace WorkspaceInfo
+	json.Unmarshal(resp.Body.Bytes(), &workspace)
+	roles := map[string]string{}
+	for _, member := range workspace.Members {
+		roles[member.Username] = member.Role
+	}
+	if workspace.Owner != "carol" || len(roles) != 2 || roles["carol"] != mongo_handlers.WorkspaceRoleOwner ||
+		roles["bob"] != mongo_handlers.WorkspaceRoleMember {
+		t.Fatalf("workspace should be handed over to admin, got %+v", workspace)
+	}
+
+	// Workspace without other members is deleted together with its tasks
+	var deleted mongo_handlers.Workspace
+	if _, err := env.store.GetWorkspace(solo, &deleted); err == nil {
+		t.Fatalf("workspace without other members should be deleted, got %+v", deleted)
+	}
+
+	// Task of workspace is kept for other members without creator
+	if len(env.tasks.tasks) != 1 || env.tasks.tasks[1] == nil || env.tasks.tasks[1].CreatorUsername != "" {
+		t.Fatalf("only anonymized task of workspace should be kept, got %+v", env.tasks.tasks)
+	}
+	resp = env.do(t, "GET", "/tasks/1", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	// Statistics of kept task are not deleted
+	last := env.events.events[len(env.events.events)-1]
+	if last != (publishedEvent{Kind: "user_deleted", Username: "alice", TaskIDs: "[2 3]"}) {
+		t.Fatalf("statistics service should delete only statistics of deleted tasks, got %+v", last)
+	}
+}
+
+// Poll export until it's finished
+func waitForDataExport(t *testing.T, env *testEnv, cookies []*http.Cookie, exportID string) DataExportInfo {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := env.do(t, "GET", "/profile/export/"+exportID, nil, cookies)
+		expectStatus(t, resp, http.StatusOK)
+		var info DataExportInfo
+		if err := json.Unmarshal(resp.Body.Bytes(), &info); err != nil {
+			t.Fatalf("failed to decode export: %v", err)
+		}
+		if info.Status != DataExportPending {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("export is not finished in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExportMyData(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Alice's first task"}, alice)
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Bob's task"}, bob)
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Alice's second task"}, alice)
+
+	resp := env.do(t, "POST", "/profile/export", nil, alice)
+	expectStatus(t, resp, http.StatusAccepted)
+	var started DataExportInfo
+	if err := json.Unmarshal(resp.Body.Bytes(), &started); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+
+	// Export can't be seen by other users
+	resp = env.do(t, "GET", "/profile/export/"+started.ID, nil, bob)
+	expectStatus(t, resp, http.StatusNotFound)
+
+	info := waitForDataExport(t, env, alice, started.ID)
+	if info.Status != DataExportReady || info.DownloadURL == "" {
+		t.Fatalf("export should be ready, got %+v", info)
+	}
+
+	resp = env.do(t, "GET", info.DownloadURL, nil, bob)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "GET", info.DownloadURL, nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	if contentType := resp.Header().Get("Content-Type"); contentType != "application/zip" {
+		t.Fatalf("expected zip archive, got %q", contentType)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(resp.Body.Bytes()), int64(resp.Body.Len()))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open `%s`: %v", file.Name, err)
+		}
+		files[file.Name], _ = io.ReadAll(reader)
+		reader.Close()
+	}
+	for _, name := range []string{"profile.json", "sessions.json", "tasks.json", "activity.json", "task_stats.json"} {
+		if !json.Valid(files[name]) {
+			t.Fatalf("archive should contain valid `%s`, got %q", name, files[name])
+		}
+	}
+
+	var profile ExportedProfile
+	json.Unmarshal(files["profile.json"], &profile)
+	if profile.Username != "alice" {
+		t.Fatalf("unexpected profile: %s", files["profile.json"])
+	}
+	var tasks []struct {
+		ID   int32 `json:"id"`
+		Task struct {
+			Title           string `json:"title"`
+			CreatorUsername string `json:"creatorUsername"`
+		} `json:"task"`
+	}
+	json.Unmarshal(files["tasks.json"], &tasks)
+	if len(tasks) != 2 || tasks[0].Task.CreatorUsername != "alice" || tasks[1].Task.CreatorUsername != "alice" {
+		t.Fatalf("only alice's tasks should be exported, got %s", files["tasks.json"])
+	}
+	var stats []struct {
+		TaskID int32 `json:"task_id"`
+	}
+	json.Unmarshal(files["task_stats.json"], &stats)
+	if len(stats) != 2 || stats[0].TaskID != tasks[0].ID || stats[1].TaskID != tasks[1].ID {
+		t.Fatalf("statistics of alice's tasks should be exported, got %s", files["task_stats.json"])
+	}
+}
+
+func TestExportMyDataWhenStatisticsServiceFails(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	env.stats.failing.Store(true)
+
+	resp := env.do(t, "POST", "/profile/export", nil, alice)
+	expectStatus(t, resp, http.StatusAccepted)
+	var started DataExportInfo
+	json.Unmarshal(resp.Body.Bytes(), &started)
+
+	info := waitForDataExport(t, env, alice, started.ID)
+	if info.Status != DataExportFailed || info.Error == "" {
+		t.Fatalf("export should fail, got %+v", info)
+	}
+	resp = env.do(t, "GET", "/profile/export/"+started.ID+"/download", nil, alice)
+	expectStatus(t, resp, http.StatusConflict)
+
+	// Failed export doesn't prevent starting a new one
+	env.stats.failing.Store(false)
+	resp = env.do(t, "POST", "/profile/export", nil, alice)
+	expectStatus(t, resp, http.StatusAccepted)
+	var restarted DataExportInfo
+	json.Unmarshal(resp.Body.Bytes(), &restarted)
+	if restarted.ID == started.ID {
+		t.Fatal("new export should be started")
+	}
+	if info := waitForDataExport(t, env, alice, restarted.ID); info.Status != DataExportReady {
+		t.Fatalf("export should be ready, got %+v", info)
+	}
+}
+
+func TestTaskProxies(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+
+	resp := env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Write tests", Description: "HTTP level", Status: "not taken"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	var taskID TaskID
+	if err := json.Unmarshal(resp.Body.Bytes(), &taskID); err != nil {
+		t.Fatalf("response of `CreateTask` is not JSON: %v", err)
+	}
+	if created := env.tasks.tasks[taskID.TaskID]; created == nil || created.CreatorUsername != "alice" {
+		t.Fatalf("task should be created by alice, got %+v", created)
+	}
+
+	resp = env.do(t, "GET", "/tasks/1", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var content TaskContent
+	json.Unmarshal(resp.Body.Bytes(), &content)
+	if content.Title != "Write tests" || content.Description != "HTTP level" || content.Status != "not_taken" || len(content.Assignees) != 0 {
+		t.Fatalf("unexpected task: %+v", content)
+	}
+
+	// Only author can change the task
+	resp = env.do(t, "PUT", "/tasks/1", UpdateTaskRequest{Title: "Hijacked"}, bob)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "PUT", "/tasks/1", UpdateTaskRequest{Title: "Write more tests", Status: "in_progress"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	if title := env.tasks.tasks[1].Title; title != "Write more tests" {
+		t.Fatalf("task should be updated, got title %q", title)
+	}
+
+	resp = env.do(t, "GET", "/tasks/page", TaskListRequest{Offset: 0, PageSize: 10}, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var page struct {
+		Tasks []struct {
+			Id int32 `json:"id"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &page); err != nil {
+		t.Fatalf("response of `GetTaskPage` is not JSON: %v", err)
+	}
+	if len(page.Tasks) != 1 || page.Tasks[0].Id != 1 {
+		t.Fatalf("expected page with task 1, got %s", resp.Body.String())
+	}
+
+	resp = env.do(t, "POST", "/tasks/1/view", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/tasks/1/like", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	expectedEvents := []publishedEvent{
+		{Kind: "empty", TaskID: 1, TaskAuthor: "alice"},
+		{Kind: "view", Username: "bob", TaskID: 1, TaskAuthor: "alice"},
+		{Kind: "like", Username: "bob", TaskID: 1, TaskAuthor: "alice"},
+	}
+	if len(env.events.events) != len(expectedEvents) {
+		t.Fatalf("expected events %+v, got %+v", expectedEvents, env.events.events)
+	}
+	for i := range expectedEvents {
+		if env.events.events[i] != expectedEvents[i] {
+			t.Fatalf("expected events %+v, got %+v", expectedEvents, env.events.events)
+		}
+	}
+
+	resp = env.do(t, "DELETE", "/tasks/1", nil, bob)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "DELETE", "/tasks/1", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = env.do(t, "GET", "/tasks/1", nil, alice)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "POST", "/tasks/1/like", nil, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestTaskProxiesRequireAuthentication(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, request := range []struct{ method, path string }{
+		{"POST", "/tasks/"},
+		{"GET", "/tasks/1"},
+		{"PUT", "/tasks/1"},
+		{"DELETE", "/tasks/1"},
+		{"GET", "/tasks/page"},
+		{"POST", "/tasks/1/view"},
+		{"POST", "/tasks/1/like"},
+	} {
+		resp := env.do(t, request.method, request.path, nil, nil)
+		expectStatus(t, resp, http.StatusBadRequest)
+	}
+	if len(env.tasks.tasks) != 0 || len(env.events.events) != 0 {
+		t.Fatal("unauthenticated requests should not reach task service or Kafka")
+	}
+}
+
+func TestTaskProxiesPersonalAccessTokenScopes(t *testing.T) {
+	env := newTestEnv(t)
+	cookies := env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "POST", "/tokens", CreateAccessTokenRequest{Name: "ci", Scopes: []string{ScopeTasksRead}}, cookies)
+	expectStatus(t, resp, http.StatusOK)
+	var token AccessTokenInfo
+	json.Unmarshal(resp.Body.Bytes(), &token)
+
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Write tests"}, cookies)
+	expectStatus(t, resp, http.StatusOK)
+
+	// Token with `tasks:read` scope can read tasks but can't create them
+	resp = env.doWithBearer(t, "GET", "/tasks/1", nil, token.Token)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.doWithBearer(t, "POST", "/tasks/", CreateTaskRequest{Title: "From CI"}, token.Token)
+	expectStatus(t, resp, http.StatusForbidden)
+	if len(env.tasks.tasks) != 1 {
+		t.Fatal("task should not be created without `tasks:write` scope")
+	}
+}
+
+func TestAdminRoutesRequireAdminRole(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "GET", "/admin/lockouts", nil, alice)
+	expectStatus(t, resp, http.StatusForbidden)
+	resp = env.do(t, "PUT", "/admin/users/alice/role", SetRoleBody{Role: "admin"}, alice)
+	expectStatus(t, resp, http.StatusForbidden)
+	resp = env.do(t, "GET", "/admin/lockouts", nil, nil)
+	expectStatus(t, resp, http.StatusBadRequest)
+
+	t.Setenv("BOOTSTRAP_ADMIN", "root")
+	t.Setenv("BOOTSTRAP_ADMIN_PASSWORD", "bootstrap password")
+	if err := BootstrapAdmin(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	root := env.login(t, "root", "bootstrap password")
+
+	resp = env.do(t, "GET", "/admin/lockouts", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "GET", "/profile", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	var profile ProfileInfo
+	json.Unmarshal(resp.Body.Bytes(), &profile)
+	if profile.Role != mongo_handlers.RoleAdmin {
+		t.Fatalf("expected admin role in profile, got %q", profile.Role)
+	}
+}
+
+func TestBootstrapAdminDoesNotPromoteExistingUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "alice", "correct horse")
+
+	// Without password bootstrap admin is not created
+	t.Setenv("BOOTSTRAP_ADMIN", "root")
+	if err := BootstrapAdmin(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	var user mongo_handlers.User
+	if code, _ := env.store.GetUser("root", &user); code != http.StatusNotFound {
+		t.Fatalf("root should not be created without password, got %d", code)
+	}
+
+	// Account registered by anyone with the name of bootstrap admin stays ordinary user
+	t.Setenv("BOOTSTRAP_ADMIN", "alice")
+	t.Setenv("BOOTSTRAP_ADMIN_PASSWORD", "bootstrap password")
+	if err := BootstrapAdmin(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	env.store.GetUser("alice", &user)
+	if user.GetRole() != mongo_handlers.RoleUser {
+		t.Fatalf("alice should not be promoted, got %q", user.GetRole())
+	}
+	env.login(t, "alice", "correct horse")
+
+	// Demoted bootstrap admin is not promoted again on restart
+	t.Setenv("BOOTSTRAP_ADMIN", "root")
+	if err := BootstrapAdmin(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	role := mongo_handlers.RoleUser
+	env.store.UpdateUser("root", mongo_handlers.UserUpdate{Role: &role})
+	if err := BootstrapAdmin(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	env.store.GetUser("root", &user)
+	if user.GetRole() != mongo_handlers.RoleUser {
+		t.Fatalf("demoted root should stay user, got %q", user.GetRole())
+	}
+}
+
+func TestRolesMatchTaskServiceRoles(t *testing.T) {
+	previous := task_servicepb.UserRole_USER_ROLE_UNSPECIFIED
+	for _, role := range mongo_handlers.Roles {
+		userRole := userRoleProto(role)
+		if userRole <= previous {
+			t.Fatalf("role `%s` should have its own value of `UserRole` after %v, got %v", role, previous, userRole)
+		}
+		previous = userRole
+	}
+	if userRoleProto("unknown") != task_servicepb.UserRole_USER_ROLE_UNSPECIFIED {
+		t.Fatal("unknown role should not get rights of known roles")
+	}
+}
+
+func TestModeratorCanEditAnyTask(t *testing.T) {
+	env := newTestEnv(t)
+	t.Setenv("BOOTSTRAP_ADMIN", "root")
+	t.Setenv("BOOTSTRAP_ADMIN_PASSWORD", "bootstrap password")
+	if err := BootstrapAdmin(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	root := env.login(t, "root", "bootstrap password")
+	alice := env.register(t, "alice", "correct horse")
+	env.register(t, "bob", "battery staple")
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Alice's task"}, alice)
+	env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Another alice's task"}, alice)
+
+	resp := env.do(t, "PUT", "/admin/users/bob/role", SetRoleBody{Role: "superuser"}, root)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "PUT", "/admin/users/root/role", SetRoleBody{Role: mongo_handlers.RoleUser}, root)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "PUT", "/admin/users/nobody/role", SetRoleBody{Role: mongo_handlers.RoleModerator}, root)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "PUT", "/admin/users/bob/role", SetRoleBody{Role: mongo_handlers.RoleModerator}, root)
+	expectStatus(t, resp, http.StatusOK)
+
+	// New role is carried by tokens issued after the change
+	bob := env.login(t, "bob", "battery staple")
+	resp = env.do(t, "PUT", "/tasks/1", UpdateTaskRequest{Title: "Moderated"}, bob)
+	expectStatus(t, resp, http.StatusOK)
+	if title := env.tasks.tasks[1].Title; title != "Moderated" {
+		t.Fatalf("moderator should edit any task, got title %q", title)
+	}
+	resp = env.do(t, "DELETE", "/tasks/2", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+
+	// Moderators are not admins
+	resp = env.do(t, "GET", "/admin/lockouts", nil, bob)
+	expectStatus(t, resp, http.StatusForbidden)
+
+	// Ordinary users still can edit only their own tasks
+	resp = env.do(t, "PUT", "/admin/users/bob/role", SetRoleBody{Role: mongo_handlers.RoleUser}, root)
+	expectStatus(t, resp, http.StatusOK)
+	bob = env.login(t, "bob", "battery staple")
+	resp = env.do(t, "PUT", "/tasks/1", UpdateTaskRequest{Title: "Hijacked"}, bob)
+	expectStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestAdminSearchUsers(t *testing.T) {
+	env := newTestEnv(t)
+	root := env.loginAdmin(t)
+	for _, username := range []string{"alice", "bob", "carol", "alina"} {
+		env.register(t, username, "correct horse")
+	}
+
+	resp := env.do(t, "GET", "/admin/users?query=AL&limit=1", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	var page AdminUserPage
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if page.Total != 2 || len(page.Users) != 1 || page.Users[0].Username != "alice" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	resp = env.do(t, "GET", "/admin/users?query=al&offset=1&limit=1", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Users) != 1 || page.Users[0].Username != "alina" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+
+	resp = env.do(t, "GET", "/admin/users?limit=1000", nil, root)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "GET", "/admin/users?offset=-1", nil, root)
+	expectStatus(t, resp, http.StatusBadRequest)
+
+	resp = env.do(t, "GET", "/admin/users/bob", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	var user AdminUserInfo
+	json.Unmarshal(resp.Body.Bytes(), &user)
+	if user.Username != "bob" || !user.HasPassword || len(user.Sessions) != 1 {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	resp = env.do(t, "GET", "/admin/users/nobody", nil, root)
+	expectStatus(t, resp, http.StatusNotFound)
+
+	alice := env.login(t, "alice", "correct horse")
+	resp = env.do(t, "GET", "/admin/users", nil, alice)
+	expectStatus(t, resp, http.StatusForbidden)
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	env := newTestEnv(t)
+	root := env.loginAdmin(t)
+	alice := env.register(t, "alice", "correct horse")
+	resp := env.do(t, "POST", "/tokens", CreateAccessTokenRequest{Name: "ci", Scopes: []string{ScopeTasksRead}}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	var token AccessTokenInfo
+	json.Unmarshal(resp.Body.Bytes(), &token)
+
+	resp = env.do(t, "POST", "/admin/users/root/disable", nil, root)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "POST", "/admin/users/alice/disable", DisableUserBody{Reason: "spam"}, root)
+	expectStatus(t, resp, http.StatusOK)
+
+	// Sessions are revoked and new ones can't be started
+	resp = env.do(t, "GET", "/profile", nil, alice)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	resp = env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "correct horse"}, nil)
+	expectStatus(t, resp, http.StatusForbidden)
+
+	// Personal access tokens are rejected too
+	resp = env.doWithBearer(t, "GET", "/tasks/1", nil, token.Token)
+	expectStatus(t, resp, http.StatusForbidden)
+	var user mongo_handlers.User
+	env.store.GetUser("alice", &user)
+	if !user.Disabled || user.DisabledBy != "root" || user.DisabledReason != "spam" || user.DisabledAt == nil {
+		t.Fatalf("unexpected state of disabled user: %+v", user)
+	}
+
+	resp = env.do(t, "POST", "/admin/users/alice/enable", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	alice = env.login(t, "alice", "correct horse")
+	resp = env.do(t, "GET", "/profile", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	user = mongo_handlers.User{}
+	env.store.GetUser("alice", &user)
+	if user.Disabled || user.DisabledBy != "" || user.DisabledReason != "" || user.DisabledAt != nil {
+		t.Fatalf("unexpected state of enabled user: %+v", user)
+	}
+
+	resp = env.do(t, "POST", "/admin/users/nobody/disable", nil, root)
+	expectStatus(t, resp, http.StatusNotFound)
+
+	var actions []string
+	for _, event := range env.store.auditEvents {
+		if !strings.HasPrefix(event.Action, "admin.") {
+			continue
+		}
+		if event.Actor != "root" || event.Target == "root" {
+			t.Fatalf("unexpected audit event: %+v", event)
+		}
+		actions = append(actions, event.Action+" "+event.Target+" "+event.Outcome)
+	}
+	expected := []string{
+		"admin.disable_user alice success",
+		"admin.enable_user alice success",
+		"admin.disable_user nobody failure",
+	}
+	if !slices.Equal(actions, expected) {
+		t.Fatalf("expected audit events %v, got %v", expected, actions)
+	}
+}
+
+func TestAdminLogoutUserAndResetPassword(t *testing.T) {
+	env := newTestEnv(t)
+	root := env.loginAdmin(t)
+	alice := env.register(t, "alice", "correct horse")
+
+	resp := env.do(t, "POST", "/admin/users/alice/logout", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "GET", "/profile", nil, alice)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	resp = env.do(t, "POST", "/admin/users/nobody/logout", nil, root)
+	expectStatus(t, resp, http.StatusNotFound)
+
+	alice = env.login(t, "alice", "correct horse")
+	resp = env.do(t, "POST", "/admin/users/alice/password/reset", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	var password TemporaryPassword
+	json.Unmarshal(resp.Body.Bytes(), &password)
+	if password.TemporaryPassword == "" {
+		t.Fatal("temporary password is empty")
+	}
+
+	resp = env.do(t, "GET", "/profile", nil, alice)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	resp = env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "correct horse"}, nil)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	env.login(t, "alice", password.TemporaryPassword)
+
+	var events []mongo_handlers.AuditEvent
+	env.store.GetAuditEvents(mongo_handlers.AuditEventFilter{Actor: "root", Action: AuditActionResetPassword}, 0, 10, &events)
+	if len(events) != 1 || events[0].Target != "alice" || events[0].Outcome != AuditOutcomeSuccess {
+		t.Fatalf("unexpected audit events: %+v", events)
+	}
+}
+
+func TestAuditLogRecordsAuthenticationEvents(t *testing.T) {
+	env := newTestEnv(t)
+	root := env.loginAdmin(t)
+	start := time.Now()
+
+	alice := env.register(t, "alice", "correct horse")
+	resp := env.do(t, "POST", "/register", RegisterBody{Username: "alice", Password: "other password"}, nil)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "wrong password"}, nil)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	resp = env.do(t, "PUT", "/profile", ProfileInfo{FirstName: "Alice"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/tokens", CreateAccessTokenRequest{Name: "ci", Scopes: []string{ScopeTasksRead}}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	var token AccessTokenInfo
+	json.Unmarshal(resp.Body.Bytes(), &token)
+	resp = env.do(t, "DELETE", "/tokens/"+token.ID, nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/logout", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	env.store.StoreTwoFactor(mongo_handlers.TwoFactor{Username: "alice", Enabled: true})
+	resp = env.do(t, "POST", "/authenticate", AuthenticateBody{Username: "alice", Password: "correct horse"}, nil)
+	expectStatus(t, resp, http.StatusOK)
+	env.store.DeleteTwoFactor("alice")
+
+	resp = env.do(t, "GET", "/admin/audit?target=alice", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	var page AuditEventPage
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	var actions []string
+	for _, event := range page.Events {
+		actions = append(actions, event.Action+" "+event.Outcome)
+		if event.IP != "192.0.2.1" || event.CreatedAt.Before(start) {
+			t.Fatalf("unexpected audit event: %+v", event)
+		}
+	}
+	// The latest first
+	expected := []string{
+		"auth.2fa_challenge success",
+		"auth.logout success",
+		"token.revoke success",
+		"token.create success",
+		"profile.update success",
+		"auth.login failure",
+		"auth.register failure",
+		"auth.register success",
+	}
+	if !slices.Equal(actions, expected) {
+		t.Fatalf("expected audit events %v, got %v", expected, actions)
+	}
+	if actor := page.Events[0].Actor; actor != "" {
+		t.Fatalf("two-factor challenge should have no actor, got %q", actor)
+	}
+	if details := page.Events[4].Details; details != "fields: firstName" {
+		t.Fatalf("profile update should have names of changed fields, got %q", details)
+	}
+	if actor := page.Events[5].Actor; actor != "" {
+		t.Fatalf("failed login should have no actor, got %q", actor)
+	}
+
+	resp = env.do(t, "GET", "/admin/audit?target=alice&outcome=failure", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Events) != 2 || page.Events[0].Details != "incorrect password" || page.Events[1].Details != "username is already taken" {
+		t.Fatalf("unexpected failed events: %+v", page.Events)
+	}
+	resp = env.do(t, "GET", "/admin/audit?actor=alice&action=token.create", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Events) != 1 {
+		t.Fatalf("expected 1 event, got %+v", page.Events)
+	}
+	from := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+	resp = env.do(t, "GET", "/admin/audit?from="+from, nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Events) != 0 {
+		t.Fatalf("expected no events in the future, got %+v", page.Events)
+	}
+	resp = env.do(t, "GET", "/admin/audit?target=alice&limit=2&offset=2", nil, root)
+	expectStatus(t, resp, http.StatusOK)
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Events) != 2 || page.Events[0].Action != AuditActionRevokeAccessToken {
+		t.Fatalf("unexpected page: %+v", page.Events)
+	}
+
+	resp = env.do(t, "GET", "/admin/audit?from=yesterday", nil, root)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "GET", "/admin/audit?outcome=maybe", nil, root)
+	expectStatus(t, resp, http.StatusBadRequest)
+	alice = env.login(t, "alice", "correct horse")
+	resp = env.do(t, "GET", "/admin/audit", nil, alice)
+	expectStatus(t, resp, http.StatusForbidden)
+}
+
+func TestWorkspaceInvitations(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+	carol := env.register(t, "carol", "correct horse")
+
+	resp := env.do(t, "POST", "/workspaces", CreateWorkspaceBody{Name: "  "}, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "POST", "/workspaces", CreateWorkspaceBody{Name: "Team"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var workspace WorkspaceInfo
+	json.Unmarshal(resp.Body.Bytes(), &workspace)
+	if workspace.Name != "Team" || workspace.Owner != "alice" || workspace.Role != mongo_handlers.WorkspaceRoleOwner {
+		t.Fatalf("unexpected workspace: %+v", workspace)
+	}
+	path := "/workspaces/" + workspace.ID
+
+	// Workspace is hidden from non-members
+	resp = env.do(t, "GET", path, nil, bob)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "POST", path+"/invitations", CreateInvitationBody{Username: "carol"}, bob)
+	expectStatus(t, resp, http.StatusNotFound)
+
+	// Invitation of user
+	resp = env.do(t, "POST", path+"/invitations", CreateInvitationBody{Username: "nobody"}, alice)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "POST", path+"/invitations", CreateInvitationBody{Username: "bob", Role: mongo_handlers.WorkspaceRoleOwner}, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "POST", path+"/invitations", CreateInvitationBody{Username: "bob"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var invitation InvitationInfo
+	json.Unmarshal(resp.Body.Bytes(), &invitation)
+	if invitation.Role != mongo_handlers.WorkspaceRoleMember || invitation.Token != "" {
+		t.Fatalf("unexpected invitation: %+v", invitation)
+	}
+
+	resp = env.do(t, "GET", "/invitations", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var invitations []InvitationInfo
+	json.Unmarshal(resp.Body.Bytes(), &invitations)
+	if len(invitations) != 1 || invitations[0].ID != invitation.ID || invitations[0].WorkspaceName != "Team" {
+		t.Fatalf("unexpected invitations of bob: %+v", invitations)
+	}
+	resp = env.do(t, "POST", "/invitations/"+invitation.ID+"/accept", nil, carol)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "POST", "/invitations/"+invitation.ID+"/accept", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/invitations/"+invitation.ID+"/accept", nil, bob)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "POST", path+"/invitations", CreateInvitationBody{Username: "bob"}, alice)
+	expectStatus(t, resp, http.StatusConflict)
+
+	// Members can't invite, admins can
+	resp = env.do(t, "POST", path+"/invitations", CreateInvitationBody{}, bob)
+	expectStatus(t, resp, http.StatusForbidden)
+	resp = env.do(t, "PUT", path+"/members/alice/role", SetRoleBody{Role: mongo_handlers.WorkspaceRoleMember}, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "PUT", path+"/members/bob/role", SetRoleBody{Role: mongo_handlers.WorkspaceRoleAdmin}, alice)
+	expectStatus(t, resp, http.StatusOK)
+
+	// Invitation by link
+	resp = env.do(t, "POST", path+"/invitations", CreateInvitationBody{}, bob)
+	expectStatus(t, resp, http.StatusCreated)
+	var link InvitationInfo
+	json.Unmarshal(resp.Body.Bytes(), &link)
+	if link.Token == "" || !strings.Contains(link.Link, url.QueryEscape(link.Token)) {
+		t.Fatalf("invitation by link should return token and link: %+v", link)
+	}
+	resp = env.do(t, "GET", path+"/invitations", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	if strings.Contains(resp.Body.String(), link.Token) {
+		t.Fatalf("token of link should be returned only once: %s", resp.Body.String())
+	}
+	resp = env.do(t, "POST", "/invitations/join?token=wrong", nil, carol)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "POST", "/invitations/join?token="+url.QueryEscape(link.Token), nil, carol)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/invitations/join?token="+url.QueryEscape(link.Token), nil, carol)
+	expectStatus(t, resp, http.StatusConflict)
+
+	resp = env.do(t, "GET", path, nil, carol)
+	expectStatus(t, resp, http.StatusOK)
+	json.Unmarshal(resp.Body.Bytes(), &workspace)
+	roles := map[string]string{}
+	for _, member := range workspace.Members {
+		roles[member.Username] = member.Role
+	}
+	if len(roles) != 3 || roles["alice"] != mongo_handlers.WorkspaceRoleOwner ||
+		roles["bob"] != mongo_handlers.WorkspaceRoleAdmin || roles["carol"] != mongo_handlers.WorkspaceRoleMember {
+		t.Fatalf("unexpected members: %+v", workspace.Members)
+	}
+
+	// Revoked link can't be used
+	dave := env.register(t, "dave", "correct horse")
+	resp = env.do(t, "DELETE", path+"/invitations/"+link.ID, nil, carol)
+	expectStatus(t, resp, http.StatusForbidden)
+	resp = env.do(t, "DELETE", path+"/invitations/"+link.ID, nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/invitations/join?token="+url.QueryEscape(link.Token), nil, dave)
+	expectStatus(t, resp, http.StatusNotFound)
+
+	// Owner can't leave, others can leave or be removed by admins
+	resp = env.do(t, "DELETE", path+"/members/alice", nil, bob)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "DELETE", path+"/members/alice", nil, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "DELETE", path+"/members/bob", nil, carol)
+	expectStatus(t, resp, http.StatusForbidden)
+	resp = env.do(t, "DELETE", path+"/members/carol", nil, carol)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "DELETE", path+"/members/bob", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = env.do(t, "GET", "/workspaces", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var workspaces []WorkspaceInfo
+	json.Unmarshal(resp.Body.Bytes(), &workspaces)
+	if len(workspaces) != 0 {
+		t.Fatalf("removed member should have no workspaces, got %+v", workspaces)
+	}
+}
+
+func TestWorkspaceTaskScoping(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+	carol := env.register(t, "carol", "correct horse")
+
+	resp := env.do(t, "POST", "/workspaces", CreateWorkspaceBody{Name: "Team"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var workspace WorkspaceInfo
+	json.Unmarshal(resp.Body.Bytes(), &workspace)
+	resp = env.do(t, "POST", "/workspaces/"+workspace.ID+"/invitations", CreateInvitationBody{Username: "bob"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var invitation InvitationInfo
+	json.Unmarshal(resp.Body.Bytes(), &invitation)
+	resp = env.do(t, "POST", "/invitations/"+invitation.ID+"/accept", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Secret", WorkspaceID: workspace.ID}, carol)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Secret", WorkspaceID: workspace.ID}, bob)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Public"}, carol)
+	expectStatus(t, resp, http.StatusOK)
+
+	// Task of workspace is visible only to members
+	resp = env.do(t, "GET", "/tasks/1", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	var content TaskContent
+	json.Unmarshal(resp.Body.Bytes(), &content)
+	if content.WorkspaceID != workspace.ID {
+		t.Fatalf("task should belong to workspace, got %+v", content)
+	}
+	for _, request := range []struct {
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"GET", "/tasks/1", nil, http.StatusNotFound},
+		{"GET", "/tasks/1/stats", nil, http.StatusNotFound},
+		{"POST", "/tasks/1/view", nil, http.StatusBadRequest},
+		{"POST", "/tasks/1/like", nil, http.StatusBadRequest},
+		{"PUT", "/tasks/1", UpdateTaskRequest{Title: "Hijacked"}, http.StatusBadRequest},
+		{"DELETE", "/tasks/1", nil, http.StatusBadRequest},
+		{"GET", "/tasks/page", TaskListRequest{PageSize: 10, WorkspaceID: workspace.ID}, http.StatusNotFound},
+		{"GET", "/top/users?workspace_id=" + workspace.ID, nil, http.StatusNotFound},
+	} {
+		resp = env.do(t, request.method, request.path, request.body, carol)
+		if resp.Code != request.status {
+			t.Fatalf("%s %s by non-member: expected status %d, got %d: %s", request.method, request.path, request.status, resp.Code, resp.Body.String())
+		}
+	}
+
+	// Lists are scoped by workspace
+	taskIDs := func(body TaskListRequest) []int32 {
+		t.Helper()
+		resp := env.do(t, "GET", "/tasks/page", body, alice)
+		expectStatus(t, resp, http.StatusOK)
+		var page struct {
+			Tasks []struct {
+				Id int32 `json:"id"`
+			} `json:"tasks"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &page)
+		ids := []int32{}
+		for _, task := range page.Tasks {
+			ids = append(ids, task.Id)
+		}
+		return ids
+	}
+	if ids := taskIDs(TaskListRequest{PageSize: 10, WorkspaceID: workspace.ID}); !slices.Equal(ids, []int32{1}) {
+		t.Fatalf("expected only task of workspace, got %v", ids)
+	}
+	if ids := taskIDs(TaskListRequest{PageSize: 10}); !slices.Equal(ids, []int32{2}) {
+		t.Fatalf("expected only task outside of workspaces, got %v", ids)
+	}
+
+	// Events carry workspace, so statistics can be scoped too
+	resp = env.do(t, "POST", "/tasks/1/like", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	last := env.events.events[len(env.events.events)-1]
+	if last.Kind != "like" || last.WorkspaceID != workspace.ID {
+		t.Fatalf("like should carry workspace, got %+v", last)
+	}
+	resp = env.do(t, "GET", "/top/tasks/likes?workspace_id="+workspace.ID, nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	if !strings.Contains(resp.Body.String(), workspace.ID) {
+		t.Fatalf("workspace should be passed to statistics service: %s", resp.Body.String())
+	}
+
+	// Admins of workspace can edit every task of workspace
+	resp = env.do(t, "PUT", "/tasks/1", UpdateTaskRequest{Title: "Edited by owner"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	if title := env.tasks.tasks[1].Title; title != "Edited by owner" {
+		t.Fatalf("owner should edit task of workspace, got title %q", title)
+	}
+}
+
+func TestTaskStatusWorkflow(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+
+	// Task gets initial status of workflow, unknown statuses are rejected
+	resp := env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Release"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Release", Status: "ready"}, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+	if status := env.tasks.tasks[1].Status; status != task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN {
+		t.Fatalf("task should get initial status, got %v", status)
+	}
+
+	// Transitions should be allowed by workflow, nothing leads out of terminal status
+	for _, step := range []struct {
+		status string
+		code   int
+	}{
+		{"done", http.StatusConflict},
+		{"in_progress", http.StatusOK},
+		{"", http.StatusOK},
+		{"done", http.StatusOK},
+		{"in_progress", http.StatusConflict},
+	} {
+		resp = env.do(t, "PUT", "/tasks/1", UpdateTaskRequest{Title: "Release", Status: step.status}, alice)
+		if resp.Code != step.code {
+			t.Fatalf("transition to %q: expected status %d, got %d: %s", step.status, step.code, resp.Code, resp.Body.String())
+		}
+	}
+
+	resp = env.do(t, "GET", "/tasks/1/history", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var history []StatusTransitionInfo
+	json.Unmarshal(resp.Body.Bytes(), &history)
+	if len(history) != 3 || history[0].From != "" || history[0].To != "not_taken" ||
+		history[1].To != "in_progress" || history[2].From != "in_progress" || history[2].To != "done" || history[2].Username != "alice" {
+		t.Fatalf("unexpected history: %s", resp.Body.String())
+	}
+
+	// Tasks can be filtered by status
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Next release"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "GET", "/tasks/page", TaskListRequest{PageSize: 10, Status: "done"}, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var page TaskPage
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Tasks) != 1 || page.Tasks[0].ID != 1 || page.Tasks[0].Task.Status != "done" {
+		t.Fatalf("expected page with task 1, got %s", resp.Body.String())
+	}
+
+	// Workflow of workspace is configured by its admins
+	resp = env.do(t, "POST", "/workspaces", CreateWorkspaceBody{Name: "Team"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var workspace WorkspaceInfo
+	json.Unmarshal(resp.Body.Bytes(), &workspace)
+	resp = env.do(t, "POST", "/workspaces/"+workspace.ID+"/invitations", CreateInvitationBody{Username: "bob"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var invitation InvitationInfo
+	json.Unmarshal(resp.Body.Bytes(), &invitation)
+	resp = env.do(t, "POST", "/invitations/"+invitation.ID+"/accept", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = env.do(t, "GET", "/workspaces/"+workspace.ID+"/workflow", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var workflow WorkflowBody
+	json.Unmarshal(resp.Body.Bytes(), &workflow)
+	if workflow.InitialStatus != "not_taken" || len(workflow.Statuses) == 0 {
+		t.Fatalf("workspace should use default workflow, got %s", resp.Body.String())
+	}
+
+	kanban := WorkflowBody{
+		InitialStatus:    "in_progress",
+		Statuses:         []string{"in_progress", "can_be_tested", "done"},
+		Transitions:      []WorkflowTransitionBody{{From: "in_progress", To: "can_be_tested"}, {From: "can_be_tested", To: "done"}},
+		TerminalStatuses: []string{"done"},
+	}
+	resp = env.do(t, "PUT", "/workspaces/"+workspace.ID+"/workflow", kanban, bob)
+	expectStatus(t, resp, http.StatusForbidden)
+	resp = env.do(t, "PUT", "/workspaces/"+workspace.ID+"/workflow", WorkflowBody{InitialStatus: "blocked"}, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "PUT", "/workspaces/"+workspace.ID+"/workflow", kanban, alice)
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Board", WorkspaceID: workspace.ID}, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var taskID TaskID
+	json.Unmarshal(resp.Body.Bytes(), &taskID)
+	path := "/tasks/" + strconv.Itoa(int(taskID.TaskID))
+	resp = env.do(t, "GET", path, nil, bob)
+	var content TaskContent
+	json.Unmarshal(resp.Body.Bytes(), &content)
+	if content.Status != "in_progress" {
+		t.Fatalf("task should get initial status of workspace's workflow, got %+v", content)
+	}
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Board", Status: "not_taken", WorkspaceID: workspace.ID}, bob)
+	expectStatus(t, resp, http.StatusConflict)
+
+	// Workflow can't drop statuses which tasks of workspace have
+	kanban.InitialStatus = "can_be_tested"
+	kanban.Statuses = []string{"can_be_tested", "done"}
+	kanban.Transitions = kanban.Transitions[1:]
+	resp = env.do(t, "PUT", "/workspaces/"+workspace.ID+"/workflow", kanban, alice)
+	expectStatus(t, resp, http.StatusConflict)
+}
+
+func TestTaskAssignees(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+	bob := env.register(t, "bob", "battery staple")
+	carol := env.register(t, "carol", "correct horse")
+
+	resp := env.do(t, "POST", "/workspaces", CreateWorkspaceBody{Name: "Team"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var workspace WorkspaceInfo
+	json.Unmarshal(resp.Body.Bytes(), &workspace)
+	resp = env.do(t, "POST", "/workspaces/"+workspace.ID+"/invitations", CreateInvitationBody{Username: "bob"}, alice)
+	expectStatus(t, resp, http.StatusCreated)
+	var invitation InvitationInfo
+	json.Unmarshal(resp.Body.Bytes(), &invitation)
+	resp = env.do(t, "POST", "/invitations/"+invitation.ID+"/accept", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Roadmap", WorkspaceID: workspace.ID}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Public"}, carol)
+	expectStatus(t, resp, http.StatusOK)
+
+	// Only author and moderators can assign, task of workspace only to its members
+	for _, request := range []struct {
+		path     string
+		assignee string
+		cookies  []*http.Cookie
+		status   int
+	}{
+		{"/tasks/1/assignees", "bob", bob, http.StatusForbidden},
+		{"/tasks/1/assignees", "bob", carol, http.StatusNotFound},
+		{"/tasks/1/assignees", "carol", alice, http.StatusBadRequest},
+		{"/tasks/1/assignees", "nobody", alice, http.StatusNotFound},
+		{"/tasks/3/assignees", "bob", alice, http.StatusNotFound},
+		{"/tasks/1/assignees", "bob", alice, http.StatusOK},
+		{"/tasks/1/assignees", "bob", alice, http.StatusOK},
+		{"/tasks/2/assignees", "bob", carol, http.StatusOK},
+	} {
+		resp = env.do(t, "POST", request.path, AssignTaskBody{Username: request.assignee}, request.cookies)
+		if resp.Code != request.status {
+			t.Fatalf("assign %s via %s: expected status %d, got %d: %s", request.assignee, request.path, request.status, resp.Code, resp.Body.String())
+		}
+	}
+
+	resp = env.do(t, "GET", "/tasks/1", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	var content TaskContent
+	json.Unmarshal(resp.Body.Bytes(), &content)
+	if !slices.Equal(content.Assignees, []string{"bob"}) {
+		t.Fatalf("bob should be assigned once, got %+v", content)
+	}
+
+	myTaskIDs := func(query string, cookies []*http.Cookie) []int32 {
+		t.Helper()
+		resp := env.do(t, "GET", "/me/tasks"+query, nil, cookies)
+		expectStatus(t, resp, http.StatusOK)
+		var page TaskPage
+		json.Unmarshal(resp.Body.Bytes(), &page)
+		ids := []int32{}
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	if ids := myTaskIDs("", bob); !slices.Equal(ids, []int32{1, 2}) {
+		t.Fatalf("bob should see both assigned tasks, got %v", ids)
+	}
+	if ids := myTaskIDs("?limit=1&offset=1", bob); !slices.Equal(ids, []int32{2}) {
+		t.Fatalf("expected second page with task 2, got %v", ids)
+	}
+	if ids := myTaskIDs("?status=done", bob); len(ids) != 0 {
+		t.Fatalf("no task of bob is done, got %v", ids)
+	}
+	if ids := myTaskIDs("", carol); !slices.Equal(ids, []int32{2}) {
+		t.Fatalf("carol should see task she created, got %v", ids)
+	}
+	resp = env.do(t, "GET", "/me/tasks?limit=1000", nil, bob)
+	expectStatus(t, resp, http.StatusBadRequest)
+	resp = env.do(t, "GET", "/me/tasks?status=ready", nil, bob)
+	expectStatus(t, resp, http.StatusBadRequest)
+
+	// Tasks of workspace disappear from the list when user leaves it
+	resp = env.do(t, "DELETE", "/workspaces/"+workspace.ID+"/members/bob", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	if ids := myTaskIDs("", bob); !slices.Equal(ids, []int32{2}) {
+		t.Fatalf("bob should not see tasks of workspace he left, got %v", ids)
+	}
+
+	// Assignee can unassign himself, other users can't unassign him
+	resp = env.do(t, "POST", "/tasks/2/assignees", AssignTaskBody{Username: "alice"}, carol)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "DELETE", "/tasks/2/assignees/alice", nil, bob)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "DELETE", "/tasks/2/assignees/bob", nil, bob)
+	expectStatus(t, resp, http.StatusOK)
+	resp = env.do(t, "DELETE", "/tasks/2/assignees/bob", nil, bob)
+	expectStatus(t, resp, http.StatusNotFound)
+	resp = env.do(t, "DELETE", "/tasks/2/assignees/alice", nil, carol)
+	expectStatus(t, resp, http.StatusOK)
+	if assignees := env.tasks.tasks[2].Assignees; len(assignees) != 0 {
+		t.Fatalf("task should have no assignees, got %v", assignees)
+	}
+
+	// Task deleted between visibility check and assignment is reported as missing
+	env.tasks.deletedBeforeAssign = true
+	resp = env.do(t, "POST", "/tasks/2/assignees", AssignTaskBody{Username: "alice"}, carol)
+	expectStatus(t, resp, http.StatusNotFound)
+}
+
+func TestTaskDeadlinesAndPriorities(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.register(t, "alice", "correct horse")
+
+	now := time.Now()
+	yesterday, soon, later, lastWeek := now.Add(-24*time.Hour), now.Add(3*24*time.Hour), now.Add(10*24*time.Hour), now.Add(-7*24*time.Hour)
+	for _, request := range []CreateTaskRequest{
+		{Title: "Overdue", Priority: "p0", DueAt: &yesterday},
+		{Title: "Soon", DueAt: &soon},
+		{Title: "Later", Priority: "P4", DueAt: &later},
+		{Title: "No deadline"},
+		{Title: "Finished", DueAt: &lastWeek},
+	} {
+		resp := env.do(t, "POST", "/tasks/", request, alice)
+		expectStatus(t, resp, http.StatusOK)
+	}
+	resp := env.do(t, "POST", "/tasks/", CreateTaskRequest{Title: "Broken", Priority: "P5"}, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+	for _, taskStatus := range []string{"in_progress", "done"} {
+		resp = env.do(t, "PUT", "/tasks/5", UpdateTaskRequest{Title: "Finished", Status: taskStatus, DueAt: &lastWeek}, alice)
+		expectStatus(t, resp, http.StatusOK)
+	}
+
+	resp = env.do(t, "GET", "/tasks/1", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	var content TaskContent
+	json.Unmarshal(resp.Body.Bytes(), &content)
+	if content.Priority != "P0" || content.DueAt == nil || !content.DueAt.Equal(yesterday) || content.CreatedAt == nil || content.UpdatedAt == nil {
+		t.Fatalf("unexpected task: %s", resp.Body.String())
+	}
+	resp = env.do(t, "GET", "/tasks/4", nil, alice)
+	content = TaskContent{}
+	json.Unmarshal(resp.Body.Bytes(), &content)
+	if content.Priority != "P2" || content.DueAt != nil {
+		t.Fatalf("task should get default priority and no deadline, got %s", resp.Body.String())
+	}
+
+	// Views by deadline skip finished tasks and are sorted by deadline
+	pageIDs := func(body TaskListRequest) []int32 {
+		t.Helper()
+		resp := env.do(t, "GET", "/tasks/page", body, alice)
+		expectStatus(t, resp, http.StatusOK)
+		var page TaskPage
+		json.Unmarshal(resp.Body.Bytes(), &page)
+		ids := []int32{}
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	if ids := pageIDs(TaskListRequest{PageSize: 10, Overdue: true}); !slices.Equal(ids, []int32{1}) {
+		t.Fatalf("expected only overdue task 1, got %v", ids)
+	}
+	if ids := pageIDs(TaskListRequest{PageSize: 10, DueWithinDays: 7}); !slices.Equal(ids, []int32{2}) {
+		t.Fatalf("expected task 2 due within a week, got %v", ids)
+	}
+	if ids := pageIDs(TaskListRequest{PageSize: 10, DueWithinDays: 30}); !slices.Equal(ids, []int32{2, 3}) {
+		t.Fatalf("expected tasks 2 and 3 due within a month, got %v", ids)
+	}
+	resp = env.do(t, "GET", "/tasks/page", TaskListRequest{PageSize: 10, Overdue: true, DueWithinDays: 7}, alice)
+	expectStatus(t, resp, http.StatusBadRequest)
+
+	resp = env.do(t, "GET", "/me/tasks?overdue=true", nil, alice)
+	expectStatus(t, resp, http.StatusOK)
+	var page TaskPage
+	json.Unmarshal(resp.Body.Bytes(), &page)
+	if len(page.Tasks) != 1 || page.Tasks[0].ID != 1 || page.Tasks[0].Task.Priority != "P0" || page.Tasks[0].Task.DueAt == nil {
+		t.Fatalf("expected overdue task 1, got %s", resp.Body.String())
+	}
+	for _, query := range []string{"?overdue=maybe", "?due_within_days=0", "?due_within_days=1000", "?overdue=true&due_within_days=7"} {
+		resp = env.do(t, "GET", "/me/tasks"+query, nil, alice)
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("GET /me/tasks%s: expected status %d, got %d", query, http.StatusBadRequest, resp.Code)
+		}
+	}
+
+	// Update without deadline removes it and keeps priority
+	resp = env.do(t, "PUT", "/tasks/1", UpdateTaskRequest{Title: "Overdue"}, alice)
+	expectStatus(t, resp, http.StatusOK)
+	if task := env.tasks.tasks[1]; task.DueAt != nil || task.Priority != task_servicepb.TaskPriority_TASK_PRIORITY_P0 {
+		t.Fatalf("deadline should be removed and priority kept, got %+v", task)
+	}
+	if ids := pageIDs(TaskListRequest{PageSize: 10, Overdue: true}); len(ids) != 0 {
+		t.Fatalf("no task should be overdue, got %v", ids)
+	}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	env := newTestEnv(t)
+	idp := newFakeIdentityProvider(t)
+
+	startLogin := func() (state string, nonce string, stateCookie *http.Cookie) {
+		t.Helper()
+		resp := env.do(t, "GET", "/oidc/login", nil, nil)
+		expectStatus(t, resp, http.StatusFound)
+		location, err := url.Parse(resp.Header().Get("Location"))
+		if err != nil || !strings.HasPrefix(location.String(), idp.server.URL+"/authorize") {
+			t.Fatalf("login should redirect to identity provider, got %q", resp.Header().Get("Location"))
+		}
+		state, nonce = location.Query().Get("state"), location.Query().Get("nonce")
+		stateCookie = findCookie(resp.Result().Cookies(), oidcStateCookieName)
+		if stateCookie == nil || stateCookie.Value != state || !stateCookie.HttpOnly || !stateCookie.Secure || stateCookie.SameSite != http.SameSiteLaxMode {
+			t.Fatalf("login should set HttpOnly, Secure, SameSite=Lax cookie with state, got %+v", stateCookie)
+		}
+		return state, nonce, stateCookie
+	}
+	callback := func(state string, code string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		return env.do(t, "GET", "/oidc/callback?"+url.Values{"state": {state}, "code": {code}}.Encode(), nil, cookies)
+	}
+
+	// Callback with login of attacker is rejected in victim's browser
+	attackerState, attackerNonce, _ := startLogin()
+	attackerCode := idp.login(attackerNonce, "attacker-subject", "mallory")
+	resp := callback(attackerState, attackerCode)
+	expectStatus(t, resp, http.StatusBadRequest)
+	_, _, victimCookie := startLogin()
+	resp = callback(attackerState, attackerCode, victimCookie)
+	expectStatus(t, resp, http.StatusBadRequest)
+	if env.store.CheckIfUserExists("mallory") {
+		t.Fatal("rejected callback should not create user")
+	}
+
+	// The first login creates user, the next ones log into the same account
+	for i := 0; i < 2; i++ {
+		state, nonce, stateCookie := startLogin()
+		resp = callback(state, idp.login(nonce, "alice-subject", "alice"), stateCookie)
+		expectStatus(t, resp, http.StatusOK)
+		if resp.Body.String() != "Logged in as alice\n" {
+			t.Fatalf("login %d: unexpected response %q", i+1, resp.Body.String())
+		}
+		if findCookie(resp.Result().Cookies(), "token") == nil {
+			t.Fatal("callback should start session")
+		}
+		if cleared := findCookie(resp.Result().Cookies(), oidcStateCookieName); cleared == nil || cleared.MaxAge >= 0 {
+			t.Fatalf("callback should clear state cookie, got %+v", cleared)
+		}
+	}
+
+	// User whose identity wasn't linked is deleted, so the next login doesn't create one more user
+	SetStores(failingIdentityStore{env.store}, env.store)
+	state, nonce, stateCookie := startLogin()
+	resp = callback(state, idp.login(nonce, "bob-subject", "bob"), stateCookie)
+	expectStatus(t, resp, http.StatusInternalServerError)
+	if env.store.CheckIfUserExists("bob") {
+		t.Fatal("user without linked identity should be deleted")
+	}
+	SetStores(env.store, env.store)
+	state, nonce, stateCookie = startLogin()
+	resp = callback(state, idp.login(nonce, "bob-subject", "bob"), stateCookie)
+	expectStatus(t, resp, http.StatusOK)
+	if resp.Body.String() != "Logged in as bob\n" {
+		t.Fatalf("unexpected response %q", resp.Body.String())
+	}
+}
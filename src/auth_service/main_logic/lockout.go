@@ -0,0 +1,150 @@
+package auth_service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"mongo_handlers"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Brute-force protection of `Authenticate`
+//
+//	Failed logins are counted per username and per IP address. After threshold is reached,
+//	every next failure locks login for exponentially growing time (from `lockoutBaseDuration`
+//	up to `lockoutMaxDuration`). Counters are forgotten after `loginAttemptsTTL` without failures
+const (
+	userLockoutThreshold = 5
+	ipLockoutThreshold   = 20
+
+	lockoutBaseDuration = time.Minute
+	lockoutMaxDuration  = time.Hour
+
+	loginAttemptsTTL = 24 * time.Hour
+
+	userAttemptsKeyPrefix = "user:"
+	ipAttemptsKeyPrefix   = "ip:"
+)
+
+// Error of locked login with time after which login can be tried again
+type LoginLockedError struct {
+	Code       int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return e.Message
+}
+
+// Write error response with `Retry-After` header
+func (e *LoginLockedError) Write(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	http.Error(w, e.Message, e.Code)
+}
+
+// Check if login is not locked for username or IP address
+//
+//	Locked IP address gets 429 (Status Too Many Requests), locked account gets 423 (Status Locked)
+func CheckLoginAllowed(username string, ip string) (lockedErr *LoginLockedError, code int, err error) {
+	now := time.Now()
+
+	var attempts mongo_handlers.LoginAttempts
+	code, err = userStore.GetLoginAttempts(ipAttemptsKeyPrefix+ip, &attempts)
+	if err != nil && code != http.StatusNotFound {
+		return nil, code, err
+	}
+	if err == nil && now.Before(attempts.LockedUntil) {
+		return &LoginLockedError{
+			Code:       http.StatusTooManyRequests,
+			Message:    "Too many failed login attempts from your address, try again later",
+			RetryAfter: attempts.LockedUntil.Sub(now),
+		}, http.StatusOK, nil
+	}
+
+	attempts = mongo_handlers.LoginAttempts{}
+	code, err = userStore.GetLoginAttempts(userAttemptsKeyPrefix+username, &attempts)
+	if err != nil && code != http.StatusNotFound {
+		return nil, code, err
+	}
+	if err == nil && now.Before(attempts.LockedUntil) {
+		return &LoginLockedError{
+			Code:       http.StatusLocked,
+			Message:    "Account is temporarily locked because of too many failed login attempts",
+			RetryAfter: attempts.LockedUntil.Sub(now),
+		}, http.StatusOK, nil
+	}
+
+	return nil, http.StatusOK, nil
+}
+
+// Count failed login for username and IP address and lock them if there are too many failures
+func RecordFailedLogin(username string, ip string) {
+	recordFailure(userAttemptsKeyPrefix+username, userLockoutThreshold, ip)
+	recordFailure(ipAttemptsKeyPrefix+ip, ipLockoutThreshold, ip)
+}
+
+func recordFailure(key string, threshold int, ip string) {
+	now := time.Now()
+
+	var attempts mongo_handlers.LoginAttempts
+	if err := userStore.RecordLoginFailure(key, now, loginAttemptsTTL, &attempts); err != nil {
+		log.Println("function `recordFailure`:", err.Error())
+		return
+	}
+	if attempts.Failures < threshold {
+		return
+	}
+
+	lockedUntil := now.Add(lockoutDuration(attempts.Failures - threshold))
+	if err := userStore.LockLogin(key, lockedUntil); err != nil {
+		log.Println("function `recordFailure`:", err.Error())
+		return
+	}
+
+	err := userStore.StoreLockoutEvent(mongo_handlers.LockoutEvent{
+		ID:          uuid.New().String(),
+		Key:         key,
+		Failures:    attempts.Failures,
+		IP:          ip,
+		LockedAt:    now,
+		LockedUntil: lockedUntil,
+	})
+	if err != nil {
+		log.Println("function `recordFailure`:", err.Error())
+	}
+	log.Printf("Login for `%s` is locked until %s after %d failed attempts", key, lockedUntil.Format(time.RFC3339), attempts.Failures)
+}
+
+// Lockout duration after `extraFailures` failures over threshold: base, base*2, base*4, ... up to max
+func lockoutDuration(extraFailures int) time.Duration {
+	duration := lockoutBaseDuration
+	for i := 0; i < extraFailures && duration < lockoutMaxDuration; i++ {
+		duration *= 2
+	}
+	return min(duration, lockoutMaxDuration)
+}
+
+// Forget failed logins of user after successful login
+//
+//	Counter of IP address is not reset, otherwise attacker could reset it by logging into another account
+func ResetFailedLogins(username string) {
+	if err := userStore.ResetLoginAttempts(userAttemptsKeyPrefix + username); err != nil {
+		log.Println("function `ResetFailedLogins`:", err.Error())
+	}
+}
+
+// Key of lockout of user or IP address in `/admin/lockouts/{key}`
+func validateLockoutKey(key string) error {
+	for _, prefix := range []string{userAttemptsKeyPrefix, ipAttemptsKeyPrefix} {
+		if value, ok := strings.CutPrefix(key, prefix); ok && value != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("lockout key should be `%s<username>` or `%s<address>`", userAttemptsKeyPrefix, ipAttemptsKeyPrefix)
+}
@@ -0,0 +1,37 @@
+package auth_service
+
+import (
+	"fmt"
+	"mail_handlers"
+	"net/url"
+	"os"
+)
+
+// Password reset email. If PASSWORD_RESET_URL is set, email has link `<PASSWORD_RESET_URL>?token=<token>`
+func NewPasswordResetMessage(to string, username string, token string) mail_handlers.Message {
+	instructions := fmt.Sprintf("Send this token with new password to POST /password/reset/confirm:\n\n%s", token)
+	if resetURL := os.Getenv("PASSWORD_RESET_URL"); resetURL != "" {
+		instructions = fmt.Sprintf("Follow the link to set new password:\n\n%s?token=%s", resetURL, url.QueryEscape(token))
+	}
+
+	return mail_handlers.Message{
+		To:      to,
+		Subject: "TaskTracker password reset",
+		Body: fmt.Sprintf(
+			"Hello, %s!\n\nSomeone (hopefully you) has requested password reset for your TaskTracker account.\n%s\n\nThe token is valid for %d minutes. If you didn't request it, just ignore this email.\n",
+			username, instructions, int(passwordResetTTL.Minutes()),
+		),
+	}
+}
+
+// Email with link which confirms that user owns the address
+func NewEmailVerificationMessage(to string, username string, link string) mail_handlers.Message {
+	return mail_handlers.Message{
+		To:      to,
+		Subject: "TaskTracker email verification",
+		Body: fmt.Sprintf(
+			"Hello, %s!\n\nFollow the link to confirm that this address belongs to your TaskTracker account:\n\n%s\n\nThe link is valid for %d hours. If you didn't change email in TaskTracker, just ignore this email.\n",
+			username, link, int(emailVerificationTTL.Hours()),
+		),
+	}
+}
@@ -0,0 +1,1128 @@
+package auth_service
+
+import (
+	"errors"
+	"mongo_handlers"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// In-memory implementation of `UserStore` and `SessionStore`
+//
+//	Data is lost on restart, so it is intended for tests and local runs without MongoDB.
+//	Errors and status codes are the same as of Mongo implementation
+type MemoryStore struct {
+	mutex sync.Mutex
+
+	users              map[string]mongo_handlers.User
+	externalIdentities map[[2]string]mongo_handlers.ExternalIdentity
+	twoFactors         map[string]mongo_handlers.TwoFactor
+	loginAttempts      map[string]mongo_handlers.LoginAttempts
+	lockoutEvents      []mongo_handlers.LockoutEvent
+	passwordResets     map[string]mongo_handlers.PasswordReset
+	accountDeletions   []mongo_handlers.AccountDeletion
+	dataExports        map[string]mongo_handlers.DataExport
+	dataExportArchives map[string]memoryArchive
+	auditEvents        []mongo_handlers.AuditEvent
+	workspaces         map[string]mongo_handlers.Workspace
+	// Key is [workspace id, username]
+	workspaceMembers     map[[2]string]mongo_handlers.WorkspaceMember
+	workspaceInvitations map[string]mongo_handlers.WorkspaceInvitation
+
+	sessions             map[string]mongo_handlers.Session
+	refreshTokens        map[string]mongo_handlers.RefreshToken
+	personalAccessTokens map[string]mongo_handlers.PersonalAccessToken
+	oidcLoginStates      map[string]mongo_handlers.OIDCLoginState
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:                map[string]mongo_handlers.User{},
+		externalIdentities:   map[[2]string]mongo_handlers.ExternalIdentity{},
+		twoFactors:           map[string]mongo_handlers.TwoFactor{},
+		loginAttempts:        map[string]mongo_handlers.LoginAttempts{},
+		passwordResets:       map[string]mongo_handlers.PasswordReset{},
+		dataExports:          map[string]mongo_handlers.DataExport{},
+		dataExportArchives:   map[string]memoryArchive{},
+		workspaces:           map[string]mongo_handlers.Workspace{},
+		workspaceMembers:     map[[2]string]mongo_handlers.WorkspaceMember{},
+		workspaceInvitations: map[string]mongo_handlers.WorkspaceInvitation{},
+		sessions:             map[string]mongo_handlers.Session{},
+		refreshTokens:        map[string]mongo_handlers.RefreshToken{},
+		personalAccessTokens: map[string]mongo_handlers.PersonalAccessToken{},
+		oidcLoginStates:      map[string]mongo_handlers.OIDCLoginState{},
+	}
+}
+
+// Users
+
+func (s *MemoryStore) CreateUser(user mongo_handlers.User) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.users[user.Username]; ok {
+		return http.StatusBadRequest, errors.New("User with this Username does already exist")
+	}
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	s.users[user.Username] = user
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetUser(username string, user *mongo_handlers.User) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.users[username]
+	if !ok {
+		return http.StatusNotFound, errors.New("user not found in registered users database")
+	}
+	*user = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) UpdateUser(username string, update mongo_handlers.UserUpdate) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user, ok := s.users[username]
+	if !ok {
+		return http.StatusNotFound, errors.New("user not found in registered users database")
+	}
+	set := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	set(&user.Password, update.Password)
+	set(&user.FirstName, update.FirstName)
+	set(&user.LastName, update.LastName)
+	set(&user.Birthday, update.Birthday)
+	set(&user.Email, update.Email)
+	set(&user.PendingEmail, update.PendingEmail)
+	set(&user.PhoneNumber, update.PhoneNumber)
+	set(&user.Role, update.Role)
+	set(&user.DisabledBy, update.DisabledBy)
+	set(&user.DisabledReason, update.DisabledReason)
+	if update.EmailVerified != nil {
+		user.EmailVerified = *update.EmailVerified
+	}
+	if update.Disabled != nil {
+		user.Disabled = *update.Disabled
+		user.DisabledAt = nil
+		if user.Disabled {
+			now := time.Now()
+			user.DisabledAt = &now
+		}
+	}
+	user.UpdatedAt = time.Now()
+	s.users[username] = user
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) SearchUsers(query string, offset int64, limit int64, page *mongo_handlers.UserPage) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	query = strings.ToLower(query)
+	found := []mongo_handlers.User{}
+	for _, user := range s.users {
+		for _, field := range []string{user.Username, user.FirstName, user.LastName, user.Email} {
+			if strings.Contains(strings.ToLower(field), query) {
+				found = append(found, user)
+				break
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Username < found[j].Username })
+
+	page.Total = int64(len(found))
+	page.Users = found[min(offset, page.Total):min(offset+limit, page.Total)]
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) CheckIfUserExists(username string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.users[username]
+	return ok
+}
+
+func (s *MemoryStore) DeleteUser(username string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.users, username)
+	delete(s.twoFactors, username)
+	for key, identity := range s.externalIdentities {
+		if identity.Username == username {
+			delete(s.externalIdentities, key)
+		}
+	}
+	for tokenHash, reset := range s.passwordResets {
+		if reset.Username == username {
+			delete(s.passwordResets, tokenHash)
+		}
+	}
+	for exportID, export := range s.dataExports {
+		if export.Username == username {
+			delete(s.dataExports, exportID)
+		}
+	}
+	for key, member := range s.workspaceMembers {
+		if member.Username == username {
+			delete(s.workspaceMembers, key)
+		}
+	}
+	for invitationID, invitation := range s.workspaceInvitations {
+		if invitation.Username == username {
+			delete(s.workspaceInvitations, invitationID)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// External identities
+
+func (s *MemoryStore) GetExternalIdentity(issuer string, subject string, identity *mongo_handlers.ExternalIdentity) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.externalIdentities[[2]string{issuer, subject}]
+	if !ok {
+		return http.StatusNotFound, errors.New("external identity is not linked to any user")
+	}
+	*identity = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) StoreExternalIdentity(identity mongo_handlers.ExternalIdentity) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := [2]string{identity.Issuer, identity.Subject}
+	if _, ok := s.externalIdentities[key]; ok {
+		return http.StatusConflict, errors.New("external identity is already linked to a user")
+	}
+	s.externalIdentities[key] = identity
+	return http.StatusOK, nil
+}
+
+// Two-factor authentication
+
+func (s *MemoryStore) GetTwoFactor(username string, twoFactor *mongo_handlers.TwoFactor) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.twoFactors[username]
+	if !ok {
+		return http.StatusNotFound, errors.New("two-factor authentication is not set up")
+	}
+	stored.RecoveryCodeHashes = slices.Clone(stored.RecoveryCodeHashes)
+	*twoFactor = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) StoreTwoFactor(twoFactor mongo_handlers.TwoFactor) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	twoFactor.RecoveryCodeHashes = slices.Clone(twoFactor.RecoveryCodeHashes)
+	s.twoFactors[twoFactor.Username] = twoFactor
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) DeleteTwoFactor(username string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.twoFactors, username)
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) UseTOTPStep(username string, step int64) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	twoFactor, ok := s.twoFactors[username]
+	if !ok || twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	twoFactor.LastUsedStep = step
+	twoFactor.FailedAttempts = 0
+	s.twoFactors[username] = twoFactor
+	return true, nil
+}
+
+func (s *MemoryStore) UseRecoveryCode(username string, codeHash string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	twoFactor, ok := s.twoFactors[username]
+	if !ok {
+		return false, nil
+	}
+	index := slices.Index(twoFactor.RecoveryCodeHashes, codeHash)
+	if index < 0 {
+		return false, nil
+	}
+	twoFactor.RecoveryCodeHashes = slices.Delete(slices.Clone(twoFactor.RecoveryCodeHashes), index, index+1)
+	twoFactor.FailedAttempts = 0
+	s.twoFactors[username] = twoFactor
+	return true, nil
+}
+
+func (s *MemoryStore) RecordTwoFactorFailure(username string, maxAttempts int, lockDuration time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	twoFactor, ok := s.twoFactors[username]
+	if !ok {
+		return errors.New("two-factor authentication is not set up")
+	}
+	twoFactor.FailedAttempts++
+	if twoFactor.FailedAttempts >= maxAttempts {
+		twoFactor.FailedAttempts = 0
+		twoFactor.LockedUntil = time.Now().Add(lockDuration)
+	}
+	s.twoFactors[username] = twoFactor
+	return nil
+}
+
+// Failed logins and lockouts
+
+func (s *MemoryStore) GetLoginAttempts(key string, attempts *mongo_handlers.LoginAttempts) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.loginAttempts[key]
+	if !ok || time.Now().After(stored.ExpiresAt) {
+		return http.StatusNotFound, errors.New("no failed login attempts")
+	}
+	*attempts = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) RecordLoginFailure(key string, now time.Time, ttl time.Duration, attempts *mongo_handlers.LoginAttempts) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.loginAttempts[key]
+	if !ok || now.After(stored.ExpiresAt) {
+		stored = mongo_handlers.LoginAttempts{Key: key}
+	}
+	stored.Failures++
+	stored.LastFailureAt = now
+	stored.ExpiresAt = now.Add(ttl)
+	s.loginAttempts[key] = stored
+	*attempts = stored
+	return nil
+}
+
+func (s *MemoryStore) LockLogin(key string, lockedUntil time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if stored, ok := s.loginAttempts[key]; ok {
+		stored.LockedUntil = lockedUntil
+		s.loginAttempts[key] = stored
+	}
+	return nil
+}
+
+func (s *MemoryStore) ResetLoginAttempts(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.loginAttempts, key)
+	return nil
+}
+
+func (s *MemoryStore) StoreLockoutEvent(event mongo_handlers.LockoutEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lockoutEvents = append(s.lockoutEvents, event)
+	return nil
+}
+
+func (s *MemoryStore) GetLockoutEvents(activeOnly bool, offset int64, limit int64, events *[]mongo_handlers.LockoutEvent) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	found := []mongo_handlers.LockoutEvent{}
+	for _, event := range s.lockoutEvents {
+		if activeOnly && (!event.LockedUntil.After(now) || event.ClearedAt != nil) {
+			continue
+		}
+		found = append(found, event)
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].LockedAt.After(found[j].LockedAt) })
+	if offset >= int64(len(found)) {
+		found = found[:0]
+	} else {
+		found = found[offset:]
+	}
+	if limit > 0 && int64(len(found)) > limit {
+		found = found[:limit]
+	}
+	*events = found
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) ClearLockout(key string, clearedBy string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.loginAttempts, key)
+	now := time.Now()
+	for i, event := range s.lockoutEvents {
+		if event.Key == key && event.ClearedAt == nil {
+			s.lockoutEvents[i].ClearedBy = clearedBy
+			s.lockoutEvents[i].ClearedAt = &now
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Password resets
+
+func (s *MemoryStore) StorePasswordReset(reset mongo_handlers.PasswordReset) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for tokenHash, stored := range s.passwordResets {
+		if stored.Username == reset.Username {
+			delete(s.passwordResets, tokenHash)
+		}
+	}
+	s.passwordResets[reset.TokenHash] = reset
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) PopPasswordReset(tokenHash string, reset *mongo_handlers.PasswordReset) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.passwordResets[tokenHash]
+	if !ok || !stored.ExpiresAt.After(time.Now()) {
+		return http.StatusBadRequest, errors.New("password reset token is invalid or has expired")
+	}
+	delete(s.passwordResets, tokenHash)
+	*reset = stored
+	return http.StatusOK, nil
+}
+
+// Account deletions
+
+func (s *MemoryStore) StartAccountDeletion(username string, requestedAt time.Time, deletion *mongo_handlers.AccountDeletion) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, stored := range s.accountDeletions {
+		if stored.Username == username && stored.CompletedAt == nil {
+			*deletion = stored
+			return http.StatusOK, nil
+		}
+	}
+	*deletion = mongo_handlers.AccountDeletion{Username: username, RequestedAt: requestedAt}
+	s.accountDeletions = append(s.accountDeletions, *deletion)
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetPendingAccountDeletion(username string, deletion *mongo_handlers.AccountDeletion) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, stored := range s.accountDeletions {
+		if stored.Username == username && stored.CompletedAt == nil {
+			*deletion = stored
+			return http.StatusOK, nil
+		}
+	}
+	return http.StatusNotFound, errors.New("account deletion is not pending")
+}
+
+func (s *MemoryStore) GetPendingAccountDeletions(deletions *[]mongo_handlers.AccountDeletion) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	found := []mongo_handlers.AccountDeletion{}
+	for _, stored := range s.accountDeletions {
+		if stored.CompletedAt == nil {
+			found = append(found, stored)
+		}
+	}
+	*deletions = found
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) ClaimAccountDeletion(username string, lockID string, lockedUntil time.Time, deletion *mongo_handlers.AccountDeletion) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, stored := range s.accountDeletions {
+		if stored.Username != username || stored.CompletedAt != nil {
+			continue
+		}
+		if stored.LockedUntil != nil && stored.LockedUntil.After(time.Now()) {
+			break
+		}
+		stored.LockID = lockID
+		stored.LockedUntil = &lockedUntil
+		s.accountDeletions[i] = stored
+		*deletion = stored
+		return http.StatusOK, nil
+	}
+	return http.StatusNotFound, errors.New("account deletion is not pending or is claimed by another replica")
+}
+
+func (s *MemoryStore) UpdateAccountDeletion(deletion mongo_handlers.AccountDeletion) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, stored := range s.accountDeletions {
+		if stored.Username == deletion.Username && stored.CompletedAt == nil {
+			if stored.LockID != deletion.LockID {
+				return http.StatusConflict, errors.New("account deletion is claimed by another replica")
+			}
+			s.accountDeletions[i] = deletion
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Data exports
+
+func (s *MemoryStore) CreateDataExport(export mongo_handlers.DataExport) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.dataExports[export.ExportID] = export
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetDataExport(username string, exportID string, export *mongo_handlers.DataExport) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.dataExports[exportID]
+	if !ok || stored.Username != username || time.Now().After(stored.ExpiresAt) {
+		return http.StatusNotFound, errors.New("data export not found")
+	}
+	*export = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetUserDataExports(username string, exports *[]mongo_handlers.DataExport) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	found := []mongo_handlers.DataExport{}
+	for _, stored := range s.dataExports {
+		if stored.Username == username && now.Before(stored.ExpiresAt) {
+			found = append(found, stored)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].CreatedAt.After(found[j].CreatedAt) })
+	*exports = found
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) UpdateDataExport(export mongo_handlers.DataExport) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.dataExports[export.ExportID]; ok {
+		s.dataExports[export.ExportID] = export
+	}
+	return http.StatusOK, nil
+}
+
+// Archive of export with time of its upload (like `uploadDate` of GridFS)
+type memoryArchive struct {
+	content    []byte
+	uploadedAt time.Time
+}
+
+func (s *MemoryStore) StoreDataExportArchive(exportID string, archive []byte) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.dataExportArchives[exportID] = memoryArchive{content: archive, uploadedAt: time.Now()}
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetDataExportArchive(exportID string, archive *[]byte) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.dataExportArchives[exportID]
+	if !ok {
+		return http.StatusNotFound, errors.New("export archive not found")
+	}
+	*archive = stored.content
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) DeleteDataExportArchive(exportID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.dataExportArchives, exportID)
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) DeleteExpiredDataExportArchives(before time.Time) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for exportID, stored := range s.dataExportArchives {
+		if stored.uploadedAt.Before(before) {
+			delete(s.dataExportArchives, exportID)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Audit log
+
+func (s *MemoryStore) StoreAuditEvent(event mongo_handlers.AuditEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.auditEvents = append(s.auditEvents, event)
+	return nil
+}
+
+func (s *MemoryStore) GetAuditEvents(filter mongo_handlers.AuditEventFilter, offset int64, limit int64, events *[]mongo_handlers.AuditEvent) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	matches := func(value string, expected string) bool {
+		return expected == "" || value == expected
+	}
+	found := []mongo_handlers.AuditEvent{}
+	for _, event := range s.auditEvents {
+		if !matches(event.Actor, filter.Actor) || !matches(event.Action, filter.Action) ||
+			!matches(event.Target, filter.Target) || !matches(event.IP, filter.IP) ||
+			!matches(event.Outcome, filter.Outcome) {
+			continue
+		}
+		if filter.From != nil && event.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !event.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		found = append(found, event)
+	}
+	// The latest first, events are appended in order of time
+	slices.Reverse(found)
+
+	total := int64(len(found))
+	*events = found[min(offset, total):min(offset+limit, total)]
+	return http.StatusOK, nil
+}
+
+// Workspaces
+
+func (s *MemoryStore) CreateWorkspace(workspace mongo_handlers.Workspace) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.workspaces[workspace.WorkspaceID] = workspace
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetWorkspace(workspaceID string, workspace *mongo_handlers.Workspace) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.workspaces[workspaceID]
+	if !ok {
+		return http.StatusNotFound, errors.New("workspace not found")
+	}
+	*workspace = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetWorkspaces(workspaceIDs []string, workspaces *[]mongo_handlers.Workspace) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	*workspaces = []mongo_handlers.Workspace{}
+	for _, workspaceID := range workspaceIDs {
+		if workspace, ok := s.workspaces[workspaceID]; ok {
+			*workspaces = append(*workspaces, workspace)
+		}
+	}
+	sort.Slice(*workspaces, func(i, j int) bool {
+		a, b := (*workspaces)[i], (*workspaces)[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.WorkspaceID < b.WorkspaceID
+	})
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) AddWorkspaceMember(member mongo_handlers.WorkspaceMember) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := [2]string{member.WorkspaceID, member.Username}
+	if _, ok := s.workspaceMembers[key]; ok {
+		return http.StatusConflict, errors.New("user is already a member of workspace")
+	}
+	s.workspaceMembers[key] = member
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetWorkspaceMember(workspaceID string, username string, member *mongo_handlers.WorkspaceMember) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.workspaceMembers[[2]string{workspaceID, username}]
+	if !ok {
+		return http.StatusNotFound, errors.New("workspace member not found")
+	}
+	*member = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetWorkspaceMembers(workspaceID string, members *[]mongo_handlers.WorkspaceMember) (code int, err error) {
+	return s.findWorkspaceMembers(func(member mongo_handlers.WorkspaceMember) bool {
+		return member.WorkspaceID == workspaceID
+	}, members)
+}
+
+func (s *MemoryStore) GetUserWorkspaceMembers(username string, members *[]mongo_handlers.WorkspaceMember) (code int, err error) {
+	return s.findWorkspaceMembers(func(member mongo_handlers.WorkspaceMember) bool {
+		return member.Username == username
+	}, members)
+}
+
+func (s *MemoryStore) findWorkspaceMembers(matches func(mongo_handlers.WorkspaceMember) bool, members *[]mongo_handlers.WorkspaceMember) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	*members = []mongo_handlers.WorkspaceMember{}
+	for _, member := range s.workspaceMembers {
+		if matches(member) {
+			*members = append(*members, member)
+		}
+	}
+	sort.Slice(*members, func(i, j int) bool {
+		a, b := (*members)[i], (*members)[j]
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		return a.WorkspaceID < b.WorkspaceID
+	})
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) SetWorkspaceMemberRole(workspaceID string, username string, role string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := [2]string{workspaceID, username}
+	member, ok := s.workspaceMembers[key]
+	if !ok {
+		return http.StatusNotFound, errors.New("workspace member not found")
+	}
+	member.Role = role
+	s.workspaceMembers[key] = member
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) DeleteWorkspaceMember(workspaceID string, username string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := [2]string{workspaceID, username}
+	if _, ok := s.workspaceMembers[key]; !ok {
+		return http.StatusNotFound, errors.New("workspace member not found")
+	}
+	delete(s.workspaceMembers, key)
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) SetWorkspaceOwner(workspaceID string, username string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := [2]string{workspaceID, username}
+	member, ok := s.workspaceMembers[key]
+	if !ok {
+		return http.StatusNotFound, errors.New("workspace member not found")
+	}
+	workspace, ok := s.workspaces[workspaceID]
+	if !ok {
+		return http.StatusNotFound, errors.New("workspace not found")
+	}
+	member.Role = mongo_handlers.WorkspaceRoleOwner
+	s.workspaceMembers[key] = member
+	workspace.Owner = username
+	s.workspaces[workspaceID] = workspace
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) DeleteWorkspace(workspaceID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.workspaces, workspaceID)
+	for key, member := range s.workspaceMembers {
+		if member.WorkspaceID == workspaceID {
+			delete(s.workspaceMembers, key)
+		}
+	}
+	for invitationID, invitation := range s.workspaceInvitations {
+		if invitation.WorkspaceID == workspaceID {
+			delete(s.workspaceInvitations, invitationID)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) CreateWorkspaceInvitation(invitation mongo_handlers.WorkspaceInvitation) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.workspaceInvitations[invitation.InvitationID] = invitation
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetWorkspaceInvitation(invitationID string, invitation *mongo_handlers.WorkspaceInvitation) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.workspaceInvitations[invitationID]
+	if !ok {
+		return http.StatusNotFound, errors.New("invitation not found")
+	}
+	*invitation = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetWorkspaceInvitationByTokenHash(tokenHash string, invitation *mongo_handlers.WorkspaceInvitation) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, stored := range s.workspaceInvitations {
+		if tokenHash != "" && stored.TokenHash == tokenHash {
+			*invitation = stored
+			return http.StatusOK, nil
+		}
+	}
+	return http.StatusNotFound, errors.New("invitation not found")
+}
+
+func (s *MemoryStore) GetWorkspaceInvitations(workspaceID string, invitations *[]mongo_handlers.WorkspaceInvitation) (code int, err error) {
+	return s.findWorkspaceInvitations(func(invitation mongo_handlers.WorkspaceInvitation) bool {
+		return invitation.WorkspaceID == workspaceID
+	}, invitations)
+}
+
+func (s *MemoryStore) GetUserWorkspaceInvitations(username string, invitations *[]mongo_handlers.WorkspaceInvitation) (code int, err error) {
+	return s.findWorkspaceInvitations(func(invitation mongo_handlers.WorkspaceInvitation) bool {
+		return invitation.Username == username
+	}, invitations)
+}
+
+func (s *MemoryStore) findWorkspaceInvitations(matches func(mongo_handlers.WorkspaceInvitation) bool, invitations *[]mongo_handlers.WorkspaceInvitation) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	*invitations = []mongo_handlers.WorkspaceInvitation{}
+	for _, invitation := range s.workspaceInvitations {
+		if matches(invitation) && invitation.ExpiresAt.After(now) {
+			*invitations = append(*invitations, invitation)
+		}
+	}
+	sort.Slice(*invitations, func(i, j int) bool {
+		return (*invitations)[i].CreatedAt.After((*invitations)[j].CreatedAt)
+	})
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) DeleteWorkspaceInvitation(invitationID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.workspaceInvitations[invitationID]; !ok {
+		return http.StatusNotFound, errors.New("invitation not found")
+	}
+	delete(s.workspaceInvitations, invitationID)
+	return http.StatusOK, nil
+}
+
+// Sessions
+
+func (s *MemoryStore) CreateSession(session mongo_handlers.Session) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sessions[session.SessionID] = session
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetSession(sessionID string, session *mongo_handlers.Session) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.sessions[sessionID]
+	if !ok {
+		return http.StatusUnauthorized, errors.New("session not found")
+	}
+	*session = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetUserSessions(username string, sessions *[]mongo_handlers.Session) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	found := []mongo_handlers.Session{}
+	for _, session := range s.sessions {
+		if session.Username == username && !session.Revoked {
+			found = append(found, session)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].LastSeenAt.After(found[j].LastSeenAt) })
+	*sessions = found
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) TouchSession(sessionID string, lastSeenAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if session, ok := s.sessions[sessionID]; ok {
+		session.LastSeenAt = lastSeenAt
+		s.sessions[sessionID] = session
+	}
+	return nil
+}
+
+func (s *MemoryStore) ExtendSession(sessionID string, expiresAt time.Time) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if session, ok := s.sessions[sessionID]; ok {
+		session.ExpiresAt = expiresAt
+		s.sessions[sessionID] = session
+	}
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) RevokeSession(username string, sessionID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok || session.Username != username || session.Revoked {
+		return http.StatusNotFound, errors.New("active session with this id is not found")
+	}
+	session.Revoked = true
+	s.sessions[sessionID] = session
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) RevokeUserSessions(username string, exceptSessionID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for sessionID, session := range s.sessions {
+		if session.Username == username && sessionID != exceptSessionID {
+			session.Revoked = true
+			s.sessions[sessionID] = session
+		}
+	}
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) DeleteUserSessions(username string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for sessionID, session := range s.sessions {
+		if session.Username == username {
+			delete(s.sessions, sessionID)
+		}
+	}
+	for tokenHash, token := range s.refreshTokens {
+		if token.Username == username {
+			delete(s.refreshTokens, tokenHash)
+		}
+	}
+	for tokenID, token := range s.personalAccessTokens {
+		if token.Username == username {
+			delete(s.personalAccessTokens, tokenID)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Refresh tokens
+
+func (s *MemoryStore) StoreRefreshToken(token mongo_handlers.RefreshToken) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.refreshTokens[token.TokenHash] = token
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetRefreshToken(tokenHash string, token *mongo_handlers.RefreshToken) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return http.StatusUnauthorized, errors.New("refresh token not found")
+	}
+	*token = stored
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) MarkRefreshTokenUsed(tokenHash string) (marked bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok || token.Used || token.Revoked {
+		return false, nil
+	}
+	token.Used = true
+	s.refreshTokens[tokenHash] = token
+	return true, nil
+}
+
+func (s *MemoryStore) RevokeRefreshTokenFamily(familyID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for tokenHash, token := range s.refreshTokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			s.refreshTokens[tokenHash] = token
+		}
+	}
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) RevokeUserRefreshTokens(username string, exceptFamilyID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for tokenHash, token := range s.refreshTokens {
+		if token.Username == username && token.FamilyID != exceptFamilyID {
+			token.Revoked = true
+			s.refreshTokens[tokenHash] = token
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Personal access tokens
+
+func (s *MemoryStore) StorePersonalAccessToken(token mongo_handlers.PersonalAccessToken) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token.Scopes = slices.Clone(token.Scopes)
+	s.personalAccessTokens[token.TokenID] = token
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) GetPersonalAccessTokenByHash(tokenHash string, token *mongo_handlers.PersonalAccessToken) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, stored := range s.personalAccessTokens {
+		if stored.TokenHash == tokenHash {
+			stored.Scopes = slices.Clone(stored.Scopes)
+			*token = stored
+			return http.StatusOK, nil
+		}
+	}
+	return http.StatusUnauthorized, errors.New("personal access token not found")
+}
+
+func (s *MemoryStore) GetUserPersonalAccessTokens(username string, tokens *[]mongo_handlers.PersonalAccessToken) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	found := []mongo_handlers.PersonalAccessToken{}
+	for _, token := range s.personalAccessTokens {
+		if token.Username == username && !token.Revoked {
+			token.Scopes = slices.Clone(token.Scopes)
+			found = append(found, token)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].CreatedAt.After(found[j].CreatedAt) })
+	*tokens = found
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) TouchPersonalAccessToken(tokenID string, lastUsedAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if token, ok := s.personalAccessTokens[tokenID]; ok {
+		token.LastUsedAt = lastUsedAt
+		s.personalAccessTokens[tokenID] = token
+	}
+	return nil
+}
+
+func (s *MemoryStore) RevokePersonalAccessToken(username string, tokenID string) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token, ok := s.personalAccessTokens[tokenID]
+	if !ok || token.Username != username || token.Revoked {
+		return http.StatusNotFound, errors.New("personal access token with this id is not found")
+	}
+	token.Revoked = true
+	s.personalAccessTokens[tokenID] = token
+	return http.StatusOK, nil
+}
+
+// OIDC logins
+
+func (s *MemoryStore) StoreOIDCLoginState(state mongo_handlers.OIDCLoginState) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.oidcLoginStates[state.State] = state
+	return http.StatusOK, nil
+}
+
+func (s *MemoryStore) PopOIDCLoginState(state string, loginState *mongo_handlers.OIDCLoginState) (code int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.oidcLoginStates[state]
+	if !ok {
+		return http.StatusBadRequest, errors.New("unknown or already used OIDC login state")
+	}
+	delete(s.oidcLoginStates, state)
+	*loginState = stored
+	if time.Now().After(loginState.ExpiresAt) {
+		return http.StatusBadRequest, errors.New("OIDC login has expired, try again")
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,172 @@
+package auth_service
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mongo_handlers"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters for newly hashed passwords.
+// If they are changed, old hashes will be upgraded on the next successful login
+const (
+	argon2Time    uint32 = 3
+	argon2Memory  uint32 = 64 * 1024
+	argon2Threads uint8  = 2
+	argon2KeyLen  uint32 = 32
+	argon2SaltLen        = 16
+
+	// Bounds of parameters accepted from stored hashes. argon2 panics if time or threads are zero,
+	// huge memory or time would make a single login exhaust the service
+	argon2MaxTime    uint32 = 16
+	argon2MaxMemory  uint32 = 1024 * 1024
+	argon2MinSaltLen        = 8
+	argon2MinKeyLen         = 16
+	argon2MaxKeyLen         = 128
+
+	// Salt which was used for all passwords before per-user salts were introduced
+	legacyPasswordSalt = "SALT"
+
+	// Requirement for passwords set by change or reset
+	minPasswordLength = 8
+)
+
+var errMalformedPasswordHash = errors.New("stored password hash is malformed")
+
+// Hash given password by argon2id with random salt
+//
+//	Result is self-describing string in PHC format:
+//	$argon2id$v=19$m=65536,t=3,p=2$<base64 salt>$<base64 hash>
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt for password: %w", err)
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		argon2Memory,
+		argon2Time,
+		argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+// Check if given password matches stored hash
+//
+//	Stored hash may be argon2id hash (see `HashPassword`) or legacy md5 + "SALT" hash.
+//	`needsRehash` is true if password matches but stored hash should be replaced
+//	by the new one (legacy format or outdated argon2id parameters)
+func VerifyPassword(password string, storedHash string) (matches bool, needsRehash bool, err error) {
+	if !strings.HasPrefix(storedHash, "$argon2id$") {
+		return verifyLegacyPassword(password, storedHash), true, nil
+	}
+
+	// Expected parts: "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(storedHash, "$")
+	if len(parts) != 6 {
+		return false, false, errMalformedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, false, errMalformedPasswordHash
+	}
+	if version != argon2.Version {
+		return false, false, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, false, errMalformedPasswordHash
+	}
+	if time < 1 || time > argon2MaxTime || threads < 1 || memory < 8*uint32(threads) || memory > argon2MaxMemory {
+		return false, false, errMalformedPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, errMalformedPasswordHash
+	}
+	expectedHash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, errMalformedPasswordHash
+	}
+	// Empty hash would match any password
+	if len(salt) < argon2MinSaltLen || len(expectedHash) < argon2MinKeyLen || len(expectedHash) > argon2MaxKeyLen {
+		return false, false, errMalformedPasswordHash
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(expectedHash)))
+	if subtle.ConstantTimeCompare(hash, expectedHash) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash = memory != argon2Memory || time != argon2Time || threads != argon2Threads ||
+		len(salt) != argon2SaltLen || uint32(len(expectedHash)) != argon2KeyLen
+	return true, needsRehash, nil
+}
+
+// Check password of already known user (e.g. to confirm dangerous action)
+//
+//	If password is incorrect or user has no password returns 401 (Status Unauthorized)
+func CheckUserPassword(username string, password string) (code int, err error) {
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(username, &user)
+	if err != nil {
+		return code, err
+	}
+
+	if user.Password == "" {
+		return http.StatusUnauthorized, errors.New("user has no password")
+	}
+	matches, _, err := VerifyPassword(password, user.Password)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !matches {
+		return http.StatusUnauthorized, errors.New("Incorrect password")
+	}
+	return http.StatusOK, nil
+}
+
+// Check if password is good enough to be set as new one
+func ValidateNewPassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return fmt.Errorf("password should have at least %d characters", minPasswordLength)
+	}
+	return nil
+}
+
+// Hash and save new user's password
+func SetUserPassword(username string, password string) (code int, err error) {
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	code, err = userStore.UpdateUser(username, mongo_handlers.UserUpdate{Password: &hashedPassword})
+	if err != nil {
+		err = fmt.Errorf("error in function `UpdateUser` occurred: %w", err)
+		return code, err
+	}
+	return http.StatusOK, nil
+}
+
+// Check password against hash made by md5 + "SALT" (format used before argon2id)
+func verifyLegacyPassword(password string, storedHash string) bool {
+	hash := fmt.Sprintf("%x", md5.Sum([]byte(password+legacyPasswordSalt)))
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(storedHash)) == 1
+}
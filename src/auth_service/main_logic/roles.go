@@ -0,0 +1,112 @@
+package auth_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mongo_handlers"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	task_servicepb "task_service/proto"
+)
+
+// Roles are passed to task service as `UserRole` enum, names of roles are its names without prefix in lower case
+const userRolePrefix = "USER_ROLE_"
+
+// Check if `role` has all rights of `required` role (admin > moderator > user)
+func RoleIncludes(role string, required string) bool {
+	index := slices.Index(mongo_handlers.Roles, role)
+	return index >= 0 && index >= slices.Index(mongo_handlers.Roles, required)
+}
+
+// Role in requests to task service. Unknown role becomes unspecified one, which has rights of `user`
+func userRoleProto(role string) task_servicepb.UserRole {
+	return task_servicepb.UserRole(task_servicepb.UserRole_value[userRolePrefix+strings.ToUpper(role)])
+}
+
+// Middleware which passes request to `handler` only if it's authenticated by session of user
+// with `role` or higher role. Personal access tokens are not accepted
+//
+//	If user is not authenticated returns 400 (Status Bad Request)
+//	If user's role is lower than `role` returns 403 (Status Forbidden)
+func RequireRole(role string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var info AuthInfo
+		code, err := GetAuthInfo(r, &info)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		if !info.HasRole(role) {
+			http.Error(w, fmt.Sprintf("only users with role `%s` can perform this request", role), http.StatusForbidden)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), authInfoContextKey{}, info)))
+	}
+}
+
+type authInfoContextKey struct{}
+
+// Requestor of request passed by `RequireRole`, so handlers behind it don't authenticate request again
+func requestAuthInfo(r *http.Request) AuthInfo {
+	info, _ := r.Context().Value(authInfoContextKey{}).(AuthInfo)
+	return info
+}
+
+// Create admin from BOOTSTRAP_ADMIN and BOOTSTRAP_ADMIN_PASSWORD environment variables, so the first admin
+// can assign roles to other users
+//
+//	Existing account is never promoted: it could be registered by anyone (by `/register` or OIDC login)
+//	before the service was configured, and demoted bootstrap admin should stay demoted after restart.
+//	Without BOOTSTRAP_ADMIN nothing is done
+func BootstrapAdmin() error {
+	// Admins were listed in ADMIN_USERNAMES before roles were stored in profiles
+	if os.Getenv("ADMIN_USERNAMES") != "" {
+		log.Println("Warning: ADMIN_USERNAMES is not used anymore and is ignored. Use BOOTSTRAP_ADMIN for the first admin, " +
+			"he assigns roles to other users")
+	}
+
+	username := os.Getenv("BOOTSTRAP_ADMIN")
+	if username == "" {
+		return nil
+	}
+	password := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD")
+	if password == "" {
+		log.Printf("Warning: bootstrap admin `%s` is not created because BOOTSTRAP_ADMIN_PASSWORD is not set", username)
+		return nil
+	}
+
+	var user mongo_handlers.User
+	code, err := userStore.GetUser(username, &user)
+	if err == nil {
+		if user.GetRole() != mongo_handlers.RoleAdmin {
+			log.Printf("Warning: bootstrap admin `%s` is not created because user with this username already exists, "+
+				"existing users are not promoted", username)
+		}
+		return nil
+	}
+	if code != http.StatusNotFound {
+		return err
+	}
+
+	if err := ValidateNewPassword(password); err != nil {
+		return fmt.Errorf("BOOTSTRAP_ADMIN_PASSWORD: %w", err)
+	}
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, err = userStore.CreateUser(mongo_handlers.User{
+		Username: username,
+		Password: hashedPassword,
+		Role:     mongo_handlers.RoleAdmin,
+	})
+	if err != nil {
+		return fmt.Errorf("error in function `CreateUser` occurred: %w", err)
+	}
+	log.Printf("Bootstrap admin `%s` has been created", username)
+	return nil
+}
@@ -2,6 +2,7 @@ package auth_service
 
 import (
 	"fmt"
+	"mongo_handlers"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -45,6 +46,13 @@ var routes = Routes{
 		Index,
 	},
 
+	Route{
+		"GetJWKS",
+		"GET",
+		"/.well-known/jwks.json",
+		GetJWKS,
+	},
+
 	Route{
 		"AuthenticatePost",
 		"POST",
@@ -59,6 +67,139 @@ var routes = Routes{
 		Register,
 	},
 
+	Route{
+		"AuthenticateTwoFactorPost",
+		"POST",
+		"/authenticate/2fa",
+		AuthenticateTwoFactor,
+	},
+
+	Route{
+		"EnrollTwoFactor",
+		"POST",
+		"/2fa/enroll",
+		EnrollTwoFactor,
+	},
+
+	Route{
+		"ConfirmTwoFactor",
+		"POST",
+		"/2fa/confirm",
+		ConfirmTwoFactor,
+	},
+
+	Route{
+		"RegenerateRecoveryCodes",
+		"POST",
+		"/2fa/recoveryCodes",
+		RegenerateRecoveryCodes,
+	},
+
+	Route{
+		"DisableTwoFactor",
+		"POST",
+		"/2fa/disable",
+		DisableTwoFactor,
+	},
+
+	Route{
+		"ChangePassword",
+		"PUT",
+		"/password",
+		ChangePassword,
+	},
+
+	Route{
+		"RequestPasswordReset",
+		"POST",
+		"/password/reset",
+		RequestPasswordReset,
+	},
+
+	Route{
+		"ConfirmPasswordReset",
+		"POST",
+		"/password/reset/confirm",
+		ConfirmPasswordReset,
+	},
+
+	Route{
+		"OIDCLogin",
+		"GET",
+		"/oidc/login",
+		OIDCLogin,
+	},
+
+	Route{
+		"OIDCCallback",
+		"GET",
+		"/oidc/callback",
+		OIDCCallback,
+	},
+
+	Route{
+		"RefreshPost",
+		"POST",
+		"/refresh",
+		Refresh,
+	},
+
+	Route{
+		"LogoutPost",
+		"POST",
+		"/logout",
+		Logout,
+	},
+
+	Route{
+		"LogoutAllPost",
+		"POST",
+		"/logout/all",
+		LogoutAll,
+	},
+
+	Route{
+		"CreatePersonalAccessToken",
+		"POST",
+		"/tokens",
+		CreatePersonalAccessToken,
+	},
+
+	Route{
+		"GetPersonalAccessTokens",
+		"GET",
+		"/tokens",
+		GetPersonalAccessTokens,
+	},
+
+	Route{
+		"DeletePersonalAccessToken",
+		"DELETE",
+		"/tokens/{token_id}",
+		DeletePersonalAccessToken,
+	},
+
+	Route{
+		"GetSessions",
+		"GET",
+		"/sessions",
+		GetSessions,
+	},
+
+	Route{
+		"DeleteSession",
+		"DELETE",
+		"/sessions/{session_id}",
+		DeleteSession,
+	},
+
+	Route{
+		"GetMyProfile",
+		"GET",
+		"/profile",
+		GetMyProfile,
+	},
+
 	Route{
 		"UpdateMyProfile",
 		"PUT",
@@ -66,6 +207,48 @@ var routes = Routes{
 		UpdateMyProfile,
 	},
 
+	Route{
+		"DeleteMyProfile",
+		"DELETE",
+		"/profile",
+		DeleteMyProfile,
+	},
+
+	Route{
+		"ExportMyData",
+		"POST",
+		"/profile/export",
+		ExportMyData,
+	},
+
+	Route{
+		"GetMyDataExport",
+		"GET",
+		"/profile/export/{export_id}",
+		GetMyDataExport,
+	},
+
+	Route{
+		"DownloadMyDataExport",
+		"GET",
+		"/profile/export/{export_id}/download",
+		DownloadMyDataExport,
+	},
+
+	Route{
+		"VerifyEmail",
+		"GET",
+		"/profile/email/verify",
+		VerifyEmail,
+	},
+
+	Route{
+		"GetUserProfile",
+		"GET",
+		"/users/{username}",
+		GetUserProfile,
+	},
+
 	Route{
 		"CreateTask",
 		"POST",
@@ -122,6 +305,34 @@ var routes = Routes{
 		GetTaskStats,
 	},
 
+	Route{
+		"GetTaskStatusHistory",
+		"GET",
+		"/tasks/{task_id}/history",
+		GetTaskStatusHistory,
+	},
+
+	Route{
+		"AssignTask",
+		"POST",
+		"/tasks/{task_id}/assignees",
+		AssignTask,
+	},
+
+	Route{
+		"UnassignTask",
+		"DELETE",
+		"/tasks/{task_id}/assignees/{username}",
+		UnassignTask,
+	},
+
+	Route{
+		"GetMyTasks",
+		"GET",
+		"/me/tasks",
+		GetMyTasks,
+	},
+
 	Route{
 		"GetTopTasksGet",
 		"GET",
@@ -135,4 +346,172 @@ var routes = Routes{
 		"/top/users",
 		GetTopUsers,
 	},
+
+	Route{
+		"GetLockouts",
+		"GET",
+		"/admin/lockouts",
+		RequireRole(mongo_handlers.RoleAdmin, GetLockouts),
+	},
+
+	Route{
+		"ClearLockout",
+		"DELETE",
+		"/admin/lockouts/{key}",
+		RequireRole(mongo_handlers.RoleAdmin, ClearLockout),
+	},
+
+	Route{
+		"SetUserRole",
+		"PUT",
+		"/admin/users/{username}/role",
+		RequireRole(mongo_handlers.RoleAdmin, SetUserRole),
+	},
+
+	Route{
+		"GetUsers",
+		"GET",
+		"/admin/users",
+		RequireRole(mongo_handlers.RoleAdmin, GetUsers),
+	},
+
+	Route{
+		"GetUser",
+		"GET",
+		"/admin/users/{username}",
+		RequireRole(mongo_handlers.RoleAdmin, GetUser),
+	},
+
+	Route{
+		"DisableUser",
+		"POST",
+		"/admin/users/{username}/disable",
+		RequireRole(mongo_handlers.RoleAdmin, DisableUser),
+	},
+
+	Route{
+		"EnableUser",
+		"POST",
+		"/admin/users/{username}/enable",
+		RequireRole(mongo_handlers.RoleAdmin, EnableUser),
+	},
+
+	Route{
+		"LogoutUser",
+		"POST",
+		"/admin/users/{username}/logout",
+		RequireRole(mongo_handlers.RoleAdmin, LogoutUser),
+	},
+
+	Route{
+		"ResetUserPassword",
+		"POST",
+		"/admin/users/{username}/password/reset",
+		RequireRole(mongo_handlers.RoleAdmin, ResetUserPassword),
+	},
+
+	Route{
+		"GetAuditLog",
+		"GET",
+		"/admin/audit",
+		RequireRole(mongo_handlers.RoleAdmin, GetAuditLog),
+	},
+
+	Route{
+		"CreateWorkspace",
+		"POST",
+		"/workspaces",
+		CreateWorkspace,
+	},
+
+	Route{
+		"GetMyWorkspaces",
+		"GET",
+		"/workspaces",
+		GetMyWorkspaces,
+	},
+
+	Route{
+		"GetWorkspace",
+		"GET",
+		"/workspaces/{workspace_id}",
+		GetWorkspace,
+	},
+
+	Route{
+		"GetWorkspaceWorkflow",
+		"GET",
+		"/workspaces/{workspace_id}/workflow",
+		GetWorkspaceWorkflow,
+	},
+
+	Route{
+		"SetWorkspaceWorkflow",
+		"PUT",
+		"/workspaces/{workspace_id}/workflow",
+		SetWorkspaceWorkflow,
+	},
+
+	Route{
+		"CreateWorkspaceInvitation",
+		"POST",
+		"/workspaces/{workspace_id}/invitations",
+		CreateWorkspaceInvitation,
+	},
+
+	Route{
+		"GetWorkspaceInvitations",
+		"GET",
+		"/workspaces/{workspace_id}/invitations",
+		GetWorkspaceInvitations,
+	},
+
+	Route{
+		"RevokeWorkspaceInvitation",
+		"DELETE",
+		"/workspaces/{workspace_id}/invitations/{invitation_id}",
+		RevokeWorkspaceInvitation,
+	},
+
+	Route{
+		"SetWorkspaceMemberRole",
+		"PUT",
+		"/workspaces/{workspace_id}/members/{username}/role",
+		SetWorkspaceMemberRole,
+	},
+
+	Route{
+		"RemoveWorkspaceMember",
+		"DELETE",
+		"/workspaces/{workspace_id}/members/{username}",
+		RemoveWorkspaceMember,
+	},
+
+	Route{
+		"GetMyInvitations",
+		"GET",
+		"/invitations",
+		GetMyInvitations,
+	},
+
+	Route{
+		"JoinWorkspace",
+		"POST",
+		"/invitations/join",
+		JoinWorkspace,
+	},
+
+	Route{
+		"AcceptInvitation",
+		"POST",
+		"/invitations/{invitation_id}/accept",
+		AcceptInvitation,
+	},
+
+	Route{
+		"DeclineInvitation",
+		"DELETE",
+		"/invitations/{invitation_id}",
+		DeclineInvitation,
+	},
 }
@@ -0,0 +1,77 @@
+package auth_service
+
+import (
+	"errors"
+	"kafka_handlers"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	task_servicepb "task_service/proto"
+)
+
+// Sender of likes, views and other events to statistics service
+type EventPublisher interface {
+	// Empty `workspaceID` means that task is outside of workspaces
+	CreateEmptyStatistics(taskID int32, taskAuthor string, workspaceID string) error
+	Like(liker string, taskID int32, taskAuthor string, workspaceID string) error
+	View(viewer string, taskID int32, taskAuthor string, workspaceID string) error
+	// User's account was deleted, his likes, views and statistics of `deletedTaskIDs` should be deleted too
+	UserDeleted(username string, deletedTaskIDs []int32) error
+}
+
+var (
+	taskServiceGRPCConnection *grpc.ClientConn
+	taskServiceClient         task_servicepb.TaskServiceClient
+
+	// Kafka by default
+	eventPublisher EventPublisher = kafka_handlers.Publisher{}
+
+	// Statistics service is requested by HTTP
+	statisticsServiceURL = "http://statistics_service:8090"
+)
+
+// Create gRPC client of task service which is located at TASK_SERVICE_URL
+func InitTaskServiceClient() error {
+	taskServiceURL, ok := os.LookupEnv("TASK_SERVICE_URL")
+	if !ok {
+		return errors.New("No TASK_SERVICE_URL setted but should")
+	}
+
+	var err error
+	taskServiceGRPCConnection, err = grpc.NewClient(taskServiceURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+
+	taskServiceClient = task_servicepb.NewTaskServiceClient(taskServiceGRPCConnection)
+	return nil
+}
+
+func CloseTaskServiceClient() {
+	if taskServiceGRPCConnection != nil {
+		taskServiceGRPCConnection.Close()
+	}
+}
+
+// Replace client of task service (e.g. by fake one in tests)
+func SetTaskServiceClient(client task_servicepb.TaskServiceClient) {
+	taskServiceClient = client
+}
+
+// Replace publisher of events to statistics service (e.g. by fake one in tests)
+func SetEventPublisher(publisher EventPublisher) {
+	eventPublisher = publisher
+}
+
+// Send GET request to statistics service. `path` should start with `/`
+func getStatistics(path string) (*http.Response, error) {
+	return http.Get(statisticsServiceURL + path)
+}
+
+// Replace address of statistics service (e.g. by test server in tests)
+func SetStatisticsServiceURL(url string) {
+	statisticsServiceURL = url
+}
@@ -0,0 +1,132 @@
+package auth_service
+
+import (
+	"mongo_handlers"
+	"time"
+)
+
+// Storage of users and everything what belongs to their accounts
+//
+//	Methods have the same contracts as functions of `mongo_handlers` with the same names
+//	(including returned status codes), `mongo_handlers.Store` is the production implementation
+type UserStore interface {
+	// Users
+	CreateUser(user mongo_handlers.User) (code int, err error)
+	GetUser(username string, user *mongo_handlers.User) (code int, err error)
+	UpdateUser(username string, update mongo_handlers.UserUpdate) (code int, err error)
+	CheckIfUserExists(username string) bool
+	DeleteUser(username string) (code int, err error)
+	SearchUsers(query string, offset int64, limit int64, page *mongo_handlers.UserPage) (code int, err error)
+
+	// External identities (OIDC)
+	GetExternalIdentity(issuer string, subject string, identity *mongo_handlers.ExternalIdentity) (code int, err error)
+	StoreExternalIdentity(identity mongo_handlers.ExternalIdentity) (code int, err error)
+
+	// Two-factor authentication
+	GetTwoFactor(username string, twoFactor *mongo_handlers.TwoFactor) (code int, err error)
+	StoreTwoFactor(twoFactor mongo_handlers.TwoFactor) (code int, err error)
+	DeleteTwoFactor(username string) (code int, err error)
+	UseTOTPStep(username string, step int64) (bool, error)
+	UseRecoveryCode(username string, codeHash string) (bool, error)
+	RecordTwoFactorFailure(username string, maxAttempts int, lockDuration time.Duration) error
+
+	// Failed logins and lockouts
+	GetLoginAttempts(key string, attempts *mongo_handlers.LoginAttempts) (code int, err error)
+	RecordLoginFailure(key string, now time.Time, ttl time.Duration, attempts *mongo_handlers.LoginAttempts) error
+	LockLogin(key string, lockedUntil time.Time) error
+	ResetLoginAttempts(key string) error
+	StoreLockoutEvent(event mongo_handlers.LockoutEvent) error
+	GetLockoutEvents(activeOnly bool, offset int64, limit int64, events *[]mongo_handlers.LockoutEvent) (code int, err error)
+	ClearLockout(key string, clearedBy string) (code int, err error)
+
+	// Password resets
+	StorePasswordReset(reset mongo_handlers.PasswordReset) (code int, err error)
+	PopPasswordReset(tokenHash string, reset *mongo_handlers.PasswordReset) (code int, err error)
+
+	// Account deletions
+	StartAccountDeletion(username string, requestedAt time.Time, deletion *mongo_handlers.AccountDeletion) (code int, err error)
+	GetPendingAccountDeletion(username string, deletion *mongo_handlers.AccountDeletion) (code int, err error)
+	GetPendingAccountDeletions(deletions *[]mongo_handlers.AccountDeletion) (code int, err error)
+	ClaimAccountDeletion(username string, lockID string, lockedUntil time.Time, deletion *mongo_handlers.AccountDeletion) (code int, err error)
+	UpdateAccountDeletion(deletion mongo_handlers.AccountDeletion) (code int, err error)
+
+	// Personal data exports
+	CreateDataExport(export mongo_handlers.DataExport) (code int, err error)
+	GetDataExport(username string, exportID string, export *mongo_handlers.DataExport) (code int, err error)
+	GetUserDataExports(username string, exports *[]mongo_handlers.DataExport) (code int, err error)
+	UpdateDataExport(export mongo_handlers.DataExport) (code int, err error)
+	StoreDataExportArchive(exportID string, archive []byte) (code int, err error)
+	GetDataExportArchive(exportID string, archive *[]byte) (code int, err error)
+	DeleteDataExportArchive(exportID string) (code int, err error)
+	DeleteExpiredDataExportArchives(before time.Time) (code int, err error)
+
+	// Audit log
+	StoreAuditEvent(event mongo_handlers.AuditEvent) error
+	GetAuditEvents(filter mongo_handlers.AuditEventFilter, offset int64, limit int64, events *[]mongo_handlers.AuditEvent) (code int, err error)
+
+	// Workspaces
+	CreateWorkspace(workspace mongo_handlers.Workspace) (code int, err error)
+	GetWorkspace(workspaceID string, workspace *mongo_handlers.Workspace) (code int, err error)
+	GetWorkspaces(workspaceIDs []string, workspaces *[]mongo_handlers.Workspace) (code int, err error)
+	AddWorkspaceMember(member mongo_handlers.WorkspaceMember) (code int, err error)
+	GetWorkspaceMember(workspaceID string, username string, member *mongo_handlers.WorkspaceMember) (code int, err error)
+	GetWorkspaceMembers(workspaceID string, members *[]mongo_handlers.WorkspaceMember) (code int, err error)
+	GetUserWorkspaceMembers(username string, members *[]mongo_handlers.WorkspaceMember) (code int, err error)
+	SetWorkspaceMemberRole(workspaceID string, username string, role string) (code int, err error)
+	DeleteWorkspaceMember(workspaceID string, username string) (code int, err error)
+	SetWorkspaceOwner(workspaceID string, username string) (code int, err error)
+	DeleteWorkspace(workspaceID string) (code int, err error)
+	CreateWorkspaceInvitation(invitation mongo_handlers.WorkspaceInvitation) (code int, err error)
+	GetWorkspaceInvitation(invitationID string, invitation *mongo_handlers.WorkspaceInvitation) (code int, err error)
+	GetWorkspaceInvitationByTokenHash(tokenHash string, invitation *mongo_handlers.WorkspaceInvitation) (code int, err error)
+	GetWorkspaceInvitations(workspaceID string, invitations *[]mongo_handlers.WorkspaceInvitation) (code int, err error)
+	GetUserWorkspaceInvitations(username string, invitations *[]mongo_handlers.WorkspaceInvitation) (code int, err error)
+	DeleteWorkspaceInvitation(invitationID string) (code int, err error)
+}
+
+// Storage of sessions, tokens and unfinished logins
+//
+//	Methods have the same contracts as functions of `mongo_handlers` with the same names
+//	(including returned status codes), `mongo_handlers.Store` is the production implementation
+type SessionStore interface {
+	// Sessions
+	CreateSession(session mongo_handlers.Session) (code int, err error)
+	GetSession(sessionID string, session *mongo_handlers.Session) (code int, err error)
+	GetUserSessions(username string, sessions *[]mongo_handlers.Session) (code int, err error)
+	TouchSession(sessionID string, lastSeenAt time.Time) error
+	ExtendSession(sessionID string, expiresAt time.Time) (code int, err error)
+	RevokeSession(username string, sessionID string) (code int, err error)
+	RevokeUserSessions(username string, exceptSessionID string) (code int, err error)
+	// Delete sessions, refresh tokens and personal access tokens of user
+	DeleteUserSessions(username string) (code int, err error)
+
+	// Refresh tokens
+	StoreRefreshToken(token mongo_handlers.RefreshToken) (code int, err error)
+	GetRefreshToken(tokenHash string, token *mongo_handlers.RefreshToken) (code int, err error)
+	MarkRefreshTokenUsed(tokenHash string) (marked bool, err error)
+	RevokeRefreshTokenFamily(familyID string) (code int, err error)
+	RevokeUserRefreshTokens(username string, exceptFamilyID string) (code int, err error)
+
+	// Personal access tokens
+	StorePersonalAccessToken(token mongo_handlers.PersonalAccessToken) (code int, err error)
+	GetPersonalAccessTokenByHash(tokenHash string, token *mongo_handlers.PersonalAccessToken) (code int, err error)
+	GetUserPersonalAccessTokens(username string, tokens *[]mongo_handlers.PersonalAccessToken) (code int, err error)
+	TouchPersonalAccessToken(tokenID string, lastUsedAt time.Time) error
+	RevokePersonalAccessToken(username string, tokenID string) (code int, err error)
+
+	// OIDC logins
+	StoreOIDCLoginState(state mongo_handlers.OIDCLoginState) (code int, err error)
+	PopOIDCLoginState(state string, loginState *mongo_handlers.OIDCLoginState) (code int, err error)
+}
+
+// Storages which are used by handlers. MongoDB by default
+var (
+	userStore    UserStore    = mongo_handlers.Store{}
+	sessionStore SessionStore = mongo_handlers.Store{}
+)
+
+// Replace storages used by handlers (e.g. by `MemoryStore` in tests or local runs without MongoDB)
+func SetStores(users UserStore, sessions SessionStore) {
+	userStore = users
+	sessionStore = sessions
+}
@@ -0,0 +1,88 @@
+package auth_service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	task_servicepb "task_service/proto"
+)
+
+// Priorities of tasks
+//
+//	HTTP API uses names of `TaskPriority` enum without prefix: `P0` (the most urgent) ... `P4`.
+//	Names are case insensitive, task without priority gets `P2`
+const taskPriorityPrefix = "TASK_PRIORITY_"
+
+// The longest view of tasks with close deadline
+const maxDueWithinDays = 365
+
+// Name of priority in HTTP API. Unspecified priority has empty name
+func taskPriorityName(priority task_servicepb.TaskPriority) string {
+	if priority == task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED {
+		return ""
+	}
+	return strings.TrimPrefix(priority.String(), taskPriorityPrefix)
+}
+
+// Parse name of priority from HTTP API. Empty name means unspecified priority
+func parseTaskPriority(name string) (task_servicepb.TaskPriority, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(name))
+	if normalized == "" {
+		return task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED, nil
+	}
+	value, ok := task_servicepb.TaskPriority_value[taskPriorityPrefix+normalized]
+	if !ok || value == int32(task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED) {
+		return task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED, fmt.Errorf("unknown task priority `%s`, expected one of P0-P4", name)
+	}
+	return task_servicepb.TaskPriority(value), nil
+}
+
+func timestampOrNil(value *time.Time) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
+	return timestamppb.New(*value)
+}
+
+func timeOrNil(timestamp *timestamppb.Timestamp) *time.Time {
+	if timestamp == nil {
+		return nil
+	}
+	value := timestamp.AsTime()
+	return &value
+}
+
+// Check views of tasks by deadline: overdue tasks or tasks with deadline in the next `dueWithinDays` days
+func checkDeadlineView(overdue bool, dueWithinDays int32) error {
+	if dueWithinDays < 0 || dueWithinDays > maxDueWithinDays {
+		return fmt.Errorf("`due_within_days` should be integer from 1 to %d", maxDueWithinDays)
+	}
+	if overdue && dueWithinDays > 0 {
+		return errors.New("`overdue` and `due_within_days` can't be used together")
+	}
+	return nil
+}
+
+// Parse optional `overdue` and `due_within_days` query parameters
+func parseDeadlineQuery(r *http.Request) (overdue bool, dueWithinDays int32, err error) {
+	if value := r.URL.Query().Get("overdue"); value != "" {
+		overdue, err = strconv.ParseBool(value)
+		if err != nil {
+			return false, 0, errors.New("`overdue` should be boolean")
+		}
+	}
+	if value := r.URL.Query().Get("due_within_days"); value != "" {
+		days, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || days <= 0 {
+			return false, 0, fmt.Errorf("`due_within_days` should be integer from 1 to %d", maxDueWithinDays)
+		}
+		dueWithinDays = int32(days)
+	}
+	return overdue, dueWithinDays, checkDeadlineView(overdue, dueWithinDays)
+}
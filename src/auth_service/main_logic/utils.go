@@ -1,26 +1,114 @@
 package auth_service
 
 import (
-	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"jwt_handlers"
+	"log"
+	"mail_handlers"
 	"mongo_handlers"
+	"net"
 	"net/http"
+	"net/url"
+	"oidc_handlers"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// Generate JWT token for user
-func GenerateJWTToken(username string) (string, error) {
-	han := jwt_handlers.GetJWTHandlers()
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	// How often session's last activity time is updated
+	sessionTouchInterval = time.Minute
+
+	personalAccessTokenPrefix = "ttpat_"
+
+	refreshTokenCookieName = "refresh_token"
+	refreshTokenCookiePath = "/refresh"
+
+	// How long user has to finish login in external identity provider
+	oidcLoginStateTTL = 10 * time.Minute
+
+	// Cookie which ties OIDC login to the browser where it was started
+	oidcStateCookieName = "oidc_state"
+	oidcStateCookiePath = "/oidc"
+
+	// How long user has to enter two-factor code after password
+	twoFactorChallengeTTL = 5 * time.Minute
+	// Wrong two-factor codes in a row after which codes are not accepted for `twoFactorLockDuration`
+	maxTwoFactorAttempts  = 5
+	twoFactorLockDuration = 5 * time.Minute
+
+	passwordResetTTL = time.Hour
+
+	// How long link for email verification is valid
+	emailVerificationTTL = 24 * time.Hour
+	defaultPublicURL     = "http://localhost:8080"
+
+	// Page size of users list for admins
+	defaultUsersPageSize = 50
+	maxUsersPageSize     = 100
+	// Page size of lockout events
+	defaultLockoutsPageSize = 100
+	maxLockoutsPageSize     = 1000
+	// Page size of audit log
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+	// Page size of requestor's tasks
+	defaultMyTasksPageSize = 50
+	maxMyTasksPageSize     = 100
+)
+
+// Values of `typ` claim of JWT tokens signed by the service
+const (
+	tokenTypeAccess             = "access"
+	tokenTypeTwoFactorChallenge = "2fa_challenge"
+	tokenTypeEmailVerification  = "email_verify"
+)
+
+// Scopes of personal access tokens
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeStatsRead  = "stats:read"
+)
+
+var allScopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeStatsRead}
+
+var errAccountDisabled = errors.New("account is disabled")
+
+// Response to failed login doesn't tell whether account exists
+var errIncorrectCredentials = errors.New("Incorrect username or password")
+
+// Generate JWT token for user's session
+//
+//	Token is short-lived, so it should be renewed by refresh token (see `IssueTokens`).
+//	User's role is carried in `role` claim, so changed role is applied after token is renewed
+func GenerateJWTToken(username string, sessionID string, role string) (string, error) {
+	now := time.Now()
 	payload := jwt.MapClaims{
 		"username": username,
+		"sid":      sessionID,
+		"role":     role,
+		"typ":      tokenTypeAccess,
+		"iat":      now.Unix(),
+		"exp":      now.Add(accessTokenTTL).Unix(),
+		"jti":      uuid.New().String(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, payload)
 
-	tokenString, err := token.SignedString(han.JwtPrivate)
+	tokenString, err := jwt_handlers.GetKeyRing().Sign(payload)
 	if err != nil {
 		err = fmt.Errorf("error while signing token: %w", err)
 		return "", err
@@ -28,49 +116,416 @@ func GenerateJWTToken(username string) (string, error) {
 	return tokenString, nil
 }
 
-func CheckIfUserAuthenticated(r *http.Request, username *string) (code int, err error) {
-	// Get token from Cookie
-	cookie, err := r.Cookie("token")
+// Information about authenticated requestor
+type AuthInfo struct {
+	Username string
+	Role     string
+	// Set if request is authenticated by session's access token
+	SessionID string
+	// Set if request is authenticated by personal access token
+	AccessTokenID string
+	Scopes        []string
+}
+
+// Check if requestor is allowed to perform actions of `scope`
+//
+//	Session's access tokens have every scope
+func (info *AuthInfo) HasScope(scope string) bool {
+	if info.AccessTokenID == "" {
+		return true
+	}
+	return slices.Contains(info.Scopes, scope)
+}
+
+// Check if requestor has `role` or higher role
+func (info *AuthInfo) HasRole(role string) bool {
+	return RoleIncludes(info.Role, role)
+}
+
+// Check if request is authenticated and get requestor's username
+//
+//	`scopes` are required if request is authenticated by personal access token.
+//	Requests without required scopes can't be authenticated by personal access token at all
+func CheckIfUserAuthenticated(r *http.Request, username *string, scopes ...string) (code int, err error) {
+	var info AuthInfo
+	code, err = GetAuthInfo(r, &info, scopes...)
+	if err != nil {
+		return code, err
+	}
+	*username = info.Username
+	return http.StatusOK, nil
+}
+
+// Check token from request's Cookie or `Authorization` header and load information about it
+//
+//	See `CheckIfUserAuthenticated` for `scopes` description
+func GetAuthInfo(r *http.Request, info *AuthInfo, scopes ...string) (code int, err error) {
+	tokenString, err := getRequestToken(r)
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
-	tokenString := cookie.Value
 
-	han := jwt_handlers.GetJWTHandlers()
+	if strings.HasPrefix(tokenString, personalAccessTokenPrefix) {
+		code, err = checkPersonalAccessToken(tokenString, info)
+		if err != nil {
+			return code, err
+		}
+		if len(scopes) == 0 {
+			return http.StatusForbidden, errors.New("personal access token can't be used for this request")
+		}
+		for _, scope := range scopes {
+			if !info.HasScope(scope) {
+				return http.StatusForbidden, fmt.Errorf("personal access token has no `%s` scope", scope)
+			}
+		}
+	} else {
+		code, err = checkSessionToken(tokenString, info)
+		if err != nil {
+			return code, err
+		}
+	}
+
+	return checkUserEnabled(info.Username)
+}
+
+// Check if user's account is not disabled by admin
+//
+//	If account is disabled returns 403 (Status Forbidden)
+func checkUserEnabled(username string) (code int, err error) {
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(username, &user)
+	if err != nil {
+		return code, err
+	}
+	if user.Disabled {
+		return http.StatusForbidden, errAccountDisabled
+	}
+	return http.StatusOK, nil
+}
+
+// Get token from `Authorization: Bearer <token>` header or from Cookie
+func getRequestToken(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || tokenString == "" {
+			return "", errors.New("authorization header should have `Bearer <token>` format")
+		}
+		return tokenString, nil
+	}
+
+	cookie, err := r.Cookie("token")
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// Check personal access token and load information about it
+func checkPersonalAccessToken(tokenString string, info *AuthInfo) (code int, err error) {
+	var token mongo_handlers.PersonalAccessToken
+	code, err = sessionStore.GetPersonalAccessTokenByHash(HashToken(tokenString), &token)
+	if err != nil {
+		return code, err
+	}
+	if token.Revoked {
+		return http.StatusUnauthorized, errors.New("personal access token has been revoked")
+	}
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return http.StatusUnauthorized, errors.New("personal access token has expired")
+	}
+
+	if now.Sub(token.LastUsedAt) > sessionTouchInterval {
+		if err := sessionStore.TouchPersonalAccessToken(token.TokenID, now); err != nil {
+			log.Println("function `checkPersonalAccessToken`:", err.Error())
+		}
+	}
+
+	// Personal access tokens act with rights of ordinary user, so they can't be used for moderation
+	info.Username = token.Username
+	info.Role = mongo_handlers.RoleUser
+	info.AccessTokenID = token.TokenID
+	info.Scopes = token.Scopes
+	return http.StatusOK, nil
+}
+
+// Check session's access token (JWT) and load information about its session
+func checkSessionToken(tokenString string, info *AuthInfo) (code int, err error) {
 	payload := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, &payload, func(token *jwt.Token) (interface{}, error) {
-		return han.JwtPublic, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &payload, jwt_handlers.GetKeyRing().Keyfunc, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
 
 	// Check if token is valid
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return http.StatusUnauthorized, errors.New("the token has expired")
+	}
 	if err != nil || !token.Valid {
 		return http.StatusBadRequest, errors.New("invalid jwt token")
 	}
 
 	// Check if token has neccessary information in payload
-	if _, ok := payload["username"]; !ok {
+	username, ok := payload["username"].(string)
+	if !ok {
+		return http.StatusBadRequest, errors.New("invalid payload in jwt token")
+	}
+	sessionID, ok := payload["sid"].(string)
+	if !ok {
 		return http.StatusBadRequest, errors.New("invalid payload in jwt token")
 	}
-	*username = payload["username"].(string) // ?? is it ok?
+	// Other tokens signed by the service (e.g. two-factor challenge) can't be used as access token
+	if tokenType, _ := payload["typ"].(string); tokenType != tokenTypeAccess {
+		return http.StatusBadRequest, errors.New("jwt token is not an access token")
+	}
+	// Tokens issued before roles were introduced have no role
+	role, _ := payload["role"].(string)
+	if role == "" {
+		role = mongo_handlers.RoleUser
+	}
+	if err := mongo_handlers.ValidateRole(role); err != nil {
+		return http.StatusBadRequest, errors.New("invalid payload in jwt token")
+	}
+
+	// Check if token's session is still active
+	var session mongo_handlers.Session
+	code, err = sessionStore.GetSession(sessionID, &session)
+	if err != nil {
+		return code, err
+	}
+	if session.Revoked || session.Username != username {
+		return http.StatusUnauthorized, errors.New("the session has been revoked")
+	}
+
+	// Last activity time is not critical, so it's updated not more often than once in `sessionTouchInterval`
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := sessionStore.TouchSession(sessionID, now); err != nil {
+			log.Println("function `checkSessionToken`:", err.Error())
+		}
+	}
+
+	info.Username = username
+	info.Role = role
+	info.SessionID = sessionID
+	return http.StatusOK, nil
+}
+
+// Create new session for user who has just logged in and set its tokens into Cookies
+func StartSession(w http.ResponseWriter, r *http.Request, username string) (code int, err error) {
+	code, err = checkUserEnabled(username)
+	if err != nil {
+		return code, err
+	}
+
+	now := time.Now()
+	session := mongo_handlers.Session{
+		SessionID:  uuid.New().String(),
+		Username:   username,
+		UserAgent:  r.UserAgent(),
+		IP:         GetClientIP(r),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+	code, err = sessionStore.CreateSession(session)
+	if err != nil {
+		err = fmt.Errorf("error in function `CreateSession` occurred: %w", err)
+		return code, err
+	}
+
+	return IssueTokens(w, username, session.SessionID)
+}
+
+// Generate new access token and refresh token for user's session and set them into Cookies
+//
+//	Session is also an identifier of refresh tokens chain. User's current role is put into access token
+func IssueTokens(w http.ResponseWriter, username string, sessionID string) (code int, err error) {
+	var user mongo_handlers.User
+	code, err = userStore.GetUser(username, &user)
+	if err != nil {
+		return code, err
+	}
+	// Disabled user can't refresh tokens of sessions which were started before
+	if user.Disabled {
+		return http.StatusForbidden, errAccountDisabled
+	}
 
-	// Get user's relevant token
-	relevantToken, err := mongo_handlers.GetUserToken(*username)
+	tokenString, err := GenerateJWTToken(username, sessionID, user.GetRole())
 	if err != nil {
+		err = fmt.Errorf("error in function `GenerateJWTToken` occurred: %w", err)
 		return http.StatusInternalServerError, err
 	}
 
-	// Check if token is relevant
-	if relevantToken != tokenString {
-		return http.StatusUnauthorized, errors.New("the token has expired")
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	now := time.Now()
+	code, err = sessionStore.StoreRefreshToken(mongo_handlers.RefreshToken{
+		TokenHash: HashToken(refreshToken),
+		FamilyID:  sessionID,
+		Username:  username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(refreshTokenTTL),
+	})
+	if err != nil {
+		err = fmt.Errorf("error in function `StoreRefreshToken` occurred: %w", err)
+		return code, err
+	}
+
+	code, err = sessionStore.ExtendSession(sessionID, now.Add(refreshTokenTTL))
+	if err != nil {
+		err = fmt.Errorf("error in function `ExtendSession` occurred: %w", err)
+		return code, err
 	}
 
+	http.SetCookie(w, &http.Cookie{
+		Name:     "token",
+		Value:    tokenString,
+		Expires:  now.Add(accessTokenTTL),
+		HttpOnly: true,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookieName,
+		Value:    refreshToken,
+		Path:     refreshTokenCookiePath,
+		Expires:  now.Add(refreshTokenTTL),
+		HttpOnly: true,
+	})
+
 	return http.StatusOK, nil
 }
 
-// Hash given password by md5 + salt
-func HashPassword(password string) string {
-	hash := md5.Sum([]byte(password + "SALT"))
-	return fmt.Sprintf("%x", hash)
+// Revoke session and all its refresh tokens
+func RevokeSession(username string, sessionID string) (code int, err error) {
+	code, err = sessionStore.RevokeSession(username, sessionID)
+	if err != nil {
+		return code, err
+	}
+	return sessionStore.RevokeRefreshTokenFamily(sessionID)
+}
+
+// Revoke all user's sessions and their refresh tokens except `exceptSessionID` session
+//
+//	Empty `exceptSessionID` revokes every session
+func RevokeAllSessions(username string, exceptSessionID string) (code int, err error) {
+	code, err = sessionStore.RevokeUserSessions(username, exceptSessionID)
+	if err != nil {
+		return code, err
+	}
+	return sessionStore.RevokeUserRefreshTokens(username, exceptSessionID)
+}
+
+// Remove access and refresh tokens from client's Cookies
+func ClearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "token",
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookieName,
+		Value:    "",
+		Path:     refreshTokenCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// Remember `state` of OIDC login in browser which started it. Callback accepts only `state` from this cookie,
+// so link to callback with `state` of another login (e.g. attacker's one) doesn't log user in
+func SetOIDCStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     oidcStateCookiePath,
+		Expires:  time.Now().Add(oidcLoginStateTTL),
+		HttpOnly: true,
+		Secure:   true,
+		// Identity provider redirects to callback by top-level navigation, Lax cookies are sent with it
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func ClearOIDCStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     oidcStateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Get `offset` and `limit` query parameters of paginated request
+//
+//	Default offset is 0, default limit is `defaultLimit`. Limit can't be greater than `maxLimit`
+func ParsePageQuery(r *http.Request, defaultLimit int64, maxLimit int64) (offset int64, limit int64, err error) {
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("`offset` should be non-negative integer")
+		}
+	}
+	limit = defaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 || limit > maxLimit {
+			return 0, 0, fmt.Errorf("`limit` should be integer from 1 to %d", maxLimit)
+		}
+	}
+	return offset, limit, nil
+}
+
+// Parse optional time query parameter in RFC 3339 format
+func parseTimeQuery(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("`%s` should be time in RFC 3339 format (e.g. 2024-01-02T15:04:05Z)", name)
+	}
+	return &parsed, nil
+}
+
+// Get IP address of the client without port
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Generate random opaque refresh token
+func generateRefreshToken() (string, error) {
+	return generateRandomToken()
+}
+
+// Generate random personal access token. It has prefix, so it can be distinguished from JWT
+func GeneratePersonalAccessToken() (string, error) {
+	token, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+	return personalAccessTokenPrefix + token, nil
+}
+
+func generateRandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Refresh tokens and personal access tokens are stored in Mongo only as sha256 hashes
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }
 
 // Copy response `resp` to response writer `rw`
@@ -80,3 +535,202 @@ func CopyResponseToWriter(rw http.ResponseWriter, resp *http.Response) {
 	io.Copy(rw, resp.Body)
 	resp.Body.Close()
 }
+
+// Generate short-lived token which proves that user has entered correct password,
+// but still has to enter two-factor code
+func GenerateTwoFactorChallenge(username string) (string, error) {
+	now := time.Now()
+	payload := jwt.MapClaims{
+		"username": username,
+		"typ":      tokenTypeTwoFactorChallenge,
+		"iat":      now.Unix(),
+		"exp":      now.Add(twoFactorChallengeTTL).Unix(),
+		"jti":      uuid.New().String(),
+	}
+
+	tokenString, err := jwt_handlers.GetKeyRing().Sign(payload)
+	if err != nil {
+		err = fmt.Errorf("error while signing token: %w", err)
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// Check two-factor challenge token and get username from it
+func ParseTwoFactorChallenge(tokenString string, username *string) (code int, err error) {
+	payload := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, &payload, jwt_handlers.GetKeyRing().Keyfunc, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return http.StatusUnauthorized, errors.New("the challenge token has expired, log in again")
+	}
+	if err != nil || !token.Valid {
+		return http.StatusBadRequest, errors.New("invalid challenge token")
+	}
+
+	if tokenType, _ := payload["typ"].(string); tokenType != tokenTypeTwoFactorChallenge {
+		return http.StatusBadRequest, errors.New("invalid challenge token")
+	}
+	*username, _ = payload["username"].(string)
+	if *username == "" {
+		return http.StatusBadRequest, errors.New("invalid payload in challenge token")
+	}
+	return http.StatusOK, nil
+}
+
+// Generate signed token which proves that user owns `email`
+func GenerateEmailVerificationToken(username string, email string) (string, error) {
+	now := time.Now()
+	payload := jwt.MapClaims{
+		"username": username,
+		"email":    email,
+		"typ":      tokenTypeEmailVerification,
+		"iat":      now.Unix(),
+		"exp":      now.Add(emailVerificationTTL).Unix(),
+		"jti":      uuid.New().String(),
+	}
+
+	tokenString, err := jwt_handlers.GetKeyRing().Sign(payload)
+	if err != nil {
+		err = fmt.Errorf("error while signing token: %w", err)
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// Check email verification token and get username and email from it
+func ParseEmailVerificationToken(tokenString string, username *string, email *string) (code int, err error) {
+	payload := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, &payload, jwt_handlers.GetKeyRing().Keyfunc, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return http.StatusUnauthorized, errors.New("the verification link has expired, change email again to get new one")
+	}
+	if err != nil || !token.Valid {
+		return http.StatusBadRequest, errors.New("invalid verification token")
+	}
+
+	if tokenType, _ := payload["typ"].(string); tokenType != tokenTypeEmailVerification {
+		return http.StatusBadRequest, errors.New("invalid verification token")
+	}
+	*username, _ = payload["username"].(string)
+	*email, _ = payload["email"].(string)
+	if *username == "" || *email == "" {
+		return http.StatusBadRequest, errors.New("invalid payload in verification token")
+	}
+	return http.StatusOK, nil
+}
+
+// Send verification link to new email. Email should be already stored as user's pending email
+//
+//	Email becomes user's email after `VerifyEmail` handler is opened by the link
+func SendEmailVerification(username string, email string) (code int, err error) {
+	token, err := GenerateEmailVerificationToken(username, email)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = defaultPublicURL
+	}
+	link := strings.TrimSuffix(publicURL, "/") + "/profile/email/verify?token=" + url.QueryEscape(token)
+
+	err = mail_handlers.GetSender().Send(NewEmailVerificationMessage(email, username, link))
+	if err != nil {
+		err = fmt.Errorf("failed to send verification email: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Check TOTP code or one-time recovery code of user with enabled two-factor authentication
+//
+//	Every code can be used only once.
+//	If there were too many wrong codes in a row returns 429 (Status Too Many Requests)
+func CheckTwoFactorCode(twoFactor *mongo_handlers.TwoFactor, code string) (status int, err error) {
+	now := time.Now()
+	if now.Before(twoFactor.LockedUntil) {
+		return http.StatusTooManyRequests, fmt.Errorf("too many wrong codes, try again after %s", twoFactor.LockedUntil.Format(time.RFC3339))
+	}
+
+	step, ok, err := VerifyTOTPCode(twoFactor.Secret, code, now)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if ok {
+		fresh, err := userStore.UseTOTPStep(twoFactor.Username, step)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if !fresh {
+			return http.StatusUnauthorized, errors.New("the code has already been used, wait for the next one")
+		}
+		return http.StatusOK, nil
+	}
+
+	used, err := userStore.UseRecoveryCode(twoFactor.Username, HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if used {
+		log.Printf("User `%s` used recovery code, %d left", twoFactor.Username, len(twoFactor.RecoveryCodeHashes)-1)
+		return http.StatusOK, nil
+	}
+
+	if err := userStore.RecordTwoFactorFailure(twoFactor.Username, maxTwoFactorAttempts, twoFactorLockDuration); err != nil {
+		log.Println("function `CheckTwoFactorCode`:", err.Error())
+	}
+	return http.StatusUnauthorized, errors.New("incorrect two-factor code")
+}
+
+// Create user for external identity which logs in for the first time
+//
+//	Username is taken from `preferred_username` or email, if it's already taken then random suffix is added.
+//	User has no password, so it can log in only through identity provider
+func ProvisionExternalUser(claims *oidc_handlers.IDTokenClaims) (username string, code int, err error) {
+	base := sanitizeUsername(claims.PreferredUsername)
+	if base == "" {
+		base = sanitizeUsername(strings.Split(claims.Email, "@")[0])
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	username = base
+	for attempt := 0; !IsUsernameAvailable(username); attempt++ {
+		if attempt == 10 {
+			return "", http.StatusInternalServerError, errors.New("failed to choose username for new user")
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", http.StatusInternalServerError, err
+		}
+		username = base + "-" + hex.EncodeToString(suffix)
+	}
+
+	user := mongo_handlers.User{
+		Username:  username,
+		FirstName: claims.GivenName,
+		LastName:  claims.FamilyName,
+	}
+	// Email is trusted only if identity provider has verified it
+	if claims.Email != "" && claims.EmailVerified && mongo_handlers.ValidateEmail(claims.Email) == nil {
+		user.Email = claims.Email
+		user.EmailVerified = true
+	}
+	code, err = userStore.CreateUser(user)
+	if err != nil {
+		err = fmt.Errorf("error in function `CreateUser` occurred: %w", err)
+		return "", code, err
+	}
+	return username, http.StatusOK, nil
+}
+
+// Keep only letters, digits and `.`, `_`, `-` characters
+func sanitizeUsername(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, name)
+}
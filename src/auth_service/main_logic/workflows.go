@@ -0,0 +1,94 @@
+package auth_service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	task_servicepb "task_service/proto"
+)
+
+// Statuses of tasks
+//
+//	HTTP API uses names of `TaskStatus` enum without prefix in lower case: `not_taken`, `in_progress`,
+//	`can_be_tested`, `done`, `cancelled`. Spaces are accepted instead of underscores, so statuses
+//	like `not taken` which were used before workflows still work. Which statuses are used and how
+//	task can move between them is defined by workflow of workspace, task service checks transitions
+const taskStatusPrefix = "TASK_STATUS_"
+
+// Name of status in HTTP API. Unspecified status has empty name
+func taskStatusName(taskStatus task_servicepb.TaskStatus) string {
+	if taskStatus == task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(taskStatus.String(), taskStatusPrefix))
+}
+
+// Parse name of status from HTTP API. Empty name means unspecified status
+func parseTaskStatus(name string) (task_servicepb.TaskStatus, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if normalized == "" {
+		return task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED, nil
+	}
+	value, ok := task_servicepb.TaskStatus_value[taskStatusPrefix+normalized]
+	if !ok || value == int32(task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED) {
+		return task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED, fmt.Errorf("unknown task status `%s`", name)
+	}
+	return task_servicepb.TaskStatus(value), nil
+}
+
+func parseTaskStatuses(names []string) ([]task_servicepb.TaskStatus, error) {
+	statuses := []task_servicepb.TaskStatus{}
+	for _, name := range names {
+		taskStatus, err := parseTaskStatus(name)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, taskStatus)
+	}
+	return statuses, nil
+}
+
+// Convert workflow from request body. Consistency of workflow is checked by task service
+func workflowFromBody(workspaceID string, body WorkflowBody) (*task_servicepb.Workflow, error) {
+	workflow := &task_servicepb.Workflow{WorkspaceId: workspaceID}
+	var err error
+	if workflow.InitialStatus, err = parseTaskStatus(body.InitialStatus); err != nil {
+		return nil, err
+	}
+	if workflow.Statuses, err = parseTaskStatuses(body.Statuses); err != nil {
+		return nil, err
+	}
+	if workflow.TerminalStatuses, err = parseTaskStatuses(body.TerminalStatuses); err != nil {
+		return nil, err
+	}
+	for _, transition := range body.Transitions {
+		from, err := parseTaskStatus(transition.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseTaskStatus(transition.To)
+		if err != nil {
+			return nil, err
+		}
+		workflow.Transitions = append(workflow.Transitions, &task_servicepb.WorkflowTransition{From: from, To: to})
+	}
+	return workflow, nil
+}
+
+// HTTP code for error of task service which is caused by workflow
+//
+//	If status is not allowed by workflow returns 409 (Status Conflict)
+//	If workflow is not consistent returns 400 (Status Bad Request)
+func workflowErrorCode(err error) int {
+	switch status.Code(err) {
+	case codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
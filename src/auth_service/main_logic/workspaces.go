@@ -0,0 +1,158 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mongo_handlers"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	task_servicepb "task_service/proto"
+)
+
+// Workspaces
+//
+//	Workspace is a shared space of tasks. Members of workspace and invitations are stored in Mongo,
+//	task service only keeps id of task's workspace. Tasks of workspace are visible only to its members,
+//	tasks outside of workspaces are visible to everyone.
+//	Roles of members: member can create tasks and edit his own ones, admin can also edit every task
+//	of workspace, invite users and change roles, owner is the creator of workspace and can't be removed
+const (
+	workspaceInvitationTTL = 7 * 24 * time.Hour
+	maxWorkspaceNameLength = 100
+)
+
+var errWorkspaceNotFound = errors.New("workspace not found")
+
+func validateWorkspaceName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("workspace name should not be empty")
+	}
+	if len(name) > maxWorkspaceNameLength {
+		return fmt.Errorf("workspace name should be at most %v characters long", maxWorkspaceNameLength)
+	}
+	return nil
+}
+
+// Get membership of user in workspace
+//
+//	If user is not a member of workspace returns 404 (Status Not Found), so others can't find out
+//	which workspaces exist
+//	If member's role is lower than `role` returns 403 (Status Forbidden)
+func checkWorkspaceRole(workspaceID string, username string, role string, member *mongo_handlers.WorkspaceMember) (code int, err error) {
+	code, err = userStore.GetWorkspaceMember(workspaceID, username, member)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return http.StatusNotFound, errWorkspaceNotFound
+		}
+		return code, err
+	}
+	if !mongo_handlers.WorkspaceRoleAtLeast(member.Role, role) {
+		return http.StatusForbidden, fmt.Errorf("only workspace members with role `%s` can perform this request", role)
+	}
+	return http.StatusOK, nil
+}
+
+// Get task from task service if requestor can see it
+//
+//	If task doesn't exist or requestor is not a member of task's workspace returns 404 (Status Not Found).
+//	`member` is filled if task belongs to workspace
+func getVisibleTask(taskID int32, username string, member *mongo_handlers.WorkspaceMember) (*task_servicepb.Task, int, error) {
+	task, err := taskServiceClient.GetTaskById(context.Background(), &task_servicepb.RequestByID{
+		Id:                taskID,
+		RequestorUsername: username,
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, http.StatusNotFound, fmt.Errorf("task with this id doesn't exist: %w", err)
+		}
+		return nil, http.StatusInternalServerError, fmt.Errorf("grpc `GetTaskById` failed with message: %w", err)
+	}
+
+	if workspaceID := task.Task.GetWorkspaceId(); workspaceID != "" {
+		code, err := checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleMember, member)
+		if err != nil {
+			if code == http.StatusNotFound {
+				return nil, http.StatusNotFound, errors.New("task with this id doesn't exist")
+			}
+			return nil, code, err
+		}
+	}
+	return task, http.StatusOK, nil
+}
+
+// Role with which requestor edits task. Admins of task's workspace can edit every task of workspace
+// like moderators
+func taskEditorRole(authInfo AuthInfo, member mongo_handlers.WorkspaceMember) task_servicepb.UserRole {
+	if member.WorkspaceID != "" && mongo_handlers.WorkspaceRoleAtLeast(member.Role, mongo_handlers.WorkspaceRoleAdmin) {
+		return userRoleProto(mongo_handlers.RoleModerator)
+	}
+	return userRoleProto(authInfo.Role)
+}
+
+// Check that requestor is a member of workspace from `workspace_id` query parameter and build query
+// for statistics service. Without parameter statistics of tasks outside of workspaces is requested
+func workspaceStatisticsQuery(r *http.Request, username string) (query string, code int, err error) {
+	workspaceID := r.URL.Query().Get("workspace_id")
+	if workspaceID == "" {
+		return "", http.StatusOK, nil
+	}
+
+	var member mongo_handlers.WorkspaceMember
+	code, err = checkWorkspaceRole(workspaceID, username, mongo_handlers.WorkspaceRoleMember, &member)
+	if err != nil {
+		return "", code, err
+	}
+	return "?workspace_id=" + url.QueryEscape(workspaceID), http.StatusOK, nil
+}
+
+// Link which is sent to users invited by link. Frontend should send token from it to `JoinWorkspace`
+func workspaceInvitationLink(token string) string {
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = defaultPublicURL
+	}
+	return strings.TrimSuffix(publicURL, "/") + "/invitations/join?token=" + url.QueryEscape(token)
+}
+
+// Check that invitation can still be accepted
+//
+//	If invitation is expired returns 410 (Status Gone)
+func checkWorkspaceInvitation(invitation mongo_handlers.WorkspaceInvitation) (code int, err error) {
+	if time.Now().After(invitation.ExpiresAt) {
+		return http.StatusGone, errors.New("invitation has expired")
+	}
+	return http.StatusOK, nil
+}
+
+// Add user to workspace by invitation
+//
+//	If user is already a member returns 409 (Status Conflict)
+func joinWorkspace(invitation mongo_handlers.WorkspaceInvitation, username string) (code int, err error) {
+	code, err = checkWorkspaceInvitation(invitation)
+	if err != nil {
+		return code, err
+	}
+
+	var workspace mongo_handlers.Workspace
+	code, err = userStore.GetWorkspace(invitation.WorkspaceID, &workspace)
+	if err != nil {
+		return code, err
+	}
+
+	return userStore.AddWorkspaceMember(mongo_handlers.WorkspaceMember{
+		WorkspaceID: invitation.WorkspaceID,
+		Username:    username,
+		Role:        invitation.Role,
+		InvitedBy:   invitation.CreatedBy,
+		JoinedAt:    time.Now(),
+	})
+}
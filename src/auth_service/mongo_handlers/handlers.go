@@ -1,22 +1,26 @@
 package mongo_handlers
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/mail"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const (
-	mongoNotFoundErrorMessage = "mongo: no documents in result"
-)
-
 var mongoClient *mongo.Client
 
 func InitMongoClient() error {
@@ -44,9 +48,288 @@ func InitMongoClient() error {
 	}
 
 	fmt.Println("Connection to Mongo is complete!")
+
+	err = migrateUsers()
+	if err != nil {
+		return err
+	}
+
+	err = createIndexes()
+	if err != nil {
+		err = fmt.Errorf("indexes creation error from mongo: %w", err)
+		return err
+	}
 	return nil
 }
 
+func createIndexes() error {
+	users := mongoClient.Database("users_data").Collection("users")
+	_, err := users.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	sessions := mongoClient.Database("users_data").Collection("sessions")
+	_, err = sessions.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "session_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+		{
+			// Session is dropped when its last refresh token expires
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	accessTokens := mongoClient.Database("users_data").Collection("personal_access_tokens")
+	_, err = accessTokens.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	loginAttempts := mongoClient.Database("users_data").Collection("login_attempts")
+	_, err = loginAttempts.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// Counter is forgotten when there were no failures for a long time
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	lockoutEvents := mongoClient.Database("users_data").Collection("lockout_events")
+	_, err = lockoutEvents.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "key", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "locked_at", Value: -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	passwordResets := mongoClient.Database("users_data").Collection("password_resets")
+	_, err = passwordResets.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	twoFactor := mongoClient.Database("users_data").Collection("two_factor")
+	_, err = twoFactor.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	identities := mongoClient.Database("users_data").Collection("external_identities")
+	_, err = identities.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "issuer", Value: 1}, {Key: "subject", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	loginStates := mongoClient.Database("users_data").Collection("oidc_login_states")
+	_, err = loginStates.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "state", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	accountDeletions := mongoClient.Database("users_data").Collection("account_deletions")
+	// Deletions started before `pending` flag was added
+	_, err = accountDeletions.UpdateMany(
+		context.Background(),
+		bson.D{{Key: "completed_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "pending", Value: true}}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = accountDeletions.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+		{
+			// Only one deletion of username can be pending. Partial indexes don't support `$exists: false`,
+			// so pending deletions are marked by flag which is removed when deletion is completed
+			Keys: bson.D{{Key: "username", Value: 1}, {Key: "pending", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "pending", Value: true}}),
+		},
+		{
+			Keys: bson.D{{Key: "completed_at", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	auditLog := mongoClient.Database("users_data").Collection("audit_log")
+	_, err = auditLog.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "actor", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "target", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "action", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	dataExports := mongoClient.Database("users_data").Collection("data_exports")
+	_, err = dataExports.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "export_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			// Mongo removes expired exports by itself, archives are removed by auth service
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	workspaces := mongoClient.Database("users_data").Collection("workspaces")
+	_, err = workspaces.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "workspace_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	workspaceMembers := mongoClient.Database("users_data").Collection("workspace_members")
+	_, err = workspaceMembers.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "workspace_id", Value: 1}, {Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	workspaceInvitations := mongoClient.Database("users_data").Collection("workspace_invitations")
+	_, err = workspaceInvitations.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "invitation_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// Only invitations by link have token
+			Keys: bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+				bson.D{{Key: "token_hash", Value: bson.D{{Key: "$exists", Value: true}}}},
+			),
+		},
+		{
+			Keys: bson.D{{Key: "workspace_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+		{
+			// Mongo removes expired invitations by itself
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	refreshTokens := mongoClient.Database("users_data").Collection("refresh_tokens")
+	_, err = refreshTokens.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "family_id", Value: 1}},
+		},
+		{
+			// Mongo removes expired refresh tokens by itself
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
 func GetMongoClient() *mongo.Client {
 	return mongoClient
 }
@@ -55,134 +338,1763 @@ func CloseMongoClient() {
 	mongoClient.Disconnect(context.Background())
 }
 
-func GetUserToken(username string) (token string, err error) {
-	collection := mongoClient.Database("users_data").Collection("tokens")
-	var tokenStruct bson.M
-	filter := bson.D{{Key: "username", Value: username}}
-	err = collection.FindOne(context.Background(), filter).Decode(&tokenStruct)
-	if err != nil {
-		err = fmt.Errorf("get user's token from mongo failed with message: %w", err)
-		return "", err
-	}
-	mapToConvert := make(map[string]string)
-	ConvertBSONToStruct(tokenStruct, &mapToConvert)
+// Registered user
+//
+//	Field names are the same as in documents which were stored before the typed model
+type User struct {
+	Username string `bson:"username"`
+	// Password hash. Users created by OIDC login have no password
+	Password      string `bson:"password,omitempty"`
+	FirstName     string `bson:"firstName,omitempty"`
+	LastName      string `bson:"lastName,omitempty"`
+	Birthday      string `bson:"birthday,omitempty"`
+	Email         string `bson:"email,omitempty"`
+	EmailVerified bool   `bson:"emailVerified"`
+	PendingEmail  string `bson:"pendingEmail,omitempty"`
+	PhoneNumber   string `bson:"phone,omitempty"`
+	// Users stored without role are ordinary users (see `GetRole`)
+	Role string `bson:"role,omitempty"`
+	// Disabled users can't log in and their tokens are not accepted
+	Disabled       bool       `bson:"disabled,omitempty"`
+	DisabledAt     *time.Time `bson:"disabled_at,omitempty"`
+	DisabledBy     string     `bson:"disabled_by,omitempty"`
+	DisabledReason string     `bson:"disabled_reason,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at"`
+	UpdatedAt      time.Time  `bson:"updated_at"`
+}
 
-	return mapToConvert["token"], nil
+// Roles of users. Every role has all rights of previous ones
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+func (user *User) GetRole() string {
+	if user.Role == "" {
+		return RoleUser
+	}
+	return user.Role
 }
 
-func StoreUserToken(username string, token string) (code int, err error) {
-	collection := mongoClient.Database("users_data").Collection("tokens")
+func ValidateRole(role string) error {
+	if !slices.Contains(Roles, role) {
+		return fmt.Errorf("unknown role `%s`, should be one of: %s", role, strings.Join(Roles, ", "))
+	}
+	return nil
+}
 
-	tokenBSON := ConvertStructToBSON(map[string]string{
-		"username": username,
-		"token":    token,
-	})
+// Changes of user's fields. Only non-nil fields are updated
+type UserUpdate struct {
+	Password      *string
+	FirstName     *string
+	LastName      *string
+	Birthday      *string
+	Email         *string
+	EmailVerified *bool
+	PendingEmail  *string
+	PhoneNumber   *string
+	Role          *string
+	// Disabling sets time of disabling, enabling removes it
+	Disabled       *bool
+	DisabledBy     *string
+	DisabledReason *string
+}
 
-	if CheckIfUserExists(username) {
-		// Replace old token by new one
-		filter := bson.D{{Key: "username", Value: username}}
-		_, err := collection.ReplaceOne(context.Background(), filter, tokenBSON)
-		if err != nil {
-			err = fmt.Errorf("mongo replace old token by new one failed with error: %w", err)
-			return http.StatusInternalServerError, err
+// Check formats of fields:
+//
+//	birthday - date from RFC 3339 (`full-date`, e.g. 2001-02-03)
+//	phone    - E.164 (e.g. +79991234567)
+//	email    - RFC 5322 address without display name (e.g. name@example.com)
+//	role     - one of `Roles`
+func (update *UserUpdate) Validate() error {
+	if update.Birthday != nil && *update.Birthday != "" {
+		if err := ValidateBirthday(*update.Birthday); err != nil {
+			return err
 		}
-	} else {
-		// Insert new user's token
-		_, err := collection.InsertOne(context.Background(), tokenBSON)
-		if err != nil {
-			err = fmt.Errorf("mongo insert new user's token failed with error: %w", err)
-			return http.StatusInternalServerError, err
+	}
+	if update.PhoneNumber != nil && *update.PhoneNumber != "" {
+		if err := ValidatePhoneNumber(*update.PhoneNumber); err != nil {
+			return err
+		}
+	}
+	if update.Role != nil {
+		if err := ValidateRole(*update.Role); err != nil {
+			return err
+		}
+	}
+	for _, email := range []*string{update.Email, update.PendingEmail} {
+		if email != nil && *email != "" {
+			if err := ValidateEmail(*email); err != nil {
+				return err
+			}
 		}
+	}
+	return nil
+}
+
+var phoneNumberRegexp = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+func ValidateBirthday(birthday string) error {
+	date, err := time.Parse(time.DateOnly, birthday)
+	if err != nil {
+		return fmt.Errorf("birthday `%s` should be date in YYYY-MM-DD format", birthday)
+	}
+	if date.After(time.Now()) {
+		return fmt.Errorf("birthday `%s` is in the future", birthday)
+	}
+	return nil
+}
+
+func ValidatePhoneNumber(phone string) error {
+	if !phoneNumberRegexp.MatchString(phone) {
+		return fmt.Errorf("phone number `%s` should be in E.164 format, e.g. +79991234567", phone)
+	}
+	return nil
+}
 
-		// Check if insert is done correctly
-		// !! Comment when not debugging
-		// err = collection.FindOne(context.Background(), tokenBSON).Decode(&tokenBSON)
-		// if err != nil {
-		// 	log.Fatal("function `StoreUserToken`: inserted user is not found ", err)
-		// }
+// Check that email is a single bare address like `name@example.com`
+func ValidateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return fmt.Errorf("`%s` is not a valid email address", email)
 	}
+	return nil
+}
+
+// Insert new user
+//
+//	If user with this username already exists returns 400 (Status Bad Request)
+func CreateUser(user User) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("users")
 
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	_, err = collection.InsertOne(context.Background(), user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return http.StatusBadRequest, errors.New("User with this Username does already exist")
+		}
+		err = fmt.Errorf("mongo insert new user's info failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
 	return http.StatusOK, nil
 }
 
-func StoreUserData(username string, data map[string]string) (code int, err error) {
+// If user is not found returns 404 (Status Not Found)
+func GetUser(username string, user *User) (code int, err error) {
 	collection := mongoClient.Database("users_data").Collection("users")
 
-	// This meta info will be automatically setted by Mongo, so we should delete it here
-	delete(data, "_id")
+	filter := bson.D{{Key: "username", Value: username}}
+	err = collection.FindOne(context.Background(), filter).Decode(user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("user not found in registered users database")
+		}
+		err = fmt.Errorf("get user from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
 
-	newUserDataBSON := ConvertStructToBSON(data)
+// Set changed fields of user. Update time is set automatically
+//
+//	If user is not found returns 404 (Status Not Found)
+func UpdateUser(username string, update UserUpdate) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("users")
 
-	if CheckIfUserExists(username) {
-		// Replace old info by new one
-		filter := bson.D{{Key: "username", Value: username}}
-		_, err := collection.ReplaceOne(context.Background(), filter, newUserDataBSON)
-		if err != nil {
-			err = fmt.Errorf("mongo replace old info by new one failed with error: %w", err)
-			return http.StatusInternalServerError, err
+	fields := bson.D{{Key: "updated_at", Value: time.Now()}}
+	unset := bson.D{}
+	setOrUnset := func(key string, value *string) {
+		if value == nil {
+			return
 		}
-	} else {
-		// Insert new user's info
-		_, err := collection.InsertOne(context.Background(), newUserDataBSON)
-		if err != nil {
-			err = fmt.Errorf("mongo insert new user's info failed with error: %w", err)
-			return http.StatusInternalServerError, err
+		if *value == "" {
+			unset = append(unset, bson.E{Key: key, Value: ""})
+		} else {
+			fields = append(fields, bson.E{Key: key, Value: *value})
+		}
+	}
+	setOrUnset("password", update.Password)
+	setOrUnset("firstName", update.FirstName)
+	setOrUnset("lastName", update.LastName)
+	setOrUnset("birthday", update.Birthday)
+	setOrUnset("email", update.Email)
+	setOrUnset("pendingEmail", update.PendingEmail)
+	setOrUnset("phone", update.PhoneNumber)
+	setOrUnset("role", update.Role)
+	setOrUnset("disabled_by", update.DisabledBy)
+	setOrUnset("disabled_reason", update.DisabledReason)
+	if update.EmailVerified != nil {
+		fields = append(fields, bson.E{Key: "emailVerified", Value: *update.EmailVerified})
+	}
+	if update.Disabled != nil {
+		if *update.Disabled {
+			fields = append(fields, bson.E{Key: "disabled", Value: true}, bson.E{Key: "disabled_at", Value: time.Now()})
+		} else {
+			unset = append(unset, bson.E{Key: "disabled", Value: ""}, bson.E{Key: "disabled_at", Value: ""})
 		}
+	}
+
+	changes := bson.D{{Key: "$set", Value: fields}}
+	if len(unset) > 0 {
+		changes = append(changes, bson.E{Key: "$unset", Value: unset})
+	}
 
-		// Check if insert is done correctly
-		// !! Comment when not debugging
-		// err = collection.FindOne(context.Background(), newUserDataBSON).Decode(&newUserDataBSON)
-		// if err != nil {
-		// 	log.Fatal("function `StoreUserData`: inserted user is not found ", err)
-		// }
+	filter := bson.D{{Key: "username", Value: username}}
+	result, err := collection.UpdateOne(context.Background(), filter, changes)
+	if err != nil {
+		err = fmt.Errorf("mongo update user's info failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.MatchedCount == 0 {
+		return http.StatusNotFound, errors.New("user not found in registered users database")
 	}
 	return http.StatusOK, nil
 }
 
-func GetUserData(username string, mapToLoad *map[string]string) (code int, err error) {
+// Page of users found by `SearchUsers`
+type UserPage struct {
+	Users []User
+	// Number of found users on all pages
+	Total int64
+}
+
+// Find users whose username, name or email contains `query` (case insensitive), sorted by username.
+// Empty query matches every user
+func SearchUsers(query string, offset int64, limit int64, page *UserPage) (code int, err error) {
 	collection := mongoClient.Database("users_data").Collection("users")
 
-	var userInformation bson.M
-	filter := bson.D{{Key: "username", Value: username}}
-	err = collection.FindOne(context.Background(), filter).Decode(&userInformation)
-	// If `FindOne` finished incorrectly then user is not found
+	filter := bson.D{}
+	if query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
+		filter = bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "username", Value: pattern}},
+			bson.D{{Key: "firstName", Value: pattern}},
+			bson.D{{Key: "lastName", Value: pattern}},
+			bson.D{{Key: "email", Value: pattern}},
+		}}}
+	}
+
+	page.Total, err = collection.CountDocuments(context.Background(), filter)
 	if err != nil {
-		if err.Error() != mongoNotFoundErrorMessage {
-			log.Println("function `GetUserData`, method `FindOne` returned unexpected error: ", err.Error(), ". service will think that user is not found")
-		}
-		return http.StatusUnauthorized, errors.New("user not found in registered users database")
+		err = fmt.Errorf("count users in mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "username", Value: 1}}).SetSkip(offset).SetLimit(limit)
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("search users in mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
 	}
 
-	ConvertBSONToStruct(userInformation, mapToLoad)
+	page.Users = []User{}
+	err = cursor.All(context.Background(), &page.Users)
+	if err != nil {
+		err = fmt.Errorf("decoding found users failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
 	return http.StatusOK, nil
 }
 
 func CheckIfUserExists(username string) bool {
-	collection := mongoClient.Database("users_data").Collection("tokens")
-	var userToken bson.M
+	collection := mongoClient.Database("users_data").Collection("users")
+
 	filter := bson.D{{Key: "username", Value: username}}
-	err := collection.FindOne(context.Background(), filter).Decode(&userToken)
-	// If `FindOne` finished incorrectly then user is not found
+	count, err := collection.CountDocuments(context.Background(), filter, options.Count().SetLimit(1))
+	if err != nil {
+		log.Println("function `CheckIfUserExists`, method `CountDocuments` returned unexpected error: ", err.Error(), ". service will think that user is not found")
+	}
+	return count > 0
+}
+
+// Bring documents stored before the typed model to its format
+func migrateUsers() error {
+	collection := mongoClient.Database("users_data").Collection("users")
+
+	// `emailVerified` was stored as string
+	filter := bson.D{{Key: "emailVerified", Value: "true"}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "emailVerified", Value: true}}}}
+	_, err := collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return fmt.Errorf("mongo migration of `emailVerified` failed with error: %w", err)
+	}
+
+	filter = bson.D{{Key: "emailVerified", Value: bson.D{{Key: "$type", Value: "string"}}}}
+	update = bson.D{{Key: "$set", Value: bson.D{{Key: "emailVerified", Value: false}}}}
+	_, err = collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return fmt.Errorf("mongo migration of `emailVerified` failed with error: %w", err)
+	}
+	return nil
+}
+
+// User's session on one device. Every login creates a new session
+type Session struct {
+	SessionID  string    `bson:"session_id"`
+	Username   string    `bson:"username"`
+	UserAgent  string    `bson:"user_agent"`
+	IP         string    `bson:"ip"`
+	CreatedAt  time.Time `bson:"created_at"`
+	LastSeenAt time.Time `bson:"last_seen_at"`
+	ExpiresAt  time.Time `bson:"expires_at"`
+	Revoked    bool      `bson:"revoked"`
+}
+
+func CreateSession(session Session) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("sessions")
+
+	_, err = collection.InsertOne(context.Background(), session)
+	if err != nil {
+		err = fmt.Errorf("mongo insert new session failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func GetSession(sessionID string, session *Session) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("sessions")
+
+	filter := bson.D{{Key: "session_id", Value: sessionID}}
+	err = collection.FindOne(context.Background(), filter).Decode(session)
 	if err != nil {
-		if err.Error() != mongoNotFoundErrorMessage {
-			log.Println("function `CheckIfUserExists`, method `FindOne` returned unexpected error: ", err.Error(), ". service will think that user is not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusUnauthorized, errors.New("session not found")
 		}
+		err = fmt.Errorf("get session from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
 	}
-	return err == nil
+	return http.StatusOK, nil
+}
+
+// Get all active (not revoked) sessions of user
+func GetUserSessions(username string, sessions *[]Session) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("sessions")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "revoked", Value: false},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get user's sessions from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	err = cursor.All(context.Background(), sessions)
+	if err != nil {
+		err = fmt.Errorf("decoding user's sessions failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Update time of the last session's activity
+func TouchSession(sessionID string, lastSeenAt time.Time) error {
+	collection := mongoClient.Database("users_data").Collection("sessions")
+
+	filter := bson.D{{Key: "session_id", Value: sessionID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_seen_at", Value: lastSeenAt}}}}
+	_, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo update session's last activity failed with error: %w", err)
+	}
+	return err
+}
+
+// Move session's expiration time. Called when new refresh token is issued
+func ExtendSession(sessionID string, expiresAt time.Time) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("sessions")
+
+	filter := bson.D{{Key: "session_id", Value: sessionID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "expires_at", Value: expiresAt}}}}
+	_, err = collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo update session's expiration time failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Revoke user's session
+//
+//	If session doesn't exist, belongs to another user or is already revoked returns 404 (Status Not Found)
+func RevokeSession(username string, sessionID string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("sessions")
+
+	filter := bson.D{
+		{Key: "session_id", Value: sessionID},
+		{Key: "username", Value: username},
+		{Key: "revoked", Value: false},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked", Value: true}}}}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo revoke session failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.MatchedCount == 0 {
+		return http.StatusNotFound, errors.New("active session with this id is not found")
+	}
+	return http.StatusOK, nil
+}
+
+// Revoke all user's sessions except `exceptSessionID` (empty `exceptSessionID` revokes every session)
+func RevokeUserSessions(username string, exceptSessionID string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("sessions")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "revoked", Value: false},
+		{Key: "session_id", Value: bson.D{{Key: "$ne", Value: exceptSessionID}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked", Value: true}}}}
+	_, err = collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo revoke user's sessions failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Refresh token as it is stored in Mongo. Token itself is never stored, only its hash
+type RefreshToken struct {
+	TokenHash string    `bson:"token_hash"`
+	FamilyID  string    `bson:"family_id"`
+	Username  string    `bson:"username"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	Used      bool      `bson:"used"`
+	Revoked   bool      `bson:"revoked"`
+}
+
+func StoreRefreshToken(token RefreshToken) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("refresh_tokens")
+
+	_, err = collection.InsertOne(context.Background(), token)
+	if err != nil {
+		err = fmt.Errorf("mongo insert new refresh token failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func GetRefreshToken(tokenHash string, token *RefreshToken) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("refresh_tokens")
+
+	filter := bson.D{{Key: "token_hash", Value: tokenHash}}
+	err = collection.FindOne(context.Background(), filter).Decode(token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusUnauthorized, errors.New("refresh token not found")
+		}
+		err = fmt.Errorf("get refresh token from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Mark refresh token as used so it can't be exchanged again
+//
+//	Returns false if token was already used or revoked (possibly by concurrent request)
+func MarkRefreshTokenUsed(tokenHash string) (marked bool, err error) {
+	collection := mongoClient.Database("users_data").Collection("refresh_tokens")
+
+	filter := bson.D{
+		{Key: "token_hash", Value: tokenHash},
+		{Key: "used", Value: false},
+		{Key: "revoked", Value: false},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "used", Value: true}}}}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo mark refresh token as used failed with error: %w", err)
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
 }
 
-func ConvertStructToBSON(data map[string]string) bson.M {
-	bsonData := bson.M{}
-	for key, value := range data {
-		bsonData[key] = value
+// Revoke every refresh token which was issued in the same chain of rotations
+func RevokeRefreshTokenFamily(familyID string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("refresh_tokens")
+
+	filter := bson.D{{Key: "family_id", Value: familyID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked", Value: true}}}}
+	_, err = collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo revoke refresh token family failed with error: %w", err)
+		return http.StatusInternalServerError, err
 	}
-	return bsonData
+	return http.StatusOK, nil
 }
 
-func ConvertBSONToStruct(bsonData bson.M, toSaveMap *map[string]string) {
-	for key, value := range bsonData {
-		strKey := fmt.Sprintf("%v", key)
-		strValue := fmt.Sprintf("%v", value)
-		(*toSaveMap)[strKey] = strValue
+// Revoke all user's refresh tokens except tokens of `exceptFamilyID` family (empty `exceptFamilyID` revokes every token)
+func RevokeUserRefreshTokens(username string, exceptFamilyID string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("refresh_tokens")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "family_id", Value: bson.D{{Key: "$ne", Value: exceptFamilyID}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked", Value: true}}}}
+	_, err = collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo revoke user's refresh tokens failed with error: %w", err)
+		return http.StatusInternalServerError, err
 	}
+	return http.StatusOK, nil
+}
+
+// Personal access token for scripts. Token itself is never stored, only its hash
+type PersonalAccessToken struct {
+	TokenID    string     `bson:"token_id"`
+	Username   string     `bson:"username"`
+	Name       string     `bson:"name"`
+	Scopes     []string   `bson:"scopes"`
+	TokenHash  string     `bson:"token_hash"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	LastUsedAt time.Time  `bson:"last_used_at"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty"`
+	Revoked    bool       `bson:"revoked"`
+}
+
+func StorePersonalAccessToken(token PersonalAccessToken) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("personal_access_tokens")
+
+	_, err = collection.InsertOne(context.Background(), token)
+	if err != nil {
+		err = fmt.Errorf("mongo insert new personal access token failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func GetPersonalAccessTokenByHash(tokenHash string, token *PersonalAccessToken) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("personal_access_tokens")
+
+	filter := bson.D{{Key: "token_hash", Value: tokenHash}}
+	err = collection.FindOne(context.Background(), filter).Decode(token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusUnauthorized, errors.New("personal access token not found")
+		}
+		err = fmt.Errorf("get personal access token from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Get all not revoked personal access tokens of user
+func GetUserPersonalAccessTokens(username string, tokens *[]PersonalAccessToken) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("personal_access_tokens")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "revoked", Value: false},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get user's personal access tokens from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	err = cursor.All(context.Background(), tokens)
+	if err != nil {
+		err = fmt.Errorf("decoding user's personal access tokens failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Update time of the last token's usage
+func TouchPersonalAccessToken(tokenID string, lastUsedAt time.Time) error {
+	collection := mongoClient.Database("users_data").Collection("personal_access_tokens")
+
+	filter := bson.D{{Key: "token_id", Value: tokenID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: lastUsedAt}}}}
+	_, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo update personal access token's last usage failed with error: %w", err)
+	}
+	return err
+}
+
+// Revoke user's personal access token
+//
+//	If token doesn't exist, belongs to another user or is already revoked returns 404 (Status Not Found)
+func RevokePersonalAccessToken(username string, tokenID string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("personal_access_tokens")
+
+	filter := bson.D{
+		{Key: "token_id", Value: tokenID},
+		{Key: "username", Value: username},
+		{Key: "revoked", Value: false},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked", Value: true}}}}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo revoke personal access token failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.MatchedCount == 0 {
+		return http.StatusNotFound, errors.New("personal access token with this id is not found")
+	}
+	return http.StatusOK, nil
+}
+
+// Account in external identity provider (OIDC) linked to user
+type ExternalIdentity struct {
+	Issuer   string    `bson:"issuer"`
+	Subject  string    `bson:"subject"`
+	Username string    `bson:"username"`
+	Email    string    `bson:"email,omitempty"`
+	LinkedAt time.Time `bson:"linked_at"`
+}
+
+func GetExternalIdentity(issuer string, subject string, identity *ExternalIdentity) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("external_identities")
+
+	filter := bson.D{
+		{Key: "issuer", Value: issuer},
+		{Key: "subject", Value: subject},
+	}
+	err = collection.FindOne(context.Background(), filter).Decode(identity)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("external identity is not linked to any user")
+		}
+		err = fmt.Errorf("get external identity from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Link external identity to user
+//
+//	If identity is already linked to some user returns 409 (Status Conflict)
+func StoreExternalIdentity(identity ExternalIdentity) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("external_identities")
+
+	_, err = collection.InsertOne(context.Background(), identity)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return http.StatusConflict, errors.New("external identity is already linked to a user")
+		}
+		err = fmt.Errorf("mongo insert external identity failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// State of OIDC login which was started but not finished yet
+type OIDCLoginState struct {
+	State        string `bson:"state"`
+	Nonce        string `bson:"nonce"`
+	CodeVerifier string `bson:"code_verifier"`
+	// Set if user is linking external identity to existing account
+	LinkUsername string    `bson:"link_username,omitempty"`
+	ExpiresAt    time.Time `bson:"expires_at"`
+}
+
+func StoreOIDCLoginState(state OIDCLoginState) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("oidc_login_states")
+
+	_, err = collection.InsertOne(context.Background(), state)
+	if err != nil {
+		err = fmt.Errorf("mongo insert OIDC login state failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Get and delete OIDC login state, so it can be used only once
+func PopOIDCLoginState(state string, loginState *OIDCLoginState) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("oidc_login_states")
+
+	filter := bson.D{{Key: "state", Value: state}}
+	err = collection.FindOneAndDelete(context.Background(), filter).Decode(loginState)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusBadRequest, errors.New("unknown or already used OIDC login state")
+		}
+		err = fmt.Errorf("get OIDC login state from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if time.Now().After(loginState.ExpiresAt) {
+		return http.StatusBadRequest, errors.New("OIDC login has expired, try again")
+	}
+	return http.StatusOK, nil
+}
+
+// TOTP two-factor authentication settings of user
+type TwoFactor struct {
+	Username string `bson:"username"`
+	Secret   string `bson:"secret"`
+	// False until user confirms enrollment by the first code
+	Enabled            bool     `bson:"enabled"`
+	RecoveryCodeHashes []string `bson:"recovery_code_hashes"`
+	// Time step of the last accepted code, codes of this and previous steps can't be used again
+	LastUsedStep int64 `bson:"last_used_step"`
+	// Consecutive wrong codes, second step of login is blocked until `LockedUntil` when there are too many of them
+	FailedAttempts int       `bson:"failed_attempts"`
+	LockedUntil    time.Time `bson:"locked_until"`
+	CreatedAt      time.Time `bson:"created_at"`
+	EnabledAt      time.Time `bson:"enabled_at"`
+}
+
+func GetTwoFactor(username string, twoFactor *TwoFactor) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("two_factor")
+
+	filter := bson.D{{Key: "username", Value: username}}
+	err = collection.FindOne(context.Background(), filter).Decode(twoFactor)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("two-factor authentication is not set up")
+		}
+		err = fmt.Errorf("get two-factor settings from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Insert or replace user's two-factor settings
+func StoreTwoFactor(twoFactor TwoFactor) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("two_factor")
+
+	filter := bson.D{{Key: "username", Value: twoFactor.Username}}
+	_, err = collection.ReplaceOne(context.Background(), filter, twoFactor, options.Replace().SetUpsert(true))
+	if err != nil {
+		err = fmt.Errorf("mongo store two-factor settings failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func DeleteTwoFactor(username string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("two_factor")
+
+	filter := bson.D{{Key: "username", Value: username}}
+	_, err = collection.DeleteOne(context.Background(), filter)
+	if err != nil {
+		err = fmt.Errorf("mongo delete two-factor settings failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Atomically remember that code of time step `step` was used
+//
+//	Returns false if code of this or later step has already been used
+func UseTOTPStep(username string, step int64) (bool, error) {
+	collection := mongoClient.Database("users_data").Collection("two_factor")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "last_used_step", Value: bson.D{{Key: "$lt", Value: step}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "last_used_step", Value: step},
+		{Key: "failed_attempts", Value: 0},
+	}}}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return false, fmt.Errorf("mongo update TOTP step failed with error: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Atomically remove recovery code, so it can be used only once
+//
+//	Returns false if user has no such recovery code
+func UseRecoveryCode(username string, codeHash string) (bool, error) {
+	collection := mongoClient.Database("users_data").Collection("two_factor")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "recovery_code_hashes", Value: codeHash},
+	}
+	update := bson.D{
+		{Key: "$pull", Value: bson.D{{Key: "recovery_code_hashes", Value: codeHash}}},
+		{Key: "$set", Value: bson.D{{Key: "failed_attempts", Value: 0}}},
+	}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return false, fmt.Errorf("mongo update recovery codes failed with error: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Count wrong two-factor code. After `maxAttempts` wrong codes in a row codes are not accepted for `lockDuration`
+func RecordTwoFactorFailure(username string, maxAttempts int, lockDuration time.Duration) error {
+	collection := mongoClient.Database("users_data").Collection("two_factor")
+
+	var twoFactor TwoFactor
+	filter := bson.D{{Key: "username", Value: username}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "failed_attempts", Value: 1}}}}
+	err := collection.FindOneAndUpdate(context.Background(), filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&twoFactor)
+	if err != nil {
+		return fmt.Errorf("mongo update two-factor failed attempts failed with error: %w", err)
+	}
+
+	if twoFactor.FailedAttempts >= maxAttempts {
+		update = bson.D{{Key: "$set", Value: bson.D{
+			{Key: "failed_attempts", Value: 0},
+			{Key: "locked_until", Value: time.Now().Add(lockDuration)},
+		}}}
+		if _, err := collection.UpdateOne(context.Background(), filter, update); err != nil {
+			return fmt.Errorf("mongo lock two-factor failed with error: %w", err)
+		}
+	}
+	return nil
+}
+
+// Failed login attempts counter. Key is "user:<username>" or "ip:<address>"
+type LoginAttempts struct {
+	Key           string    `bson:"key"`
+	Failures      int       `bson:"failures"`
+	LastFailureAt time.Time `bson:"last_failure_at"`
+	LockedUntil   time.Time `bson:"locked_until"`
+	ExpiresAt     time.Time `bson:"expires_at"`
+}
+
+func GetLoginAttempts(key string, attempts *LoginAttempts) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("login_attempts")
+
+	filter := bson.D{{Key: "key", Value: key}}
+	err = collection.FindOne(context.Background(), filter).Decode(attempts)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("no failed login attempts")
+		}
+		err = fmt.Errorf("get login attempts from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Atomically increment failures counter and return updated counter
+//
+//	Counter is deleted if there are no new failures during `ttl`
+func RecordLoginFailure(key string, now time.Time, ttl time.Duration, attempts *LoginAttempts) error {
+	collection := mongoClient.Database("users_data").Collection("login_attempts")
+
+	filter := bson.D{{Key: "key", Value: key}}
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "failures", Value: 1}}},
+		{Key: "$set", Value: bson.D{
+			{Key: "last_failure_at", Value: now},
+			{Key: "expires_at", Value: now.Add(ttl)},
+		}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(attempts)
+	if err != nil {
+		return fmt.Errorf("mongo update login attempts failed with error: %w", err)
+	}
+	return nil
+}
+
+func LockLogin(key string, lockedUntil time.Time) error {
+	collection := mongoClient.Database("users_data").Collection("login_attempts")
+
+	filter := bson.D{{Key: "key", Value: key}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "locked_until", Value: lockedUntil}}}}
+	_, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return fmt.Errorf("mongo lock login failed with error: %w", err)
+	}
+	return nil
+}
+
+// Forget failed login attempts (after successful login or when admin clears lockout)
+func ResetLoginAttempts(key string) error {
+	collection := mongoClient.Database("users_data").Collection("login_attempts")
+
+	filter := bson.D{{Key: "key", Value: key}}
+	_, err := collection.DeleteOne(context.Background(), filter)
+	if err != nil {
+		return fmt.Errorf("mongo delete login attempts failed with error: %w", err)
+	}
+	return nil
+}
+
+// Record about temporary lockout of account or IP address
+type LockoutEvent struct {
+	ID          string    `bson:"event_id"`
+	Key         string    `bson:"key"`
+	Failures    int       `bson:"failures"`
+	IP          string    `bson:"ip"`
+	LockedAt    time.Time `bson:"locked_at"`
+	LockedUntil time.Time `bson:"locked_until"`
+	// Set if lockout was cleared by admin
+	ClearedBy string     `bson:"cleared_by,omitempty"`
+	ClearedAt *time.Time `bson:"cleared_at,omitempty"`
+}
+
+func StoreLockoutEvent(event LockoutEvent) error {
+	collection := mongoClient.Database("users_data").Collection("lockout_events")
+
+	_, err := collection.InsertOne(context.Background(), event)
+	if err != nil {
+		return fmt.Errorf("mongo insert lockout event failed with error: %w", err)
+	}
+	return nil
+}
+
+// Get the latest lockout events. If `activeOnly` is set, only lockouts which are not over yet are returned
+func GetLockoutEvents(activeOnly bool, offset int64, limit int64, events *[]LockoutEvent) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("lockout_events")
+
+	filter := bson.D{}
+	if activeOnly {
+		filter = bson.D{
+			{Key: "locked_until", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+			{Key: "cleared_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "locked_at", Value: -1}}).SetSkip(offset).SetLimit(limit)
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get lockout events from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	err = cursor.All(context.Background(), events)
+	if err != nil {
+		err = fmt.Errorf("decoding lockout events failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Unlock account or IP address and mark its lockout events as cleared
+func ClearLockout(key string, clearedBy string) (code int, err error) {
+	if err := ResetLoginAttempts(key); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	collection := mongoClient.Database("users_data").Collection("lockout_events")
+
+	now := time.Now()
+	filter := bson.D{
+		{Key: "key", Value: key},
+		{Key: "cleared_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "cleared_by", Value: clearedBy},
+		{Key: "cleared_at", Value: now},
+	}}}
+	_, err = collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo update lockout events failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Single-use password reset token. Token itself is never stored, only its hash
+type PasswordReset struct {
+	TokenHash string    `bson:"token_hash"`
+	Username  string    `bson:"username"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Store new reset token. Previous reset tokens of the user are dropped
+func StorePasswordReset(reset PasswordReset) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("password_resets")
+
+	filter := bson.D{{Key: "username", Value: reset.Username}}
+	_, err = collection.DeleteMany(context.Background(), filter)
+	if err != nil {
+		err = fmt.Errorf("mongo delete old password resets failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	_, err = collection.InsertOne(context.Background(), reset)
+	if err != nil {
+		err = fmt.Errorf("mongo insert password reset failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Atomically get and delete reset token, so it can be used only once
+//
+//	If token is unknown, already used or expired returns 400 (Status Bad Request)
+func PopPasswordReset(tokenHash string, reset *PasswordReset) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("password_resets")
+
+	filter := bson.D{
+		{Key: "token_hash", Value: tokenHash},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+	err = collection.FindOneAndDelete(context.Background(), filter).Decode(reset)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusBadRequest, errors.New("password reset token is invalid or has expired")
+		}
+		err = fmt.Errorf("get password reset from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Delete user and data of his account (including his workspace memberships and invitations).
+// Deleting of already deleted user is not an error
+func DeleteUser(username string) (code int, err error) {
+	filter := bson.D{{Key: "username", Value: username}}
+	for _, name := range []string{"users", "two_factor", "external_identities", "password_resets", "data_exports", "workspace_members", "workspace_invitations"} {
+		collection := mongoClient.Database("users_data").Collection(name)
+		_, err = collection.DeleteMany(context.Background(), filter)
+		if err != nil {
+			err = fmt.Errorf("mongo delete user's data from `%s` failed with error: %w", name, err)
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Delete all sessions, refresh tokens and personal access tokens of user
+func DeleteUserSessions(username string) (code int, err error) {
+	filter := bson.D{{Key: "username", Value: username}}
+	for _, name := range []string{"sessions", "refresh_tokens", "personal_access_tokens"} {
+		collection := mongoClient.Database("users_data").Collection(name)
+		_, err = collection.DeleteMany(context.Background(), filter)
+		if err != nil {
+			err = fmt.Errorf("mongo delete user's data from `%s` failed with error: %w", name, err)
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Deletion of user's account. Data in other services is deleted asynchronously,
+// so deletion is kept as pending until every step is done
+type AccountDeletion struct {
+	Username    string    `bson:"username"`
+	RequestedAt time.Time `bson:"requested_at"`
+	// Done steps
+	LocalDataDeleted   bool `bson:"local_data_deleted"`
+	TasksDeleted       bool `bson:"tasks_deleted"`
+	StatisticsNotified bool `bson:"statistics_notified"`
+	// Workspaces owned by user which had no other members, their tasks are deleted too
+	DeletedWorkspaceIDs []string `bson:"deleted_workspace_ids,omitempty"`
+	// Tasks deleted by task service, statistics service deletes their statistics
+	DeletedTaskIDs []int32 `bson:"deleted_task_ids,omitempty"`
+	// Deletion is processed only by replica which claimed it by `ClaimAccountDeletion`.
+	// Claim is released when `LockedUntil` is nil or has passed
+	LockID      string     `bson:"lock_id,omitempty"`
+	LockedUntil *time.Time `bson:"locked_until,omitempty"`
+	// Failed attempts of finishing deletion
+	Attempts    int        `bson:"attempts"`
+	LastError   string     `bson:"last_error,omitempty"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty"`
+}
+
+// Account deletion as it's stored in Mongo
+type storedAccountDeletion struct {
+	AccountDeletion `bson:",inline"`
+	// Set while deletion is not completed, unique index on username uses it
+	Pending bool `bson:"pending,omitempty"`
+}
+
+// Start deletion of user's account. If deletion is already pending, it's kept as is
+func StartAccountDeletion(username string, requestedAt time.Time, deletion *AccountDeletion) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("account_deletions")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "completed_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	update := bson.D{{Key: "$setOnInsert", Value: bson.D{
+		{Key: "requested_at", Value: requestedAt},
+		{Key: "local_data_deleted", Value: false},
+		{Key: "tasks_deleted", Value: false},
+		{Key: "statistics_notified", Value: false},
+		{Key: "attempts", Value: 0},
+		{Key: "pending", Value: true},
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(deletion)
+	if mongo.IsDuplicateKeyError(err) {
+		// Concurrent request has just started deletion, so it's found now
+		err = collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(deletion)
+	}
+	if err != nil {
+		err = fmt.Errorf("mongo start account deletion failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If there is no pending deletion of user's account returns 404 (Status Not Found)
+func GetPendingAccountDeletion(username string, deletion *AccountDeletion) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("account_deletions")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "completed_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	err = collection.FindOne(context.Background(), filter).Decode(deletion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("account deletion is not pending")
+		}
+		err = fmt.Errorf("get account deletion from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Get all not finished account deletions, the oldest first
+func GetPendingAccountDeletions(deletions *[]AccountDeletion) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("account_deletions")
+
+	filter := bson.D{{Key: "completed_at", Value: bson.D{{Key: "$exists", Value: false}}}}
+	opts := options.Find().SetSort(bson.D{{Key: "requested_at", Value: 1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get pending account deletions from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	err = cursor.All(context.Background(), deletions)
+	if err != nil {
+		err = fmt.Errorf("decoding pending account deletions failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Claim pending deletion of user's account for `lockID` until `lockedUntil`, so other replicas don't process it at the same time
+//
+//	If there is no pending deletion or it's claimed by someone else returns 404 (Status Not Found)
+func ClaimAccountDeletion(username string, lockID string, lockedUntil time.Time, deletion *AccountDeletion) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("account_deletions")
+
+	filter := bson.D{
+		{Key: "username", Value: username},
+		{Key: "completed_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		// Missing, released or expired claim
+		{Key: "locked_until", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gt", Value: time.Now()}}}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "lock_id", Value: lockID},
+		{Key: "locked_until", Value: lockedUntil},
+	}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(deletion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("account deletion is not pending or is claimed by another replica")
+		}
+		err = fmt.Errorf("mongo claim account deletion failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Save progress of pending account deletion claimed by `deletion.LockID`
+//
+//	If claim was taken over by another replica (e.g. after it expired) returns 409 (Status Conflict)
+func UpdateAccountDeletion(deletion AccountDeletion) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("account_deletions")
+
+	filter := bson.D{
+		{Key: "username", Value: deletion.Username},
+		{Key: "completed_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "lock_id", Value: deletion.LockID},
+	}
+	stored := storedAccountDeletion{AccountDeletion: deletion, Pending: deletion.CompletedAt == nil}
+	result, err := collection.ReplaceOne(context.Background(), filter, stored)
+	if err != nil {
+		err = fmt.Errorf("mongo update account deletion failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.MatchedCount == 0 {
+		return http.StatusConflict, errors.New("account deletion is claimed by another replica")
+	}
+	return http.StatusOK, nil
+}
+
+// Export of everything what is stored about user. Archive with exported data is kept in GridFS bucket
+// `data_export_archives` under the same ID, this document keeps only status of the export
+type DataExport struct {
+	ExportID   string     `bson:"export_id"`
+	Username   string     `bson:"username"`
+	Status     string     `bson:"status"`
+	Error      string     `bson:"error,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty"`
+	// Export and its archive are deleted after this time
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func CreateDataExport(export DataExport) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("data_exports")
+
+	_, err = collection.InsertOne(context.Background(), export)
+	if err != nil {
+		err = fmt.Errorf("mongo insert data export failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If export doesn't exist or belongs to another user returns 404 (Status Not Found)
+func GetDataExport(username string, exportID string, export *DataExport) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("data_exports")
+
+	filter := bson.D{
+		{Key: "export_id", Value: exportID},
+		{Key: "username", Value: username},
+	}
+	err = collection.FindOne(context.Background(), filter).Decode(export)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("data export not found")
+		}
+		err = fmt.Errorf("get data export from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Get not expired exports of user, the newest first
+func GetUserDataExports(username string, exports *[]DataExport) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("data_exports")
+
+	filter := bson.D{{Key: "username", Value: username}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get user's data exports from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	err = cursor.All(context.Background(), exports)
+	if err != nil {
+		err = fmt.Errorf("decoding user's data exports failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Save status of export
+func UpdateDataExport(export DataExport) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("data_exports")
+
+	filter := bson.D{{Key: "export_id", Value: export.ExportID}}
+	_, err = collection.ReplaceOne(context.Background(), filter, export)
+	if err != nil {
+		err = fmt.Errorf("mongo update data export failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// GridFS bucket with archives of exports, it's shared by every replica of auth service
+func dataExportArchives() (*gridfs.Bucket, error) {
+	opts := options.GridFSBucket().SetName("data_export_archives")
+	bucket, err := gridfs.NewBucket(mongoClient.Database("users_data"), opts)
+	if err != nil {
+		return nil, fmt.Errorf("opening GridFS bucket of export archives failed with error: %w", err)
+	}
+	return bucket, nil
+}
+
+// Save archive of export. File document is written after all chunks, so incomplete archive is never read
+func StoreDataExportArchive(exportID string, archive []byte) (code int, err error) {
+	bucket, err := dataExportArchives()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	err = bucket.UploadFromStreamWithID(exportID, exportID+".zip", bytes.NewReader(archive))
+	if err != nil {
+		err = fmt.Errorf("mongo upload export archive failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If archive doesn't exist returns 404 (Status Not Found)
+func GetDataExportArchive(exportID string, archive *[]byte) (code int, err error) {
+	bucket, err := dataExportArchives()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	var buffer bytes.Buffer
+	_, err = bucket.DownloadToStream(exportID, &buffer)
+	if err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return http.StatusNotFound, errors.New("export archive not found")
+		}
+		err = fmt.Errorf("mongo download export archive failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	*archive = buffer.Bytes()
+	return http.StatusOK, nil
+}
+
+// Delete archive of export. Missing archive is not an error
+func DeleteDataExportArchive(exportID string) (code int, err error) {
+	bucket, err := dataExportArchives()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	err = bucket.Delete(exportID)
+	if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		err = fmt.Errorf("mongo delete export archive failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Delete archives which were uploaded before `before`
+func DeleteExpiredDataExportArchives(before time.Time) (code int, err error) {
+	bucket, err := dataExportArchives()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	cursor, err := bucket.Find(bson.D{{Key: "uploadDate", Value: bson.D{{Key: "$lt", Value: before}}}})
+	if err != nil {
+		err = fmt.Errorf("get expired export archives from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	var files []gridfs.File
+	err = cursor.All(context.Background(), &files)
+	if err != nil {
+		err = fmt.Errorf("decoding expired export archives failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	for _, file := range files {
+		err = bucket.Delete(file.ID)
+		if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			err = fmt.Errorf("mongo delete export archive failed with error: %w", err)
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Record of audit log. Records are only appended and never changed
+type AuditEvent struct {
+	ID string `bson:"event_id"`
+	// Username of user who performed action. Empty if requestor is not authenticated
+	Actor  string `bson:"actor,omitempty"`
+	Action string `bson:"action"`
+	// Username (or other identifier) of object of action
+	Target    string `bson:"target,omitempty"`
+	IP        string `bson:"ip"`
+	UserAgent string `bson:"user_agent"`
+	Outcome   string `bson:"outcome"`
+	// Additional information (e.g. reason of failure or new role)
+	Details   string    `bson:"details,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func StoreAuditEvent(event AuditEvent) error {
+	collection := mongoClient.Database("users_data").Collection("audit_log")
+
+	_, err := collection.InsertOne(context.Background(), event)
+	if err != nil {
+		return fmt.Errorf("mongo insert audit event failed with error: %w", err)
+	}
+	return nil
+}
+
+// Filter of audit log. Empty fields match every event
+type AuditEventFilter struct {
+	Actor   string
+	Action  string
+	Target  string
+	IP      string
+	Outcome string
+	// Time range [From, To)
+	From *time.Time
+	To   *time.Time
+}
+
+// Get events of audit log matching `filter`, the latest first
+func GetAuditEvents(filter AuditEventFilter, offset int64, limit int64, events *[]AuditEvent) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("audit_log")
+
+	query := bson.D{}
+	for _, field := range []struct {
+		key   string
+		value string
+	}{
+		{"actor", filter.Actor},
+		{"action", filter.Action},
+		{"target", filter.Target},
+		{"ip", filter.IP},
+		{"outcome", filter.Outcome},
+	} {
+		if field.value != "" {
+			query = append(query, bson.E{Key: field.key, Value: field.value})
+		}
+	}
+	timeRange := bson.D{}
+	if filter.From != nil {
+		timeRange = append(timeRange, bson.E{Key: "$gte", Value: *filter.From})
+	}
+	if filter.To != nil {
+		timeRange = append(timeRange, bson.E{Key: "$lt", Value: *filter.To})
+	}
+	if len(timeRange) > 0 {
+		query = append(query, bson.E{Key: "created_at", Value: timeRange})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetSkip(offset).SetLimit(limit)
+	cursor, err := collection.Find(context.Background(), query, opts)
+	if err != nil {
+		err = fmt.Errorf("get audit events from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	*events = []AuditEvent{}
+	err = cursor.All(context.Background(), events)
+	if err != nil {
+		err = fmt.Errorf("decoding audit events failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Shared space of tasks. Tasks of workspace are visible only to its members
+type Workspace struct {
+	WorkspaceID string `bson:"workspace_id"`
+	Name        string `bson:"name"`
+	// User who created workspace, or member who got it when previous owner deleted his account
+	Owner     string    `bson:"owner"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Roles of workspace members. Every role has all rights of previous ones
+const (
+	WorkspaceRoleMember = "member"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleOwner  = "owner"
+)
+
+var WorkspaceRoles = []string{WorkspaceRoleMember, WorkspaceRoleAdmin, WorkspaceRoleOwner}
+
+func ValidateWorkspaceRole(role string) error {
+	if !slices.Contains(WorkspaceRoles, role) {
+		return fmt.Errorf("unknown workspace role `%s`, should be one of: %s", role, strings.Join(WorkspaceRoles, ", "))
+	}
+	return nil
+}
+
+// Check that `role` has all rights of `required` role
+func WorkspaceRoleAtLeast(role string, required string) bool {
+	return slices.Index(WorkspaceRoles, role) >= slices.Index(WorkspaceRoles, required)
+}
+
+// Membership of user in workspace
+type WorkspaceMember struct {
+	WorkspaceID string `bson:"workspace_id"`
+	Username    string `bson:"username"`
+	Role        string `bson:"role"`
+	// User who invited member. Empty for owner
+	InvitedBy string    `bson:"invited_by,omitempty"`
+	JoinedAt  time.Time `bson:"joined_at"`
+}
+
+// Invitation to workspace. Invitation is addressed either to user with `Username` or to
+// anyone who has its link. Token of link is never stored, only its hash
+type WorkspaceInvitation struct {
+	InvitationID string `bson:"invitation_id"`
+	WorkspaceID  string `bson:"workspace_id"`
+	// Invited user. Empty for invitation by link
+	Username string `bson:"username,omitempty"`
+	// Hash of link's token. Empty for invitation of user
+	TokenHash string `bson:"token_hash,omitempty"`
+	// Role which is given to user who accepts invitation
+	Role      string    `bson:"role"`
+	CreatedBy string    `bson:"created_by"`
+	CreatedAt time.Time `bson:"created_at"`
+	// Invitation can't be accepted after this time
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func CreateWorkspace(workspace Workspace) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspaces")
+
+	_, err = collection.InsertOne(context.Background(), workspace)
+	if err != nil {
+		err = fmt.Errorf("mongo insert workspace failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If workspace doesn't exist returns 404 (Status Not Found)
+func GetWorkspace(workspaceID string, workspace *Workspace) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspaces")
+
+	filter := bson.D{{Key: "workspace_id", Value: workspaceID}}
+	err = collection.FindOne(context.Background(), filter).Decode(workspace)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("workspace not found")
+		}
+		err = fmt.Errorf("get workspace from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Get workspaces with given ids, sorted by name. Unknown ids are skipped
+func GetWorkspaces(workspaceIDs []string, workspaces *[]Workspace) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspaces")
+
+	filter := bson.D{{Key: "workspace_id", Value: bson.D{{Key: "$in", Value: workspaceIDs}}}}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "workspace_id", Value: 1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get workspaces from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	*workspaces = []Workspace{}
+	err = cursor.All(context.Background(), workspaces)
+	if err != nil {
+		err = fmt.Errorf("decoding workspaces failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If user is already a member of workspace returns 409 (Status Conflict)
+func AddWorkspaceMember(member WorkspaceMember) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_members")
+
+	_, err = collection.InsertOne(context.Background(), member)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return http.StatusConflict, errors.New("user is already a member of workspace")
+		}
+		err = fmt.Errorf("mongo insert workspace member failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If user is not a member of workspace returns 404 (Status Not Found)
+func GetWorkspaceMember(workspaceID string, username string, member *WorkspaceMember) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_members")
+
+	filter := bson.D{
+		{Key: "workspace_id", Value: workspaceID},
+		{Key: "username", Value: username},
+	}
+	err = collection.FindOne(context.Background(), filter).Decode(member)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("workspace member not found")
+		}
+		err = fmt.Errorf("get workspace member from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Get all members of workspace, sorted by username
+func GetWorkspaceMembers(workspaceID string, members *[]WorkspaceMember) (code int, err error) {
+	return findWorkspaceMembers(bson.D{{Key: "workspace_id", Value: workspaceID}}, members)
+}
+
+// Get all memberships of user
+func GetUserWorkspaceMembers(username string, members *[]WorkspaceMember) (code int, err error) {
+	return findWorkspaceMembers(bson.D{{Key: "username", Value: username}}, members)
+}
+
+func findWorkspaceMembers(filter bson.D, members *[]WorkspaceMember) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_members")
+
+	opts := options.Find().SetSort(bson.D{{Key: "username", Value: 1}, {Key: "workspace_id", Value: 1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get workspace members from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	*members = []WorkspaceMember{}
+	err = cursor.All(context.Background(), members)
+	if err != nil {
+		err = fmt.Errorf("decoding workspace members failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If user is not a member of workspace returns 404 (Status Not Found)
+func SetWorkspaceMemberRole(workspaceID string, username string, role string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_members")
+
+	filter := bson.D{
+		{Key: "workspace_id", Value: workspaceID},
+		{Key: "username", Value: username},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "role", Value: role}}}}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo update workspace member failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.MatchedCount == 0 {
+		return http.StatusNotFound, errors.New("workspace member not found")
+	}
+	return http.StatusOK, nil
+}
+
+// If user is not a member of workspace returns 404 (Status Not Found)
+func DeleteWorkspaceMember(workspaceID string, username string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_members")
+
+	filter := bson.D{
+		{Key: "workspace_id", Value: workspaceID},
+		{Key: "username", Value: username},
+	}
+	result, err := collection.DeleteOne(context.Background(), filter)
+	if err != nil {
+		err = fmt.Errorf("mongo delete workspace member failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.DeletedCount == 0 {
+		return http.StatusNotFound, errors.New("workspace member not found")
+	}
+	return http.StatusOK, nil
+}
+
+// Make member owner of workspace. Previous owner stays a member with his role, so it should be changed separately
+//
+//	If workspace or member doesn't exist returns 404 (Status Not Found)
+func SetWorkspaceOwner(workspaceID string, username string) (code int, err error) {
+	code, err = SetWorkspaceMemberRole(workspaceID, username, WorkspaceRoleOwner)
+	if err != nil {
+		return code, err
+	}
+
+	collection := mongoClient.Database("users_data").Collection("workspaces")
+	filter := bson.D{{Key: "workspace_id", Value: workspaceID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "owner", Value: username}}}}
+	result, err := collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		err = fmt.Errorf("mongo update workspace failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.MatchedCount == 0 {
+		return http.StatusNotFound, errors.New("workspace not found")
+	}
+	return http.StatusOK, nil
+}
+
+// Delete workspace with its members and invitations. Deleting of already deleted workspace is not an error
+func DeleteWorkspace(workspaceID string) (code int, err error) {
+	filter := bson.D{{Key: "workspace_id", Value: workspaceID}}
+	for _, name := range []string{"workspace_invitations", "workspace_members", "workspaces"} {
+		collection := mongoClient.Database("users_data").Collection(name)
+		_, err = collection.DeleteMany(context.Background(), filter)
+		if err != nil {
+			err = fmt.Errorf("mongo delete workspace's data from `%s` failed with error: %w", name, err)
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+func CreateWorkspaceInvitation(invitation WorkspaceInvitation) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_invitations")
+
+	_, err = collection.InsertOne(context.Background(), invitation)
+	if err != nil {
+		err = fmt.Errorf("mongo insert workspace invitation failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If invitation doesn't exist returns 404 (Status Not Found). Expired invitations are returned too
+func GetWorkspaceInvitation(invitationID string, invitation *WorkspaceInvitation) (code int, err error) {
+	return findWorkspaceInvitation(bson.D{{Key: "invitation_id", Value: invitationID}}, invitation)
+}
+
+// If invitation doesn't exist returns 404 (Status Not Found). Expired invitations are returned too
+func GetWorkspaceInvitationByTokenHash(tokenHash string, invitation *WorkspaceInvitation) (code int, err error) {
+	return findWorkspaceInvitation(bson.D{{Key: "token_hash", Value: tokenHash}}, invitation)
+}
+
+func findWorkspaceInvitation(filter bson.D, invitation *WorkspaceInvitation) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_invitations")
+
+	err = collection.FindOne(context.Background(), filter).Decode(invitation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return http.StatusNotFound, errors.New("invitation not found")
+		}
+		err = fmt.Errorf("get workspace invitation from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Get not expired invitations to workspace, the newest first
+func GetWorkspaceInvitations(workspaceID string, invitations *[]WorkspaceInvitation) (code int, err error) {
+	return findWorkspaceInvitations(bson.D{{Key: "workspace_id", Value: workspaceID}}, invitations)
+}
+
+// Get not expired invitations addressed to user, the newest first
+func GetUserWorkspaceInvitations(username string, invitations *[]WorkspaceInvitation) (code int, err error) {
+	return findWorkspaceInvitations(bson.D{{Key: "username", Value: username}}, invitations)
+}
+
+func findWorkspaceInvitations(filter bson.D, invitations *[]WorkspaceInvitation) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_invitations")
+
+	// Mongo removes expired documents not immediately
+	filter = append(filter, bson.E{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}})
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		err = fmt.Errorf("get workspace invitations from mongo failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+
+	*invitations = []WorkspaceInvitation{}
+	err = cursor.All(context.Background(), invitations)
+	if err != nil {
+		err = fmt.Errorf("decoding workspace invitations failed with message: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// If invitation doesn't exist returns 404 (Status Not Found)
+func DeleteWorkspaceInvitation(invitationID string) (code int, err error) {
+	collection := mongoClient.Database("users_data").Collection("workspace_invitations")
+
+	filter := bson.D{{Key: "invitation_id", Value: invitationID}}
+	result, err := collection.DeleteOne(context.Background(), filter)
+	if err != nil {
+		err = fmt.Errorf("mongo delete workspace invitation failed with error: %w", err)
+		return http.StatusInternalServerError, err
+	}
+	if result.DeletedCount == 0 {
+		return http.StatusNotFound, errors.New("invitation not found")
+	}
+	return http.StatusOK, nil
 }
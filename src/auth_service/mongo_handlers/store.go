@@ -0,0 +1,308 @@
+package mongo_handlers
+
+import "time"
+
+// Storage of auth_service backed by MongoDB
+//
+//	Methods only call package functions, so the global client should be initialized by `InitMongoClient`
+type Store struct{}
+
+func (Store) CreateUser(user User) (code int, err error) {
+	return CreateUser(user)
+}
+
+func (Store) GetUser(username string, user *User) (code int, err error) {
+	return GetUser(username, user)
+}
+
+func (Store) UpdateUser(username string, update UserUpdate) (code int, err error) {
+	return UpdateUser(username, update)
+}
+
+func (Store) CheckIfUserExists(username string) bool {
+	return CheckIfUserExists(username)
+}
+
+func (Store) CreateSession(session Session) (code int, err error) {
+	return CreateSession(session)
+}
+
+func (Store) GetSession(sessionID string, session *Session) (code int, err error) {
+	return GetSession(sessionID, session)
+}
+
+func (Store) GetUserSessions(username string, sessions *[]Session) (code int, err error) {
+	return GetUserSessions(username, sessions)
+}
+
+func (Store) TouchSession(sessionID string, lastSeenAt time.Time) error {
+	return TouchSession(sessionID, lastSeenAt)
+}
+
+func (Store) ExtendSession(sessionID string, expiresAt time.Time) (code int, err error) {
+	return ExtendSession(sessionID, expiresAt)
+}
+
+func (Store) RevokeSession(username string, sessionID string) (code int, err error) {
+	return RevokeSession(username, sessionID)
+}
+
+func (Store) RevokeUserSessions(username string, exceptSessionID string) (code int, err error) {
+	return RevokeUserSessions(username, exceptSessionID)
+}
+
+func (Store) StoreRefreshToken(token RefreshToken) (code int, err error) {
+	return StoreRefreshToken(token)
+}
+
+func (Store) GetRefreshToken(tokenHash string, token *RefreshToken) (code int, err error) {
+	return GetRefreshToken(tokenHash, token)
+}
+
+func (Store) MarkRefreshTokenUsed(tokenHash string) (marked bool, err error) {
+	return MarkRefreshTokenUsed(tokenHash)
+}
+
+func (Store) RevokeRefreshTokenFamily(familyID string) (code int, err error) {
+	return RevokeRefreshTokenFamily(familyID)
+}
+
+func (Store) RevokeUserRefreshTokens(username string, exceptFamilyID string) (code int, err error) {
+	return RevokeUserRefreshTokens(username, exceptFamilyID)
+}
+
+func (Store) StorePersonalAccessToken(token PersonalAccessToken) (code int, err error) {
+	return StorePersonalAccessToken(token)
+}
+
+func (Store) GetPersonalAccessTokenByHash(tokenHash string, token *PersonalAccessToken) (code int, err error) {
+	return GetPersonalAccessTokenByHash(tokenHash, token)
+}
+
+func (Store) GetUserPersonalAccessTokens(username string, tokens *[]PersonalAccessToken) (code int, err error) {
+	return GetUserPersonalAccessTokens(username, tokens)
+}
+
+func (Store) TouchPersonalAccessToken(tokenID string, lastUsedAt time.Time) error {
+	return TouchPersonalAccessToken(tokenID, lastUsedAt)
+}
+
+func (Store) RevokePersonalAccessToken(username string, tokenID string) (code int, err error) {
+	return RevokePersonalAccessToken(username, tokenID)
+}
+
+func (Store) GetExternalIdentity(issuer string, subject string, identity *ExternalIdentity) (code int, err error) {
+	return GetExternalIdentity(issuer, subject, identity)
+}
+
+func (Store) StoreExternalIdentity(identity ExternalIdentity) (code int, err error) {
+	return StoreExternalIdentity(identity)
+}
+
+func (Store) StoreOIDCLoginState(state OIDCLoginState) (code int, err error) {
+	return StoreOIDCLoginState(state)
+}
+
+func (Store) PopOIDCLoginState(state string, loginState *OIDCLoginState) (code int, err error) {
+	return PopOIDCLoginState(state, loginState)
+}
+
+func (Store) GetTwoFactor(username string, twoFactor *TwoFactor) (code int, err error) {
+	return GetTwoFactor(username, twoFactor)
+}
+
+func (Store) StoreTwoFactor(twoFactor TwoFactor) (code int, err error) {
+	return StoreTwoFactor(twoFactor)
+}
+
+func (Store) DeleteTwoFactor(username string) (code int, err error) {
+	return DeleteTwoFactor(username)
+}
+
+func (Store) UseTOTPStep(username string, step int64) (bool, error) {
+	return UseTOTPStep(username, step)
+}
+
+func (Store) UseRecoveryCode(username string, codeHash string) (bool, error) {
+	return UseRecoveryCode(username, codeHash)
+}
+
+func (Store) RecordTwoFactorFailure(username string, maxAttempts int, lockDuration time.Duration) error {
+	return RecordTwoFactorFailure(username, maxAttempts, lockDuration)
+}
+
+func (Store) GetLoginAttempts(key string, attempts *LoginAttempts) (code int, err error) {
+	return GetLoginAttempts(key, attempts)
+}
+
+func (Store) RecordLoginFailure(key string, now time.Time, ttl time.Duration, attempts *LoginAttempts) error {
+	return RecordLoginFailure(key, now, ttl, attempts)
+}
+
+func (Store) LockLogin(key string, lockedUntil time.Time) error {
+	return LockLogin(key, lockedUntil)
+}
+
+func (Store) ResetLoginAttempts(key string) error {
+	return ResetLoginAttempts(key)
+}
+
+func (Store) StoreLockoutEvent(event LockoutEvent) error {
+	return StoreLockoutEvent(event)
+}
+
+func (Store) GetLockoutEvents(activeOnly bool, offset int64, limit int64, events *[]LockoutEvent) (code int, err error) {
+	return GetLockoutEvents(activeOnly, offset, limit, events)
+}
+
+func (Store) ClearLockout(key string, clearedBy string) (code int, err error) {
+	return ClearLockout(key, clearedBy)
+}
+
+func (Store) StorePasswordReset(reset PasswordReset) (code int, err error) {
+	return StorePasswordReset(reset)
+}
+
+func (Store) PopPasswordReset(tokenHash string, reset *PasswordReset) (code int, err error) {
+	return PopPasswordReset(tokenHash, reset)
+}
+
+func (Store) DeleteUser(username string) (code int, err error) {
+	return DeleteUser(username)
+}
+
+func (Store) DeleteUserSessions(username string) (code int, err error) {
+	return DeleteUserSessions(username)
+}
+
+func (Store) StartAccountDeletion(username string, requestedAt time.Time, deletion *AccountDeletion) (code int, err error) {
+	return StartAccountDeletion(username, requestedAt, deletion)
+}
+
+func (Store) GetPendingAccountDeletion(username string, deletion *AccountDeletion) (code int, err error) {
+	return GetPendingAccountDeletion(username, deletion)
+}
+
+func (Store) GetPendingAccountDeletions(deletions *[]AccountDeletion) (code int, err error) {
+	return GetPendingAccountDeletions(deletions)
+}
+
+func (Store) ClaimAccountDeletion(username string, lockID string, lockedUntil time.Time, deletion *AccountDeletion) (code int, err error) {
+	return ClaimAccountDeletion(username, lockID, lockedUntil, deletion)
+}
+
+func (Store) UpdateAccountDeletion(deletion AccountDeletion) (code int, err error) {
+	return UpdateAccountDeletion(deletion)
+}
+
+func (Store) CreateDataExport(export DataExport) (code int, err error) {
+	return CreateDataExport(export)
+}
+
+func (Store) GetDataExport(username string, exportID string, export *DataExport) (code int, err error) {
+	return GetDataExport(username, exportID, export)
+}
+
+func (Store) GetUserDataExports(username string, exports *[]DataExport) (code int, err error) {
+	return GetUserDataExports(username, exports)
+}
+
+func (Store) UpdateDataExport(export DataExport) (code int, err error) {
+	return UpdateDataExport(export)
+}
+
+func (Store) StoreDataExportArchive(exportID string, archive []byte) (code int, err error) {
+	return StoreDataExportArchive(exportID, archive)
+}
+
+func (Store) GetDataExportArchive(exportID string, archive *[]byte) (code int, err error) {
+	return GetDataExportArchive(exportID, archive)
+}
+
+func (Store) DeleteDataExportArchive(exportID string) (code int, err error) {
+	return DeleteDataExportArchive(exportID)
+}
+
+func (Store) DeleteExpiredDataExportArchives(before time.Time) (code int, err error) {
+	return DeleteExpiredDataExportArchives(before)
+}
+
+func (Store) SearchUsers(query string, offset int64, limit int64, page *UserPage) (code int, err error) {
+	return SearchUsers(query, offset, limit, page)
+}
+
+func (Store) StoreAuditEvent(event AuditEvent) error {
+	return StoreAuditEvent(event)
+}
+
+func (Store) GetAuditEvents(filter AuditEventFilter, offset int64, limit int64, events *[]AuditEvent) (code int, err error) {
+	return GetAuditEvents(filter, offset, limit, events)
+}
+
+func (Store) CreateWorkspace(workspace Workspace) (code int, err error) {
+	return CreateWorkspace(workspace)
+}
+
+func (Store) GetWorkspace(workspaceID string, workspace *Workspace) (code int, err error) {
+	return GetWorkspace(workspaceID, workspace)
+}
+
+func (Store) GetWorkspaces(workspaceIDs []string, workspaces *[]Workspace) (code int, err error) {
+	return GetWorkspaces(workspaceIDs, workspaces)
+}
+
+func (Store) AddWorkspaceMember(member WorkspaceMember) (code int, err error) {
+	return AddWorkspaceMember(member)
+}
+
+func (Store) GetWorkspaceMember(workspaceID string, username string, member *WorkspaceMember) (code int, err error) {
+	return GetWorkspaceMember(workspaceID, username, member)
+}
+
+func (Store) GetWorkspaceMembers(workspaceID string, members *[]WorkspaceMember) (code int, err error) {
+	return GetWorkspaceMembers(workspaceID, members)
+}
+
+func (Store) GetUserWorkspaceMembers(username string, members *[]WorkspaceMember) (code int, err error) {
+	return GetUserWorkspaceMembers(username, members)
+}
+
+func (Store) SetWorkspaceMemberRole(workspaceID string, username string, role string) (code int, err error) {
+	return SetWorkspaceMemberRole(workspaceID, username, role)
+}
+
+func (Store) DeleteWorkspaceMember(workspaceID string, username string) (code int, err error) {
+	return DeleteWorkspaceMember(workspaceID, username)
+}
+
+func (Store) SetWorkspaceOwner(workspaceID string, username string) (code int, err error) {
+	return SetWorkspaceOwner(workspaceID, username)
+}
+
+func (Store) DeleteWorkspace(workspaceID string) (code int, err error) {
+	return DeleteWorkspace(workspaceID)
+}
+
+func (Store) CreateWorkspaceInvitation(invitation WorkspaceInvitation) (code int, err error) {
+	return CreateWorkspaceInvitation(invitation)
+}
+
+func (Store) GetWorkspaceInvitation(invitationID string, invitation *WorkspaceInvitation) (code int, err error) {
+	return GetWorkspaceInvitation(invitationID, invitation)
+}
+
+func (Store) GetWorkspaceInvitationByTokenHash(tokenHash string, invitation *WorkspaceInvitation) (code int, err error) {
+	return GetWorkspaceInvitationByTokenHash(tokenHash, invitation)
+}
+
+func (Store) GetWorkspaceInvitations(workspaceID string, invitations *[]WorkspaceInvitation) (code int, err error) {
+	return GetWorkspaceInvitations(workspaceID, invitations)
+}
+
+func (Store) GetUserWorkspaceInvitations(username string, invitations *[]WorkspaceInvitation) (code int, err error) {
+	return GetUserWorkspaceInvitations(username, invitations)
+}
+
+func (Store) DeleteWorkspaceInvitation(invitationID string) (code int, err error) {
+	return DeleteWorkspaceInvitation(invitationID)
+}
@@ -0,0 +1,5 @@
+module oidc_handlers
+
+go 1.22.0
+
+require github.com/golang-jwt/jwt/v5 v5.2.1
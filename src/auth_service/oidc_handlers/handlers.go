@@ -0,0 +1,359 @@
+package oidc_handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultScopes = "openid profile email"
+
+	requestTimeout = 10 * time.Second
+)
+
+type Config struct {
+	// Issuer as it's written in `iss` claim. Discovery document is loaded from <IssuerURL>/.well-known/openid-configuration
+	IssuerURL string
+	// Issuer's URL which is reachable from user's browser, if it differs from `IssuerURL` (e.g. inside docker network)
+	PublicIssuerURL string
+	ClientID        string
+	// May be empty for public clients, they are protected by PKCE
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Parts of OpenID Provider Metadata which are used by the service
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims of ID token which are used by the service
+type IDTokenClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	jwt.RegisteredClaims
+}
+
+// OpenID Connect provider (external identity provider)
+type Provider struct {
+	config     Config
+	httpClient *http.Client
+
+	mutex     sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]*rsa.PublicKey
+}
+
+func NewProvider(config Config) *Provider {
+	return &Provider{
+		config:     config,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (p *Provider) Issuer() string {
+	return p.config.IssuerURL
+}
+
+// Load discovery document. It's loaded lazily, so the service can start before identity provider
+func (p *Provider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var doc discoveryDocument
+	err := p.getJSON(ctx, strings.TrimSuffix(p.config.IssuerURL, "/")+"/.well-known/openid-configuration", &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openid configuration: %w", err)
+	}
+	if doc.Issuer != p.config.IssuerURL {
+		return nil, fmt.Errorf("issuer in openid configuration `%s` doesn't match configured issuer `%s`", doc.Issuer, p.config.IssuerURL)
+	}
+
+	p.discovery = &doc
+	return p.discovery, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Build URL of identity provider's login page where user should be redirected
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := doc.AuthorizationEndpoint
+	if p.config.PublicIssuerURL != "" {
+		if rest, ok := strings.CutPrefix(endpoint, p.config.IssuerURL); ok {
+			endpoint = p.config.PublicIssuerURL + rest
+		}
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+	return endpoint + separator + query.Encode(), nil
+}
+
+// Exchange authorization code for tokens and return verified claims of ID token
+//
+//	Caller should check that `nonce` claim matches nonce of the login request
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string) (*IDTokenClaims, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode token response (status %s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request returned status %s: %s %s", resp.Status, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return p.verifyIDToken(ctx, tokenResponse.IDToken)
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, rawIDToken string) (*IDTokenClaims, error) {
+	var claims IDTokenClaims
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(p.config.IssuerURL),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id_token: no `sub` claim")
+	}
+	return &claims, nil
+}
+
+// Get identity provider's key by id. Keys are reloaded if key is unknown (provider could rotate keys)
+func (p *Provider) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mutex.Lock()
+	key, ok := p.keys[kid]
+	p.mutex.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, doc.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to load identity provider's keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mutex.Lock()
+	p.keys = keys
+	p.mutex.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	// Provider may publish the only key without `kid`
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("identity provider has no key `%s`", kid)
+}
+
+// Generate random value for `state` and `nonce` parameters
+func GenerateRandomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Generate PKCE code verifier and its S256 challenge (RFC 7636)
+func GeneratePKCE() (verifier string, challenge string, err error) {
+	verifier, err = GenerateRandomString()
+	if err != nil {
+		return "", "", err
+	}
+	hash := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+var provider *Provider
+
+// Configure identity provider from environment:
+//
+//	OIDC_ISSUER_URL        - issuer, if it's not set then OIDC login is disabled
+//	OIDC_PUBLIC_ISSUER_URL - issuer's URL reachable from browser (optional)
+//	OIDC_CLIENT_ID         - client id registered in identity provider
+//	OIDC_CLIENT_SECRET     - client secret (optional)
+//	OIDC_REDIRECT_URL      - URL of `/oidc/callback` handler
+//	OIDC_SCOPES            - space separated scopes (default: "openid profile email")
+func InitOIDCProvider() error {
+	issuer := os.Getenv("OIDC_ISSUER_URL")
+	if issuer == "" {
+		log.Printf("OIDC_ISSUER_URL is not set, OIDC login is disabled")
+		return nil
+	}
+
+	config := Config{
+		IssuerURL:       issuer,
+		PublicIssuerURL: os.Getenv("OIDC_PUBLIC_ISSUER_URL"),
+		ClientID:        os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret:    os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:     os.Getenv("OIDC_REDIRECT_URL"),
+	}
+	if config.ClientID == "" {
+		return errors.New("OIDC client id is not configured (OIDC_CLIENT_ID environment variable)")
+	}
+	if config.RedirectURL == "" {
+		return errors.New("OIDC redirect URL is not configured (OIDC_REDIRECT_URL environment variable)")
+	}
+
+	scopes := os.Getenv("OIDC_SCOPES")
+	if scopes == "" {
+		scopes = defaultScopes
+	}
+	config.Scopes = strings.Fields(scopes)
+	if !slices.Contains(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+
+	provider = NewProvider(config)
+	log.Printf("OIDC login is enabled with issuer %s", issuer)
+	return nil
+}
+
+// Returns nil if OIDC login is disabled
+func GetProvider() *Provider {
+	return provider
+}
+
+// Replace identity provider (e.g. by provider with stub issuer in tests). Nil disables OIDC login
+func SetProvider(newProvider *Provider) {
+	provider = newProvider
+}
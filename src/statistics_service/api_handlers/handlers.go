@@ -47,7 +47,8 @@ func GetTopTasks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
 	parameter := mux.Vars(r)["parameter"]
-	top, err := clickhouse_handlers.GetTopTasksByParameter(parameter, defaultTopTasksSize)
+	workspaceID := r.URL.Query().Get("workspace_id")
+	top, err := clickhouse_handlers.GetTopTasksByParameter(parameter, defaultTopTasksSize, workspaceID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -64,7 +65,8 @@ func GetTopTasks(w http.ResponseWriter, r *http.Request) {
 func GetTopUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	top, err := clickhouse_handlers.GetTopUsersByLikes(defaultTopUsersSize)
+	workspaceID := r.URL.Query().Get("workspace_id")
+	top, err := clickhouse_handlers.GetTopUsersByLikes(defaultTopUsersSize, workspaceID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -77,3 +79,26 @@ func GetTopUsers(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(encoded)
 }
+
+// Likes and views of user (for export of personal data by auth_service)
+//
+//	There is no authentication, so statistics service must be reachable only from internal network
+func GetUserActivity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	username := mux.Vars(r)["username"]
+	activity, err := clickhouse_handlers.GetUserActivity(username)
+	if err != nil {
+		err = fmt.Errorf("`GetUserActivity` failed with message: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encoded, err := json.Marshal(activity)
+	if err != nil {
+		err = fmt.Errorf("json result marshal error: %w", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(encoded)
+}
@@ -62,4 +62,10 @@ var routes = Routes{
 		"/top/users",
 		GetTopUsers,
 	},
+	Route{
+		"GetUserActivity",
+		"GET",
+		"/users/{username}/activity",
+		GetUserActivity,
+	},
 }
@@ -113,7 +113,8 @@ func GetTaskStatistics(taskID int32) (statistics Statistics, err error) {
 	return statistics, nil
 }
 
-func GetTopTasksByParameter(parameter string, topSize int) (res []TaskWithStatistics, err error) {
+// Get `topSize` tasks of workspace with the most `parameter`. Empty `workspaceID` means tasks outside of workspaces
+func GetTopTasksByParameter(parameter string, topSize int, workspaceID string) (res []TaskWithStatistics, err error) {
 	if _, ok := statisticsNames[parameter]; !ok {
 		err = fmt.Errorf("there is not statistic named `%s`", parameter)
 		return
@@ -125,6 +126,7 @@ func GetTopTasksByParameter(parameter string, topSize int) (res []TaskWithStatis
     	task_id,
 		task_author
 	FROM %s
+	WHERE workspace_id = ?
 	GROUP BY
 		task_id,
 		task_author
@@ -132,7 +134,7 @@ func GetTopTasksByParameter(parameter string, topSize int) (res []TaskWithStatis
 	COUNT(DISTINCT username) DESC
 	LIMIT %v
 	`, parameter, topSize)
-	rows, err := conn.Query(context.Background(), query)
+	rows, err := conn.Query(context.Background(), query, workspaceID)
 	if err != nil {
 		return
 	}
@@ -163,18 +165,20 @@ type UserWithLikes struct {
 	Likes  int64  `json:"likes"`
 }
 
-func GetTopUsersByLikes(usersCount int) ([]UserWithLikes, error) {
+// Get `usersCount` authors of workspace whose tasks have the most likes. Empty `workspaceID` means tasks outside of workspaces
+func GetTopUsersByLikes(usersCount int, workspaceID string) ([]UserWithLikes, error) {
 	// Get `usersCount` rows with most liked usernames and their like counts
 	query := fmt.Sprintf(`
 	SELECT
 		task_author,
 		COUNT() - COUNT(DISTINCT task_id) AS total_likes
 	FROM likes
+	WHERE workspace_id = ?
 	GROUP BY task_author
 	ORDER BY total_likes DESC
 	LIMIT %v;
 	`, usersCount)
-	rows, err := conn.Query(context.Background(), query)
+	rows, err := conn.Query(context.Background(), query, workspaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -195,3 +199,98 @@ func GetTopUsersByLikes(usersCount int) ([]UserWithLikes, error) {
 	}
 	return result, nil
 }
+
+type TaskReference struct {
+	TaskID     int32  `json:"task_id"`
+	TaskAuthor string `json:"task_author"`
+}
+
+// Tasks which were liked and viewed by user
+type UserActivity struct {
+	Username string          `json:"username"`
+	Likes    []TaskReference `json:"likes"`
+	Views    []TaskReference `json:"views"`
+}
+
+func getUserTasksByParameter(username string, parameter string) ([]TaskReference, error) {
+	if _, ok := statisticsNames[parameter]; !ok {
+		return nil, fmt.Errorf("there is not statistic named `%s`", parameter)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT DISTINCT
+		task_id,
+		task_author
+	FROM %s
+	WHERE username = ?
+	ORDER BY task_id;
+	`, parameter)
+	rows, err := conn.Query(context.Background(), query, username)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []TaskReference{}
+	for rows.Next() {
+		var t TaskReference
+		err := rows.Scan(&t.TaskID, &t.TaskAuthor)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func GetUserActivity(username string) (activity UserActivity, err error) {
+	activity.Username = username
+	activity.Likes, err = getUserTasksByParameter(username, "likes")
+	if err != nil {
+		err = fmt.Errorf("`getUserTasksByParameter` failed with error: %w", err)
+		return
+	}
+	activity.Views, err = getUserTasksByParameter(username, "views")
+	if err != nil {
+		err = fmt.Errorf("`getUserTasksByParameter` failed with error: %w", err)
+		return
+	}
+	return
+}
+
+// Delete likes and views of user and statistics of `deletedTaskIDs`
+//
+//	Other tasks of user are kept in workspaces, so their statistics (including activity of other members)
+//	stay and only their author is removed.
+//	Deleting statistics of already deleted user is not an error, so it can be safely repeated
+func DeleteUserStatistics(username string, deletedTaskIDs []int32) error {
+	// Wait until rows are really deleted, otherwise deletion may be reported before it's done
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithSettings(clickhouse.Settings{
+		"mutations_sync": 1,
+	}))
+
+	for parameter := range statisticsNames {
+		query := fmt.Sprintf("ALTER TABLE %s DELETE WHERE username = ?", parameter)
+		err := conn.Exec(ctx, query, username)
+		if err != nil {
+			return fmt.Errorf("deletion of user's rows from `%s` failed with error: %w", parameter, err)
+		}
+
+		if len(deletedTaskIDs) > 0 {
+			query = fmt.Sprintf("ALTER TABLE %s DELETE WHERE has(?, task_id)", parameter)
+			err = conn.Exec(ctx, query, deletedTaskIDs)
+			if err != nil {
+				return fmt.Errorf("deletion of deleted tasks' rows from `%s` failed with error: %w", parameter, err)
+			}
+		}
+
+		query = fmt.Sprintf("ALTER TABLE %s UPDATE task_author = '' WHERE task_author = ?", parameter)
+		err = conn.Exec(ctx, query, username)
+		if err != nil {
+			return fmt.Errorf("anonymization of user's tasks in `%s` failed with error: %w", parameter, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package clickhouse_handlers
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Versioned migrations of statistics schema
+//
+//	Migrations are SQL files in `migrations` directory named `<version>_<name>.up.sql` and
+//	`<version>_<name>.down.sql`, where down migration reverts up one. Statements of file are separated
+//	by `;` (semicolons inside of strings, quoted identifiers and comments don't separate statements) and
+//	executed one by one, because ClickHouse has no transactions. If some statement fails, previous ones
+//	stay executed and migration is not recorded, so it's applied from the first statement next time.
+//	That's why every statement must be idempotent (`IF EXISTS`, `IF NOT EXISTS`).
+//	Applied versions are stored in `schema_migrations` table. Rows are never deleted: rolled back
+//	migration gets new row with `applied = 0` and the latest row of version wins.
+//	ClickHouse has no locks, so two processes migrating at the same time would run DDL twice or fail halfway.
+//	Migrations are applied only by single `migrate up` job before replicas start, service itself only checks them
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint32
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	// Nil if migration is not applied
+	AppliedAt *time.Time
+}
+
+// Read migrations from `migrations` directory, sorted by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[uint32]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected name of migration file `%s`", entry.Name())
+		}
+		version, _ := strconv.ParseUint(match[1], 10, 32)
+		content, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration `%s`: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[uint32(version)]
+		if !ok {
+			migration = &Migration{Version: uint32(version), Name: match[2]}
+			byVersion[uint32(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations `%s` and `%s` have the same version %v", migration.Name, match[2], version)
+		}
+		if _, err := splitStatements(string(content)); err != nil {
+			return nil, fmt.Errorf("migration `%s` is malformed: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %v_%s should have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Get every migration with time when it was applied
+func GetMigrationStatus() ([]MigrationStatus, error) {
+	ctx := context.Background()
+	if err := createMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+	return getMigrationStatus(ctx)
+}
+
+// Check that every migration is applied, otherwise service would work with outdated schema
+func CheckMigrations() error {
+	statuses, err := GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+	pending := []uint32{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("migrations %v are not applied, run `statistics_service migrate up` before starting the service", pending)
+	}
+	return nil
+}
+
+// Apply every migration which is not applied yet. Returns number of applied migrations
+//
+//	It must not run concurrently with another `Migrate` or `Rollback`
+func Migrate() (applied int, err error) {
+	ctx := context.Background()
+	if err := createMigrationsTable(ctx); err != nil {
+		return 0, err
+	}
+	statuses, err := getMigrationStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		err = runMigration(ctx, status.Migration, status.Up, true)
+		if err != nil {
+			return applied, fmt.Errorf("migration %v_%s failed: %w", status.Version, status.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Revert `steps` latest applied migrations. Returns number of reverted migrations
+func Rollback(steps int) (reverted int, err error) {
+	ctx := context.Background()
+	if err := createMigrationsTable(ctx); err != nil {
+		return 0, err
+	}
+	statuses, err := getMigrationStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(statuses) - 1; i >= 0 && reverted < steps; i-- {
+		status := statuses[i]
+		if status.AppliedAt == nil {
+			continue
+		}
+		err = runMigration(ctx, status.Migration, status.Down, false)
+		if err != nil {
+			return reverted, fmt.Errorf("rollback of migration %v_%s failed: %w", status.Version, status.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+func createMigrationsTable(ctx context.Context) error {
+	err := conn.Exec(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version UInt32,
+		name String,
+		applied UInt8,
+		updated_at DateTime64(3)
+	) ENGINE = ReplacingMergeTree(updated_at)
+	ORDER BY version
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create `schema_migrations` table: %w", err)
+	}
+	return nil
+}
+
+// Migrations from files joined with applied versions from database
+func getMigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, "SELECT version, updated_at FROM schema_migrations FINAL WHERE applied = 1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := map[uint32]time.Time{}
+	for rows.Next() {
+		var version uint32
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	// Database was migrated by newer version of statistics service
+	if len(applied) > 0 {
+		unknown := []int{}
+		for version := range applied {
+			unknown = append(unknown, int(version))
+		}
+		sort.Ints(unknown)
+		return nil, fmt.Errorf("applied migrations %v are unknown to this version of statistics service", unknown)
+	}
+	return statuses, nil
+}
+
+// Execute statements of migration one by one and record new state of migration.
+// State is recorded only after every statement succeeded
+func runMigration(ctx context.Context, migration Migration, script string, applied bool) error {
+	statements, err := splitStatements(script)
+	if err != nil {
+		return fmt.Errorf("migration %v is malformed: %w", migration.Version, err)
+	}
+	for _, statement := range statements {
+		if err := conn.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	var appliedFlag uint8
+	if applied {
+		appliedFlag = 1
+	}
+	err = conn.Exec(
+		ctx,
+		"INSERT INTO schema_migrations (version, name, applied, updated_at) VALUES (?, ?, ?, ?)",
+		migration.Version, migration.Name, appliedFlag, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %v: %w", migration.Version, err)
+	}
+	return nil
+}
+
+// Split script into statements by `;` outside of strings, quoted identifiers and comments.
+// Parts without anything but spaces and comments are skipped
+func splitStatements(script string) ([]string, error) {
+	statements := []string{}
+	start := 0
+	// Statement has something besides spaces and comments
+	hasCode := false
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			// Quote is escaped by backslash or by doubling it
+			end := i + 1
+			for ; end < len(script); end++ {
+				if script[end] == '\\' {
+					end++
+				} else if script[end] == c {
+					if end+1 < len(script) && script[end+1] == c {
+						end++
+					} else {
+						break
+					}
+				}
+			}
+			if end >= len(script) {
+				return nil, fmt.Errorf("unterminated %c at offset %v", c, i)
+			}
+			i = end
+			hasCode = true
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %v", i)
+			}
+			i += end + 3
+		case c == ';':
+			if hasCode {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			start = i + 1
+			hasCode = false
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			hasCode = true
+		}
+	}
+	if hasCode {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+	return statements, nil
+}
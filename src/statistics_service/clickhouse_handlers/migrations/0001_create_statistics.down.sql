@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS mv_likes;
+
+DROP TABLE IF EXISTS mv_views;
+
+DROP TABLE IF EXISTS likes;
+
+DROP TABLE IF EXISTS views;
+
+DROP TABLE IF EXISTS likes_queue;
+
+DROP TABLE IF EXISTS views_queue;
@@ -1,3 +1,4 @@
+-- Schema which was created by `init.sql` before migrations were introduced
 
 CREATE TABLE IF NOT EXISTS views_queue (
   username String,
@@ -0,0 +1,51 @@
+-- Empty for tasks outside of workspaces
+ALTER TABLE views ADD COLUMN IF NOT EXISTS workspace_id String DEFAULT '';
+
+ALTER TABLE likes ADD COLUMN IF NOT EXISTS workspace_id String DEFAULT '';
+
+-- Columns of Kafka tables can't be altered, so queues and views reading them are recreated
+DROP TABLE IF EXISTS mv_views;
+
+DROP TABLE IF EXISTS mv_likes;
+
+DROP TABLE IF EXISTS views_queue;
+
+DROP TABLE IF EXISTS likes_queue;
+
+CREATE TABLE IF NOT EXISTS views_queue (
+  username String,
+  task_id Int32,
+  task_author String,
+  workspace_id String
+) ENGINE = Kafka
+SETTINGS kafka_broker_list = 'kafka:9092',
+       kafka_topic_list = 'views',
+       kafka_group_name = 'group1',
+       kafka_format = 'JSONEachRow';
+
+CREATE TABLE IF NOT EXISTS likes_queue (
+  username String,
+  task_id Int32,
+  task_author String,
+  workspace_id String
+) ENGINE = Kafka
+SETTINGS kafka_broker_list = 'kafka:9092',
+       kafka_topic_list = 'likes',
+       kafka_group_name = 'group1',
+       kafka_format = 'JSONEachRow';
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_views TO views AS
+SELECT 
+  username,
+  task_id,
+  task_author,
+  workspace_id
+FROM views_queue;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_likes TO likes AS
+SELECT 
+  username,
+  task_id,
+  task_author,
+  workspace_id
+FROM likes_queue;
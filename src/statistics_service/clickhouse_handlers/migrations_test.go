@@ -0,0 +1,61 @@
+package clickhouse_handlers
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		script     string
+		statements []string
+	}{
+		{"single statement", "SELECT 1;", []string{"SELECT 1"}},
+		{"trailing statement without semicolon", "SELECT 1;\nSELECT 2", []string{"SELECT 1", "SELECT 2"}},
+		{"empty parts", " ;\n;SELECT 1;;\n", []string{"SELECT 1"}},
+		{"semicolon in string", "SELECT 'a;b'; SELECT 2;", []string{"SELECT 'a;b'", "SELECT 2"}},
+		{"semicolon in quoted identifiers", "SELECT \"a;b\", `c;d`;", []string{"SELECT \"a;b\", `c;d`"}},
+		{"quote escaped by backslash", `SELECT 'it\'s;'; SELECT 2;`, []string{`SELECT 'it\'s;'`, "SELECT 2"}},
+		{"escaped backslash before quote", `SELECT 'a\\'; SELECT 2;`, []string{`SELECT 'a\\'`, "SELECT 2"}},
+		{"doubled quote", "SELECT 'it''s;'; SELECT 2;", []string{"SELECT 'it''s;'", "SELECT 2"}},
+		{"line comment", "-- first; statement\nSELECT 1; -- trailing;\nSELECT 2;", []string{"-- first; statement\nSELECT 1", "-- trailing;\nSELECT 2"}},
+		{"line comment at the end", "SELECT 1;\n-- nothing else; here", []string{"SELECT 1"}},
+		{"block comment", "SELECT /* a; b */ 1; /* only; comment */;", []string{"SELECT /* a; b */ 1"}},
+		{"quote in comment", "-- it's\nSELECT 1; /* \" */ SELECT 2", []string{"-- it's\nSELECT 1", "/* \" */ SELECT 2"}},
+	} {
+		statements, err := splitStatements(test.script)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !slices.Equal(statements, test.statements) {
+			t.Errorf("%s: expected statements %q, got %q", test.name, test.statements, statements)
+		}
+	}
+}
+
+func TestSplitStatementsMalformed(t *testing.T) {
+	for _, script := range []string{
+		"SELECT 'unterminated;",
+		"SELECT `unterminated;",
+		`SELECT 'escaped quote at the end\';`,
+		"SELECT 1; /* unterminated comment",
+	} {
+		if statements, err := splitStatements(script); err == nil {
+			t.Errorf("script %q: expected error, got statements %q", script, statements)
+		}
+	}
+}
+
+func TestMigrationFilesAreWellFormed(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != uint32(i+1) {
+			t.Errorf("expected migration version %v, got %v_%s", i+1, migration.Version, migration.Name)
+		}
+	}
+}
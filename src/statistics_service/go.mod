@@ -5,6 +5,7 @@ go 1.22.0
 require (
 	clickhouse_handlers v0.0.0-00010101000000-000000000000
 	github.com/gorilla/mux v1.8.1
+	kafka_handlers v0.0.0
 )
 
 require (
@@ -19,6 +20,7 @@ require (
 	github.com/pierrec/lz4/v4 v4.1.21 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/segmentio/asm v1.2.0 // indirect
+	github.com/segmentio/kafka-go v0.4.47 // indirect
 	github.com/shopspring/decimal v1.4.0 // indirect
 	go.opentelemetry.io/otel v1.26.0 // indirect
 	go.opentelemetry.io/otel/trace v1.26.0 // indirect
@@ -27,3 +29,5 @@ require (
 )
 
 replace clickhouse_handlers => ./clickhouse_handlers
+
+replace kafka_handlers => ./kafka_handlers
@@ -0,0 +1,81 @@
+package kafka_handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+const (
+	userDeletionsTopic = "user_deletions"
+	consumerGroupID    = "statistics_service"
+	// Pause before next try when Kafka or handler failed
+	retryDelay = 10 * time.Second
+)
+
+type userDeletion struct {
+	Username string `json:"username"`
+	// Tasks deleted together with user, other tasks of user are kept in workspaces
+	DeletedTaskIDs []int32 `json:"deleted_task_ids"`
+}
+
+// Read notifications about deleted users (sent by auth service) and pass their usernames and deleted tasks to `handle`
+//
+//	Message is committed only after `handle` succeeds, so after failure or restart of the service
+//	notification is processed again. Because of that `handle` should be idempotent.
+//	Blocks until `ctx` is done
+func ConsumeUserDeletions(ctx context.Context, kafkaURL string, handle func(username string, deletedTaskIDs []int32) error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{kafkaURL},
+		GroupID: consumerGroupID,
+		Topic:   userDeletionsTopic,
+	})
+	defer reader.Close()
+
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Fetch message from `%s` failed with error: %s", userDeletionsTopic, err.Error())
+			sleep(ctx, retryDelay)
+			continue
+		}
+
+		var deletion userDeletion
+		if err := json.Unmarshal(message.Value, &deletion); err != nil || deletion.Username == "" {
+			// Malformed message can't be processed even after retry, so it's skipped
+			log.Printf("Skip malformed message from `%s`: %s", userDeletionsTopic, string(message.Value))
+		} else {
+			log.Printf("Delete statistics of user `%s`", deletion.Username)
+			for {
+				err := handle(deletion.Username, deletion.DeletedTaskIDs)
+				if err == nil {
+					break
+				}
+				log.Printf("Deletion of statistics of user `%s` failed, will retry: %s", deletion.Username, err.Error())
+				if !sleep(ctx, retryDelay) {
+					return
+				}
+			}
+		}
+
+		if err := reader.CommitMessages(ctx, message); err != nil {
+			log.Printf("Commit message to `%s` failed with error: %s", userDeletionsTopic, err.Error())
+		}
+	}
+}
+
+// Returns false if `ctx` was done before `duration` passed
+func sleep(ctx context.Context, duration time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
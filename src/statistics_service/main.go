@@ -2,17 +2,31 @@
  * 		-- Statistics Service --
  * - Returns statistics for tasks or users
  * - Holds connection to ClickHouse with statistic tables
+ * - Deletes statistics of deleted users (notifications from Kafka)
  */
 
 package main
 
 import (
 	"clickhouse_handlers"
+	"context"
+	"fmt"
+	"kafka_handlers"
 	"log"
 	"net/http"
+	"os"
 	han "statistics_service/api_handlers"
+	"strconv"
+	"text/tabwriter"
 )
 
+const migrateUsage = `Usage: statistics_service migrate <command>
+
+Commands:
+  status        show migrations and whether they are applied
+  up            apply every migration which is not applied yet
+  down [steps]  roll back the latest applied migrations (1 by default)`
+
 func main() {
 	router := han.NewRouter()
 	err := clickhouse_handlers.InitConnection()
@@ -22,6 +36,68 @@ func main() {
 
 	defer clickhouse_handlers.CloseConnection()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Schema is migrated by single `migrate up` job, replicas can't do it concurrently
+	if err := clickhouse_handlers.CheckMigrations(); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	kafkaURL, ok := os.LookupEnv("KAFKA_URL")
+	if ok {
+		go kafka_handlers.ConsumeUserDeletions(context.Background(), kafkaURL, clickhouse_handlers.DeleteUserStatistics)
+	} else {
+		log.Printf("No KAFKA_URL setted, statistics of deleted users will not be deleted")
+	}
+
 	log.Printf("Statistics service is starting...")
 	log.Fatal(http.ListenAndServe(":8090", router))
 }
+
+// Subcommand `migrate` which manages schema of ClickHouse without starting the service
+func migrate(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := clickhouse_handlers.GetMigrationStatus()
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "not applied"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.String()
+			}
+			fmt.Fprintf(writer, "%v\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return writer.Flush()
+	case "up":
+		applied, err := clickhouse_handlers.Migrate()
+		log.Printf("%v migrations have been applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("number of steps should be a positive integer")
+			}
+		}
+		reverted, err := clickhouse_handlers.Rollback(steps)
+		log.Printf("%v migrations have been rolled back", reverted)
+		return err
+	default:
+		return fmt.Errorf("%s", migrateUsage)
+	}
+}
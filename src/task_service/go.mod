@@ -3,6 +3,7 @@ module task_service
 go 1.22.0
 
 require (
+	github.com/lib/pq v1.10.9
 	google.golang.org/grpc v1.62.1
 	google.golang.org/protobuf v1.32.0
 	postgres v0.0.0
@@ -10,7 +11,6 @@ require (
 
 require (
 	github.com/golang/protobuf v1.5.3 // indirect
-	github.com/lib/pq v1.10.9 // indirect
 	golang.org/x/net v0.20.0 // indirect
 	golang.org/x/sys v0.16.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
@@ -1,16 +1,35 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net"
+	"os"
+	"postgres"
+	"strconv"
 	task_servicepb "task_service/proto"
 	task_service "task_service/task_service_handlers"
+	"text/tabwriter"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
+const migrateUsage = `Usage: task_service migrate <command>
+
+Commands:
+  status        show migrations and whether they are applied
+  up            apply every migration which is not applied yet
+  down [steps]  roll back the latest applied migrations (1 by default)`
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	lis, err := net.Listen("tcp", "0.0.0.0:8081")
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
@@ -32,3 +51,52 @@ func main() {
 		log.Fatalf("grpcServer failed")
 	}
 }
+
+// Subcommand `migrate` which manages schema of PostgreSQL without starting the service
+func migrate(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	db := postgres.InitPostgreSQLClient()
+	if db == nil {
+		return fmt.Errorf("no connection to PostgreSQL")
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "status":
+		statuses, err := postgres.GetMigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "not applied"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.String()
+			}
+			fmt.Fprintf(writer, "%v\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return writer.Flush()
+	case "up":
+		applied, err := postgres.Migrate(db)
+		log.Printf("%v migrations have been applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("number of steps should be a positive integer")
+			}
+		}
+		reverted, err := postgres.Rollback(db, steps)
+		log.Printf("%v migrations have been rolled back", reverted)
+		return err
+	default:
+		return fmt.Errorf("%s", migrateUsage)
+	}
+}
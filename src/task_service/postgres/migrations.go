@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Versioned migrations of database schema
+//
+//	Migrations are SQL files in `migrations` directory named `<version>_<name>.up.sql` and
+//	`<version>_<name>.down.sql`, where down migration reverts up one. Applied versions are stored in
+//	`schema_migrations` table. Every migration is applied in its own transaction together with its
+//	record, and the whole run holds advisory lock, so replicas of task_service started at the same
+//	time don't apply migrations twice
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Key of advisory lock which is held while migrations are applied or rolled back
+const migrationsLockKey = 20240313
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	// Nil if migration is not applied
+	AppliedAt *time.Time
+}
+
+// Read migrations from `migrations` directory, sorted by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected name of migration file `%s`", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration `%s`: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations `%s` and `%s` have the same version %v", migration.Name, match[2], version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %v_%s should have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Get every migration with time when it was applied
+func GetMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	if db == nil {
+		return nil, fmt.Errorf("no connection to PostgreSQL")
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	return getMigrationStatus(ctx, conn)
+}
+
+// Apply every migration which is not applied yet. Returns number of applied migrations
+func Migrate(db *sql.DB) (applied int, err error) {
+	err = withMigrationsLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		statuses, err := getMigrationStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.AppliedAt != nil {
+				continue
+			}
+			err = runMigration(ctx, conn, status.Version, status.Up,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", status.Version, status.Name)
+			if err != nil {
+				return fmt.Errorf("migration %v_%s failed: %w", status.Version, status.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Revert `steps` latest applied migrations. Returns number of reverted migrations
+func Rollback(db *sql.DB, steps int) (reverted int, err error) {
+	err = withMigrationsLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		statuses, err := getMigrationStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0 && reverted < steps; i-- {
+			status := statuses[i]
+			if status.AppliedAt == nil {
+				continue
+			}
+			err = runMigration(ctx, conn, status.Version, status.Down,
+				"DELETE FROM schema_migrations WHERE version = $1", status.Version)
+			if err != nil {
+				return fmt.Errorf("rollback of migration %v_%s failed: %w", status.Version, status.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+func createMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create `schema_migrations` table: %w", err)
+	}
+	return nil
+}
+
+// Run `f` while advisory lock is held. Lock belongs to session, so everything is done in one connection
+func withMigrationsLock(db *sql.DB, f func(ctx context.Context, conn *sql.Conn) error) error {
+	if db == nil {
+		return fmt.Errorf("no connection to PostgreSQL")
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationsLockKey)
+
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return f(ctx, conn)
+}
+
+// Migrations from files joined with applied versions from database
+func getMigrationStatus(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	// Database was migrated by newer version of task_service
+	if len(applied) > 0 {
+		unknown := []int{}
+		for version := range applied {
+			unknown = append(unknown, version)
+		}
+		sort.Ints(unknown)
+		return nil, fmt.Errorf("applied migrations %v are unknown to this version of task_service", unknown)
+	}
+	return statuses, nil
+}
+
+// Execute migration's SQL and update `schema_migrations` in one transaction
+func runMigration(ctx context.Context, conn *sql.Conn, version int, script string, record string, args ...any) error {
+	txn, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	if _, err := txn.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := txn.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %v: %w", version, err)
+	}
+	return txn.Commit()
+}
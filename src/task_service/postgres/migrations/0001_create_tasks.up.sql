@@ -1,3 +1,4 @@
+-- Schema which was created by `init.sql` before migrations were introduced
 CREATE TABLE IF NOT EXISTS task_service_db (
     id SERIAL PRIMARY KEY,
     creator_username TEXT NOT NULL,
@@ -5,4 +6,4 @@ CREATE TABLE IF NOT EXISTS task_service_db (
     title TEXT NOT NULL,
     description TEXT NOT NULL,
     status TEXT NOT NULL
-);
\ No newline at end of file
+);
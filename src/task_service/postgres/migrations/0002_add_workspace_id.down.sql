@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS task_service_db_workspace_id_idx;
+
+ALTER TABLE task_service_db DROP COLUMN IF EXISTS workspace_id;
@@ -0,0 +1,4 @@
+-- Empty for tasks outside of workspaces
+ALTER TABLE task_service_db ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS task_service_db_workspace_id_idx ON task_service_db (workspace_id);
@@ -0,0 +1,4 @@
+ALTER TABLE task_service_db ALTER COLUMN task_id DROP IDENTITY;
+ALTER TABLE task_service_db DROP CONSTRAINT task_service_db_pkey;
+ALTER TABLE task_service_db ALTER COLUMN task_id TYPE TEXT;
+ALTER TABLE task_service_db ADD COLUMN id SERIAL PRIMARY KEY;
@@ -0,0 +1,35 @@
+-- IDs were generated by in-memory counter of task_service and stored as text.
+-- Tables which were created with integer identity `task_id` by `init.sql` are already converted
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'task_service_db' AND column_name = 'id'
+    ) THEN
+        RETURN;
+    END IF;
+
+    ALTER TABLE task_service_db ALTER COLUMN task_id TYPE INTEGER USING task_id::INTEGER;
+
+    -- Counter started from 1 after every restart, so IDs could repeat.
+    -- The oldest task keeps its ID, the others get new IDs after the maximal one
+    WITH duplicates AS (
+        SELECT id, ROW_NUMBER() OVER (ORDER BY id) AS number
+        FROM (
+            SELECT id, ROW_NUMBER() OVER (PARTITION BY task_id ORDER BY id) AS copy
+            FROM task_service_db
+        ) AS copies
+        WHERE copy > 1
+    )
+    UPDATE task_service_db
+    SET task_id = (SELECT MAX(task_id) FROM task_service_db) + duplicates.number
+    FROM duplicates
+    WHERE task_service_db.id = duplicates.id;
+
+    -- IDs are allocated by database, so they are unique across restarts and replicas of task_service
+    ALTER TABLE task_service_db DROP COLUMN id;
+    ALTER TABLE task_service_db ADD PRIMARY KEY (task_id);
+    ALTER TABLE task_service_db ALTER COLUMN task_id ADD GENERATED ALWAYS AS IDENTITY;
+    PERFORM setval(pg_get_serial_sequence('task_service_db', 'task_id'), COALESCE(MAX(task_id), 0) + 1, false) FROM task_service_db;
+END
+$$;
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS task_status_transitions;
+
+DROP TABLE IF EXISTS task_workflows;
+
+DROP INDEX IF EXISTS task_service_db_status_idx;
+
+UPDATE task_service_db
+SET status = CASE status
+    WHEN 'TASK_STATUS_IN_PROGRESS' THEN 'in progress'
+    WHEN 'TASK_STATUS_CAN_BE_TESTED' THEN 'can be tested'
+    WHEN 'TASK_STATUS_DONE' THEN 'done'
+    WHEN 'TASK_STATUS_CANCELLED' THEN 'cancelled'
+    ELSE 'not taken'
+END;
@@ -0,0 +1,32 @@
+-- Statuses are stored as names of `TaskStatus` enum. Free-form statuses which were used before
+-- are converted to the closest status, unknown ones become `TASK_STATUS_NOT_TAKEN`
+UPDATE task_service_db
+SET status = CASE lower(trim(status))
+    WHEN 'in progress' THEN 'TASK_STATUS_IN_PROGRESS'
+    WHEN 'can be tested' THEN 'TASK_STATUS_CAN_BE_TESTED'
+    WHEN 'ready' THEN 'TASK_STATUS_DONE'
+    WHEN 'done' THEN 'TASK_STATUS_DONE'
+    WHEN 'cancelled' THEN 'TASK_STATUS_CANCELLED'
+    ELSE 'TASK_STATUS_NOT_TAKEN'
+END;
+
+CREATE INDEX IF NOT EXISTS task_service_db_status_idx ON task_service_db (workspace_id, status);
+
+-- Workflows configured for workspaces. Other workspaces use the default workflow of task_service
+CREATE TABLE IF NOT EXISTS task_workflows (
+    workspace_id TEXT PRIMARY KEY,
+    -- `Workflow` message in JSON
+    workflow JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS task_status_transitions (
+    transition_id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES task_service_db (task_id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    username TEXT NOT NULL,
+    transitioned_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_status_transitions_task_id_idx ON task_status_transitions (task_id);
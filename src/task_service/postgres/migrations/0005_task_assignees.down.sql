@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS task_service_db_creator_username_idx;
+
+DROP TABLE IF EXISTS task_assignees;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS task_assignees (
+    task_id INTEGER NOT NULL REFERENCES task_service_db (task_id) ON DELETE CASCADE,
+    username TEXT NOT NULL,
+    assigned_by TEXT NOT NULL,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (task_id, username)
+);
+
+-- Tasks assigned to user
+CREATE INDEX IF NOT EXISTS task_assignees_username_idx ON task_assignees (username);
+
+-- Tasks created by user
+CREATE INDEX IF NOT EXISTS task_service_db_creator_username_idx ON task_service_db (creator_username);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS task_service_db_due_at_idx;
+
+ALTER TABLE task_service_db DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE task_service_db DROP COLUMN IF EXISTS created_at;
+ALTER TABLE task_service_db DROP COLUMN IF EXISTS due_at;
+ALTER TABLE task_service_db DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,18 @@
+-- Priorities are stored as names of `TaskPriority` enum, existing tasks get the default one
+ALTER TABLE task_service_db ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'TASK_PRIORITY_P2';
+ALTER TABLE task_service_db ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE task_service_db ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE task_service_db ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Tasks created after status history was introduced get times from it, older tasks get time of migration
+UPDATE task_service_db
+SET created_at = history.created_at, updated_at = history.updated_at
+FROM (
+    SELECT task_id, MIN(transitioned_at) AS created_at, MAX(transitioned_at) AS updated_at
+    FROM task_status_transitions
+    GROUP BY task_id
+) AS history
+WHERE task_service_db.task_id = history.task_id;
+
+-- Overdue tasks and tasks with close deadline
+CREATE INDEX IF NOT EXISTS task_service_db_due_at_idx ON task_service_db (due_at) WHERE due_at IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE task_workflows DROP COLUMN IF EXISTS terminal_statuses;
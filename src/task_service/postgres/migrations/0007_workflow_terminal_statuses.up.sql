@@ -0,0 +1,5 @@
+-- Terminal statuses of workflow are stored in column too, so lists of tasks by deadline don't parse JSON of workflows
+ALTER TABLE task_workflows ADD COLUMN IF NOT EXISTS terminal_statuses TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE task_workflows
+SET terminal_statuses = ARRAY(SELECT jsonb_array_elements_text(COALESCE(workflow->'terminalStatuses', '[]'::JSONB)));
@@ -19,11 +19,17 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	TaskService_CreateTask_FullMethodName  = "/task_service.TaskService/CreateTask"
-	TaskService_UpdateTask_FullMethodName  = "/task_service.TaskService/UpdateTask"
-	TaskService_DeleteTask_FullMethodName  = "/task_service.TaskService/DeleteTask"
-	TaskService_GetTaskById_FullMethodName = "/task_service.TaskService/GetTaskById"
-	TaskService_GetTaskList_FullMethodName = "/task_service.TaskService/GetTaskList"
+	TaskService_CreateTask_FullMethodName       = "/task_service.TaskService/CreateTask"
+	TaskService_UpdateTask_FullMethodName       = "/task_service.TaskService/UpdateTask"
+	TaskService_DeleteTask_FullMethodName       = "/task_service.TaskService/DeleteTask"
+	TaskService_GetTaskById_FullMethodName      = "/task_service.TaskService/GetTaskById"
+	TaskService_GetTaskList_FullMethodName      = "/task_service.TaskService/GetTaskList"
+	TaskService_DeleteUserTasks_FullMethodName  = "/task_service.TaskService/DeleteUserTasks"
+	TaskService_GetWorkflow_FullMethodName      = "/task_service.TaskService/GetWorkflow"
+	TaskService_SetWorkflow_FullMethodName      = "/task_service.TaskService/SetWorkflow"
+	TaskService_GetStatusHistory_FullMethodName = "/task_service.TaskService/GetStatusHistory"
+	TaskService_AssignTask_FullMethodName       = "/task_service.TaskService/AssignTask"
+	TaskService_UnassignTask_FullMethodName     = "/task_service.TaskService/UnassignTask"
 )
 
 // TaskServiceClient is the client API for TaskService service.
@@ -35,6 +41,24 @@ type TaskServiceClient interface {
 	DeleteTask(ctx context.Context, in *RequestByID, opts ...grpc.CallOption) (*TaskID, error)
 	GetTaskById(ctx context.Context, in *RequestByID, opts ...grpc.CallOption) (*Task, error)
 	GetTaskList(ctx context.Context, in *TaskPageRequest, opts ...grpc.CallOption) (*TaskList, error)
+	// Delete tasks of user outside of workspaces, remove his name from his tasks in workspaces and
+	// delete his assignments to other tasks (when account is deleted).
+	// Deleting tasks of user without tasks is not an error
+	DeleteUserTasks(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*DeletedTasks, error)
+	// Workflow of workspace. Returns the default workflow if workspace has no configured one
+	GetWorkflow(ctx context.Context, in *WorkflowRequest, opts ...grpc.CallOption) (*Workflow, error)
+	// Replace workflow of workspace. Fails with `FailedPrecondition` if some tasks of workspace have
+	// statuses which are not in the new workflow
+	SetWorkflow(ctx context.Context, in *Workflow, opts ...grpc.CallOption) (*Workflow, error)
+	// Transitions of task's status, the oldest first
+	GetStatusHistory(ctx context.Context, in *RequestByID, opts ...grpc.CallOption) (*StatusHistory, error)
+	// Add assignee to task. Only author of task and moderators can assign users, assigning user twice is
+	// not an error. Existence of assignee is checked by caller.
+	// Fails with `NotFound` if task doesn't exist and with `PermissionDenied` if requestor can't assign users
+	AssignTask(ctx context.Context, in *AssigneeRequest, opts ...grpc.CallOption) (*TaskID, error)
+	// Remove assignee from task. Author of task, moderators and assignee himself can do it.
+	// Fails with `NotFound` if user is not assigned
+	UnassignTask(ctx context.Context, in *AssigneeRequest, opts ...grpc.CallOption) (*TaskID, error)
 }
 
 type taskServiceClient struct {
@@ -90,6 +114,60 @@ func (c *taskServiceClient) GetTaskList(ctx context.Context, in *TaskPageRequest
 	return out, nil
 }
 
+func (c *taskServiceClient) DeleteUserTasks(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*DeletedTasks, error) {
+	out := new(DeletedTasks)
+	err := c.cc.Invoke(ctx, TaskService_DeleteUserTasks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) GetWorkflow(ctx context.Context, in *WorkflowRequest, opts ...grpc.CallOption) (*Workflow, error) {
+	out := new(Workflow)
+	err := c.cc.Invoke(ctx, TaskService_GetWorkflow_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) SetWorkflow(ctx context.Context, in *Workflow, opts ...grpc.CallOption) (*Workflow, error) {
+	out := new(Workflow)
+	err := c.cc.Invoke(ctx, TaskService_SetWorkflow_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) GetStatusHistory(ctx context.Context, in *RequestByID, opts ...grpc.CallOption) (*StatusHistory, error) {
+	out := new(StatusHistory)
+	err := c.cc.Invoke(ctx, TaskService_GetStatusHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) AssignTask(ctx context.Context, in *AssigneeRequest, opts ...grpc.CallOption) (*TaskID, error) {
+	out := new(TaskID)
+	err := c.cc.Invoke(ctx, TaskService_AssignTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taskServiceClient) UnassignTask(ctx context.Context, in *AssigneeRequest, opts ...grpc.CallOption) (*TaskID, error) {
+	out := new(TaskID)
+	err := c.cc.Invoke(ctx, TaskService_UnassignTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TaskServiceServer is the server API for TaskService service.
 // All implementations must embed UnimplementedTaskServiceServer
 // for forward compatibility
@@ -99,6 +177,24 @@ type TaskServiceServer interface {
 	DeleteTask(context.Context, *RequestByID) (*TaskID, error)
 	GetTaskById(context.Context, *RequestByID) (*Task, error)
 	GetTaskList(context.Context, *TaskPageRequest) (*TaskList, error)
+	// Delete tasks of user outside of workspaces, remove his name from his tasks in workspaces and
+	// delete his assignments to other tasks (when account is deleted).
+	// Deleting tasks of user without tasks is not an error
+	DeleteUserTasks(context.Context, *UserRequest) (*DeletedTasks, error)
+	// Workflow of workspace. Returns the default workflow if workspace has no configured one
+	GetWorkflow(context.Context, *WorkflowRequest) (*Workflow, error)
+	// Replace workflow of workspace. Fails with `FailedPrecondition` if some tasks of workspace have
+	// statuses which are not in the new workflow
+	SetWorkflow(context.Context, *Workflow) (*Workflow, error)
+	// Transitions of task's status, the oldest first
+	GetStatusHistory(context.Context, *RequestByID) (*StatusHistory, error)
+	// Add assignee to task. Only author of task and moderators can assign users, assigning user twice is
+	// not an error. Existence of assignee is checked by caller.
+	// Fails with `NotFound` if task doesn't exist and with `PermissionDenied` if requestor can't assign users
+	AssignTask(context.Context, *AssigneeRequest) (*TaskID, error)
+	// Remove assignee from task. Author of task, moderators and assignee himself can do it.
+	// Fails with `NotFound` if user is not assigned
+	UnassignTask(context.Context, *AssigneeRequest) (*TaskID, error)
 	mustEmbedUnimplementedTaskServiceServer()
 }
 
@@ -121,6 +217,24 @@ func (UnimplementedTaskServiceServer) GetTaskById(context.Context, *RequestByID)
 func (UnimplementedTaskServiceServer) GetTaskList(context.Context, *TaskPageRequest) (*TaskList, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTaskList not implemented")
 }
+func (UnimplementedTaskServiceServer) DeleteUserTasks(context.Context, *UserRequest) (*DeletedTasks, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserTasks not implemented")
+}
+func (UnimplementedTaskServiceServer) GetWorkflow(context.Context, *WorkflowRequest) (*Workflow, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorkflow not implemented")
+}
+func (UnimplementedTaskServiceServer) SetWorkflow(context.Context, *Workflow) (*Workflow, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWorkflow not implemented")
+}
+func (UnimplementedTaskServiceServer) GetStatusHistory(context.Context, *RequestByID) (*StatusHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatusHistory not implemented")
+}
+func (UnimplementedTaskServiceServer) AssignTask(context.Context, *AssigneeRequest) (*TaskID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignTask not implemented")
+}
+func (UnimplementedTaskServiceServer) UnassignTask(context.Context, *AssigneeRequest) (*TaskID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnassignTask not implemented")
+}
 func (UnimplementedTaskServiceServer) mustEmbedUnimplementedTaskServiceServer() {}
 
 // UnsafeTaskServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -224,6 +338,114 @@ func _TaskService_GetTaskList_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TaskService_DeleteUserTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).DeleteUserTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_DeleteUserTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).DeleteUserTasks(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_GetWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WorkflowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).GetWorkflow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_GetWorkflow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).GetWorkflow(ctx, req.(*WorkflowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_SetWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Workflow)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).SetWorkflow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_SetWorkflow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).SetWorkflow(ctx, req.(*Workflow))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_GetStatusHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestByID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).GetStatusHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_GetStatusHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).GetStatusHistory(ctx, req.(*RequestByID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_AssignTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssigneeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).AssignTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_AssignTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).AssignTask(ctx, req.(*AssigneeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TaskService_UnassignTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssigneeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaskServiceServer).UnassignTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaskService_UnassignTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaskServiceServer).UnassignTask(ctx, req.(*AssigneeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TaskService_ServiceDesc is the grpc.ServiceDesc for TaskService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -251,6 +473,30 @@ var TaskService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTaskList",
 			Handler:    _TaskService_GetTaskList_Handler,
 		},
+		{
+			MethodName: "DeleteUserTasks",
+			Handler:    _TaskService_DeleteUserTasks_Handler,
+		},
+		{
+			MethodName: "GetWorkflow",
+			Handler:    _TaskService_GetWorkflow_Handler,
+		},
+		{
+			MethodName: "SetWorkflow",
+			Handler:    _TaskService_SetWorkflow_Handler,
+		},
+		{
+			MethodName: "GetStatusHistory",
+			Handler:    _TaskService_GetStatusHistory_Handler,
+		},
+		{
+			MethodName: "AssignTask",
+			Handler:    _TaskService_AssignTask_Handler,
+		},
+		{
+			MethodName: "UnassignTask",
+			Handler:    _TaskService_UnassignTask_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "task_service.proto",
@@ -3,42 +3,94 @@ package task_service
 import (
 	"context"
 	"database/sql"
-	"sync/atomic"
+	"errors"
+	"log"
+	"time"
 
 	postgres "postgres"
 	task_servicepb "task_service/proto"
 
+	"github.com/lib/pq"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Server struct {
 	task_servicepb.UnimplementedTaskServiceServer
-	db          *sql.DB
-	taskCounter atomic.Int64
+	db *sql.DB
 }
 
+// Connect to PostgreSQL and bring its schema to the latest version
 func NewServer() (server *Server, err error) {
 	server = &Server{}
-	server.taskCounter.Store(0)
 	server.db = postgres.InitPostgreSQLClient()
-	return
+	if server.db == nil {
+		return nil, errors.New("no connection to PostgreSQL")
+	}
+
+	applied, err := postgres.Migrate(server.db)
+	if err != nil {
+		return nil, err
+	}
+	if applied > 0 {
+		log.Printf("%v migrations have been applied", applied)
+	}
+	return server, nil
 }
 
-func GenerateTaskID(s *Server) int32 {
-	return int32(s.taskCounter.Add(1))
+// Moderators and admins can update and delete tasks of other users
+func canModerate(role task_servicepb.UserRole) bool {
+	return role >= task_servicepb.UserRole_USER_ROLE_MODERATOR
 }
 
 func (s *Server) CreateTask(ctx context.Context, request *task_servicepb.TaskContent) (*task_servicepb.TaskID, error) {
-	taskID := GenerateTaskID(s)
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &task_servicepb.TaskID{}, status.Errorf(codes.Internal, "[CreateTask] Failed to start transaction. Error message: %v", err)
+	}
+	defer txn.Rollback()
+
+	// Task gets initial status of workspace's workflow if status is not specified
+	workflow, err := getWorkflow(ctx, txn, request.WorkspaceId)
+	if err != nil {
+		return &task_servicepb.TaskID{}, status.Errorf(codes.Internal, "[CreateTask] Failed to get workflow of workspace `%v`. Error message: %v", request.WorkspaceId, err)
+	}
+	taskStatus := request.Status
+	if taskStatus == task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		taskStatus = workflow.InitialStatus
+	}
+	if !hasStatus(workflow.Statuses, taskStatus) {
+		return &task_servicepb.TaskID{}, status.Errorf(codes.FailedPrecondition, "[CreateTask] Status %v is not used by workflow of workspace `%v`", taskStatus, request.WorkspaceId)
+	}
+	priority := request.Priority
+	if priority == task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED {
+		priority = defaultPriority
+	}
+	if !isKnownPriority(priority) {
+		return &task_servicepb.TaskID{}, status.Errorf(codes.InvalidArgument, "[CreateTask] Unknown priority %v", priority)
+	}
 
-	_, err := s.db.ExecContext(
+	// ID is allocated by identity column, so it's unique across restarts and replicas of the service
+	var taskID int32
+	err = txn.QueryRowContext(
 		ctx,
-		"INSERT INTO task_service_db (creator_username, task_id, title, description, status) VALUES ($1, $2, $3, $4, $5)",
-		request.CreatorUsername, taskID, request.Title, request.Description, request.Status,
-	)
+		"INSERT INTO task_service_db (creator_username, title, description, status, workspace_id, priority, due_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING task_id",
+		request.CreatorUsername, request.Title, request.Description, taskStatus.String(), request.WorkspaceId, priority.String(), nullTime(request.DueAt),
+	).Scan(&taskID)
+	if err != nil {
+		return &task_servicepb.TaskID{}, status.Errorf(codes.Internal, "[CreateTask] Insert new task into db has been failed. Error message: %v", err)
+	}
+
+	err = recordTransition(ctx, txn, taskID, task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED, taskStatus, request.CreatorUsername)
+	if err != nil {
+		return &task_servicepb.TaskID{}, status.Errorf(codes.Internal, "[CreateTask] Failed to record status of new task. Error message: %v", err)
+	}
+
+	err = txn.Commit()
 	if err != nil {
-		return &task_servicepb.TaskID{Id: taskID}, status.Errorf(codes.Internal, "[CreateTask] Insert new task into db has been failed, taskID: %v", taskID)
+		return &task_servicepb.TaskID{}, status.Errorf(codes.Internal, "[CreateTask] Failed to commit transaction. Error message: %v", err)
 	}
 
 	return &task_servicepb.TaskID{Id: taskID}, nil
@@ -54,23 +106,56 @@ func (s *Server) UpdateTask(ctx context.Context, request *task_servicepb.Task) (
 	}
 	defer txn.Rollback()
 
-	// Get user's task to check if it exists
-	count := 0
-	txn.QueryRowContext(
+	// Get user's task to check if it exists. Moderators can get task of any user.
+	// Row is locked, so concurrent updates can't both move task from the same status
+	var currentStatus, workspaceID, currentPriority string
+	moderator := canModerate(request.RequestorRole)
+	err = txn.QueryRowContext(
 		ctx,
-		"SELECT COUNT(*) FROM task_service_db WHERE creator_username = $1 AND task_id = $2",
-		request.Task.CreatorUsername, request.Id,
-	).Scan(&count)
-	// If tasks are not 1
-	if count != 1 {
-		return &taskID, status.Errorf(codes.NotFound, "[UpdateTask] Expected to find 1 task by user: `%v`, with ID: %v, but found %v", request.Id, request.Task.CreatorUsername, count)
+		"SELECT status, workspace_id, priority FROM task_service_db WHERE (creator_username = $1 OR $3) AND task_id = $2 FOR UPDATE",
+		request.Task.CreatorUsername, request.Id, moderator,
+	).Scan(&currentStatus, &workspaceID, &currentPriority)
+	if err == sql.ErrNoRows {
+		return &taskID, status.Errorf(codes.NotFound, "[UpdateTask] Expected to find 1 task by user: `%v`, with ID: %v, but found 0", request.Task.CreatorUsername, request.Id)
+	}
+	if err != nil {
+		return &taskID, status.Errorf(codes.Internal, "[UpdateTask] Failed to get task with ID: %v. Error message: %v", request.Id, err)
+	}
+
+	// Unspecified status keeps the current one, other changes of status should be allowed by workflow
+	from := statusFromString(currentStatus)
+	to := request.Task.Status
+	if to == task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		to = from
+	}
+	if to != from {
+		workflow, err := getWorkflow(ctx, txn, workspaceID)
+		if err != nil {
+			return &taskID, status.Errorf(codes.Internal, "[UpdateTask] Failed to get workflow of workspace `%v`. Error message: %v", workspaceID, err)
+		}
+		if !workflowAllows(workflow, from, to) {
+			return &taskID, status.Errorf(codes.FailedPrecondition, "[UpdateTask] Workflow of workspace `%v` doesn't allow transition from %v to %v", workspaceID, from, to)
+		}
+		err = recordTransition(ctx, txn, request.Id, from, to, request.Task.CreatorUsername)
+		if err != nil {
+			return &taskID, status.Errorf(codes.Internal, "[UpdateTask] Failed to record transition of task with ID: %v. Error message: %v", request.Id, err)
+		}
+	}
+
+	// Unspecified priority keeps the current one
+	priority := request.Task.Priority
+	if priority == task_servicepb.TaskPriority_TASK_PRIORITY_UNSPECIFIED {
+		priority = priorityFromString(currentPriority)
+	}
+	if !isKnownPriority(priority) {
+		return &taskID, status.Errorf(codes.InvalidArgument, "[UpdateTask] Unknown priority %v", priority)
 	}
 
 	// Update user's task
 	_, err = txn.ExecContext(
 		ctx,
-		"UPDATE task_service_db SET title = $1, description = $2, status = $3 WHERE creator_username = $4 AND task_id = $5",
-		request.Task.Title, request.Task.Description, request.Task.Status, request.Task.CreatorUsername, request.Id,
+		"UPDATE task_service_db SET title = $1, description = $2, status = $3, priority = $4, due_at = $5, updated_at = now() WHERE task_id = $6",
+		request.Task.Title, request.Task.Description, to.String(), priority.String(), nullTime(request.Task.DueAt), request.Id,
 	)
 	if err != nil {
 		return &taskID, status.Errorf(codes.Internal, "[UpdateTask] Failed to update task by user: `%v`, with ID: %v. Error message: %e", request.Task.CreatorUsername, request.Id, err)
@@ -95,12 +180,13 @@ func (s *Server) DeleteTask(ctx context.Context, request *task_servicepb.Request
 	}
 	defer txn.Rollback()
 
-	// Get user's task to check if it exists
+	// Get user's task to check if it exists. Moderators can get task of any user
 	count := 0
+	moderator := canModerate(request.RequestorRole)
 	txn.QueryRowContext(
 		ctx,
-		"SELECT COUNT(*) FROM task_service_db WHERE creator_username = $1 AND task_id = $2",
-		request.RequestorUsername, request.Id,
+		"SELECT COUNT(*) FROM task_service_db WHERE (creator_username = $1 OR $3) AND task_id = $2",
+		request.RequestorUsername, request.Id, moderator,
 	).Scan(&count)
 	// If tasks are not 1
 	if count != 1 {
@@ -110,8 +196,8 @@ func (s *Server) DeleteTask(ctx context.Context, request *task_servicepb.Request
 	// Delete user's task
 	_, err = txn.ExecContext(
 		ctx,
-		"DELETE FROM task_service_db WHERE creator_username = $1 AND task_id = $2",
-		request.RequestorUsername, request.Id,
+		"DELETE FROM task_service_db WHERE (creator_username = $1 OR $3) AND task_id = $2",
+		request.RequestorUsername, request.Id, moderator,
 	)
 	if err != nil {
 		return &taskID, status.Errorf(codes.Internal, "[DeleteTask] Failed to delete task by user: %v, with ID: %v. Error message: %e", request.RequestorUsername, request.Id, err)
@@ -126,13 +212,13 @@ func (s *Server) DeleteTask(ctx context.Context, request *task_servicepb.Request
 }
 
 func (s *Server) GetTaskById(ctx context.Context, request *task_servicepb.RequestByID) (*task_servicepb.Task, error) {
-	var title, description, taskStatus, creator string
 	// Get row with answer
-	err := s.db.QueryRowContext(
+	row := s.db.QueryRowContext(
 		ctx,
-		"SELECT title, description, status, creator_username FROM task_service_db WHERE task_id = $1",
+		"SELECT "+taskColumns+" FROM task_service_db WHERE task_id = $1",
 		request.Id,
-	).Scan(&title, &description, &taskStatus, &creator)
+	)
+	task, err := scanTask(row.Scan)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return &task_servicepb.Task{}, status.Errorf(codes.NotFound, "[GetTaskById] Task with ID %v doesn't exist", request.Id)
@@ -141,23 +227,43 @@ func (s *Server) GetTaskById(ctx context.Context, request *task_servicepb.Reques
 		}
 	}
 
-	return &task_servicepb.Task{
-		Id: request.Id,
-		Task: &task_servicepb.TaskContent{
-			Title:           title,
-			Description:     description,
-			Status:          taskStatus,
-			CreatorUsername: creator,
-		},
-	}, nil
+	return task, nil
 }
 
 func (s *Server) GetTaskList(ctx context.Context, request *task_servicepb.TaskPageRequest) (*task_servicepb.TaskList, error) {
-	// Get rows with tasks by offset and limit
+	// Unspecified status means tasks with any status
+	statusFilter := ""
+	if request.Status != task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		statusFilter = request.Status.String()
+	}
+
+	if request.DueWithinDays < 0 {
+		return &task_servicepb.TaskList{}, status.Errorf(codes.InvalidArgument, "[GetTaskList] Number of days should not be negative")
+	}
+	if request.Overdue && request.DueWithinDays > 0 {
+		return &task_servicepb.TaskList{}, status.Errorf(codes.InvalidArgument, "[GetTaskList] Overdue tasks and tasks due within %v days can't be requested together", request.DueWithinDays)
+	}
+	byDeadline := request.Overdue || request.DueWithinDays > 0
+
+	// Get rows with tasks by offset and limit. Empty creator and involved usernames mean tasks of all users.
+	// Views by deadline skip finished tasks: their status is terminal in workflow of their workspace,
+	// workspaces without configured workflow use terminal statuses of the default one
 	rows, err := s.db.QueryContext(
 		ctx,
-		"SELECT task_id, title, description, status, creator_username FROM task_service_db ORDER BY task_id LIMIT $1 OFFSET $2",
-		request.PageSize, request.Offset,
+		"SELECT "+taskColumns+" FROM task_service_db "+
+			"WHERE ($3 = '' OR creator_username = $3) "+
+			"AND (CASE WHEN cardinality($8::TEXT[]) > 0 THEN workspace_id = ANY($8) ELSE ($5 OR workspace_id = $4) END) "+
+			"AND ($6 = '' OR status = $6) "+
+			"AND ($7 = '' OR creator_username = $7 OR EXISTS (SELECT 1 FROM task_assignees WHERE task_assignees.task_id = task_service_db.task_id AND username = $7)) "+
+			"AND (NOT $9 OR due_at < now()) "+
+			"AND ($10 = 0 OR due_at BETWEEN now() AND now() + make_interval(days => $10)) "+
+			"AND (NOT ($9 OR $10 > 0) OR NOT status = ANY(COALESCE("+
+			"(SELECT terminal_statuses FROM task_workflows WHERE task_workflows.workspace_id = task_service_db.workspace_id), "+
+			"$11::TEXT[]))) "+
+			"ORDER BY CASE WHEN $12 THEN due_at END, task_id LIMIT $1 OFFSET $2",
+		request.PageSize, request.Offset, request.CreatorUsername, request.WorkspaceId, request.AnyWorkspace, statusFilter,
+		request.InvolvedUsername, pq.Array(request.WorkspaceIds), request.Overdue, request.DueWithinDays,
+		pq.Array(statusNames(DefaultWorkflow("").TerminalStatuses)), byDeadline,
 	)
 	if err != nil {
 		return &task_servicepb.TaskList{}, status.Errorf(codes.Internal, "[GetTaskList] Failed to get page of tasks with offset: %v, page size: %v", request.Offset, request.PageSize)
@@ -169,13 +275,7 @@ func (s *Server) GetTaskList(ctx context.Context, request *task_servicepb.TaskPa
 
 	// Iterate through list of tasks and move them into struct
 	for rows.Next() {
-		// Empty task
-		task := &task_servicepb.Task{
-			Id:   0,
-			Task: &task_servicepb.TaskContent{},
-		}
-
-		err = rows.Scan(&task.Id, &task.Task.Title, &task.Task.Description, &task.Task.Status, &task.Task.CreatorUsername)
+		task, err := scanTask(rows.Scan)
 		if err != nil {
 			return &task_servicepb.TaskList{}, status.Errorf(codes.Internal, "[GetTaskList] %e", err)
 		}
@@ -186,3 +286,278 @@ func (s *Server) GetTaskList(ctx context.Context, request *task_servicepb.TaskPa
 
 	return &task_servicepb.TaskList{Tasks: tasks_list, PageSize: int32(len(tasks_list))}, nil
 }
+
+func (s *Server) DeleteUserTasks(ctx context.Context, request *task_servicepb.UserRequest) (*task_servicepb.DeletedTasks, error) {
+	if request.Username == "" {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.InvalidArgument, "[DeleteUserTasks] Username should not be empty")
+	}
+
+	// Delete tasks of user outside of workspaces and every task of deleted workspaces.
+	// Repeated call deletes nothing, so request can be safely retried
+	rows, err := s.db.QueryContext(
+		ctx,
+		"DELETE FROM task_service_db WHERE (creator_username = $1 AND workspace_id = '') OR workspace_id = ANY($2) RETURNING task_id",
+		request.Username, pq.Array(request.DeletedWorkspaceIds),
+	)
+	if err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to delete tasks of user: `%v`. Error message: %v", request.Username, err)
+	}
+	defer rows.Close()
+
+	var deletedTaskIDs []int32
+	for rows.Next() {
+		var taskID int32
+		if err := rows.Scan(&taskID); err != nil {
+			return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to read deleted tasks of user: `%v`. Error message: %v", request.Username, err)
+		}
+		deletedTaskIDs = append(deletedTaskIDs, taskID)
+	}
+	if err := rows.Err(); err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to read deleted tasks of user: `%v`. Error message: %v", request.Username, err)
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		"DELETE FROM task_workflows WHERE workspace_id = ANY($1)",
+		pq.Array(request.DeletedWorkspaceIds),
+	)
+	if err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to delete workflows of deleted workspaces. Error message: %v", err)
+	}
+
+	// Tasks of user in workspaces are shared with other members, so they are kept without creator
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE task_service_db SET creator_username = '' WHERE creator_username = $1",
+		request.Username,
+	)
+	if err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to anonymize tasks of user: `%v`. Error message: %v", request.Username, err)
+	}
+
+	anonymized, err := result.RowsAffected()
+	if err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to count anonymized tasks of user: `%v`. Error message: %v", request.Username, err)
+	}
+
+	// User could be assigned to tasks of other users
+	_, err = s.db.ExecContext(
+		ctx,
+		"DELETE FROM task_assignees WHERE username = $1",
+		request.Username,
+	)
+	if err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to unassign user: `%v` from tasks. Error message: %v", request.Username, err)
+	}
+
+	// Assignments made by user stay, but without his name
+	_, err = s.db.ExecContext(
+		ctx,
+		"UPDATE task_assignees SET assigned_by = '' WHERE assigned_by = $1",
+		request.Username,
+	)
+	if err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to delete user: `%v` from assignments. Error message: %v", request.Username, err)
+	}
+
+	// History of tasks is deleted together with them, but user could also change statuses of other tasks
+	_, err = s.db.ExecContext(
+		ctx,
+		"UPDATE task_status_transitions SET username = '' WHERE username = $1",
+		request.Username,
+	)
+	if err != nil {
+		return &task_servicepb.DeletedTasks{}, status.Errorf(codes.Internal, "[DeleteUserTasks] Failed to delete user: `%v` from history of tasks. Error message: %v", request.Username, err)
+	}
+
+	return &task_servicepb.DeletedTasks{Count: int32(len(deletedTaskIDs)), Anonymized: int32(anonymized), DeletedTaskIds: deletedTaskIDs}, nil
+}
+
+func (s *Server) GetWorkflow(ctx context.Context, request *task_servicepb.WorkflowRequest) (*task_servicepb.Workflow, error) {
+	txn, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[GetWorkflow] Failed to start transaction. Error message: %v", err)
+	}
+	defer txn.Rollback()
+
+	workflow, err := getWorkflow(ctx, txn, request.WorkspaceId)
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[GetWorkflow] Failed to get workflow of workspace `%v`. Error message: %v", request.WorkspaceId, err)
+	}
+	return workflow, nil
+}
+
+func (s *Server) SetWorkflow(ctx context.Context, request *task_servicepb.Workflow) (*task_servicepb.Workflow, error) {
+	if request.WorkspaceId == "" {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.InvalidArgument, "[SetWorkflow] Workflow of tasks outside of workspaces can't be changed")
+	}
+	if err := validateWorkflow(request); err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.InvalidArgument, "[SetWorkflow] %v", err)
+	}
+	data, err := protojson.Marshal(request)
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] Failed to marshal workflow. Error message: %v", err)
+	}
+
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] Failed to start transaction. Error message: %v", err)
+	}
+	defer txn.Rollback()
+
+	// Lock workflow first: lock is held until commit, so tasks can't be created or change status meanwhile
+	_, err = txn.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", workflowLockClass, request.WorkspaceId)
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] Failed to lock workflow of workspace `%v`. Error message: %v", request.WorkspaceId, err)
+	}
+
+	_, err = txn.ExecContext(
+		ctx,
+		"INSERT INTO task_workflows (workspace_id, workflow, terminal_statuses) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (workspace_id) DO UPDATE SET workflow = EXCLUDED.workflow, terminal_statuses = EXCLUDED.terminal_statuses, updated_at = now()",
+		request.WorkspaceId, data, pq.Array(statusNames(request.TerminalStatuses)),
+	)
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] Failed to save workflow of workspace `%v`. Error message: %v", request.WorkspaceId, err)
+	}
+
+	// Every task of workspace should still have a status of workflow
+	rows, err := txn.QueryContext(
+		ctx,
+		"SELECT DISTINCT status FROM task_service_db WHERE workspace_id = $1",
+		request.WorkspaceId,
+	)
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] Failed to get statuses of tasks of workspace `%v`. Error message: %v", request.WorkspaceId, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var taskStatus string
+		if err := rows.Scan(&taskStatus); err != nil {
+			return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] %v", err)
+		}
+		if !hasStatus(request.Statuses, statusFromString(taskStatus)) {
+			return &task_servicepb.Workflow{}, status.Errorf(codes.FailedPrecondition, "[SetWorkflow] Some tasks of workspace `%v` have status %v which is not in the new workflow", request.WorkspaceId, taskStatus)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] %v", err)
+	}
+
+	err = txn.Commit()
+	if err != nil {
+		return &task_servicepb.Workflow{}, status.Errorf(codes.Internal, "[SetWorkflow] Failed to commit transaction. Error message: %v", err)
+	}
+	return request, nil
+}
+
+func (s *Server) GetStatusHistory(ctx context.Context, request *task_servicepb.RequestByID) (*task_servicepb.StatusHistory, error) {
+	exists := false
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM task_service_db WHERE task_id = $1)",
+		request.Id,
+	).Scan(&exists)
+	if err != nil {
+		return &task_servicepb.StatusHistory{}, status.Errorf(codes.Internal, "[GetStatusHistory] Failed to get task with ID %v. Error message: %v", request.Id, err)
+	}
+	if !exists {
+		return &task_servicepb.StatusHistory{}, status.Errorf(codes.NotFound, "[GetStatusHistory] Task with ID %v doesn't exist", request.Id)
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT from_status, to_status, username, transitioned_at FROM task_status_transitions WHERE task_id = $1 ORDER BY transition_id",
+		request.Id,
+	)
+	if err != nil {
+		return &task_servicepb.StatusHistory{}, status.Errorf(codes.Internal, "[GetStatusHistory] Failed to get history of task with ID %v. Error message: %v", request.Id, err)
+	}
+	defer rows.Close()
+
+	history := &task_servicepb.StatusHistory{}
+	for rows.Next() {
+		var from, to, username string
+		var transitionedAt time.Time
+		if err := rows.Scan(&from, &to, &username, &transitionedAt); err != nil {
+			return &task_servicepb.StatusHistory{}, status.Errorf(codes.Internal, "[GetStatusHistory] %v", err)
+		}
+		history.Transitions = append(history.Transitions, &task_servicepb.StatusTransition{
+			From:           statusFromString(from),
+			To:             statusFromString(to),
+			Username:       username,
+			TransitionedAt: timestamppb.New(transitionedAt),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return &task_servicepb.StatusHistory{}, status.Errorf(codes.Internal, "[GetStatusHistory] %v", err)
+	}
+	return history, nil
+}
+
+func (s *Server) AssignTask(ctx context.Context, request *task_servicepb.AssigneeRequest) (*task_servicepb.TaskID, error) {
+	taskID := task_servicepb.TaskID{Id: request.TaskId}
+	if request.Assignee == "" {
+		return &taskID, status.Errorf(codes.InvalidArgument, "[AssignTask] Assignee should not be empty")
+	}
+
+	// Only author of task and moderators can assign users
+	var allowed bool
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT creator_username = $1 OR $3 FROM task_service_db WHERE task_id = $2",
+		request.RequestorUsername, request.TaskId, canModerate(request.RequestorRole),
+	).Scan(&allowed)
+	if err == sql.ErrNoRows {
+		return &taskID, status.Errorf(codes.NotFound, "[AssignTask] Task with ID: %v doesn't exist", request.TaskId)
+	}
+	if err != nil {
+		return &taskID, status.Errorf(codes.Internal, "[AssignTask] Failed to get task with ID: %v. Error message: %v", request.TaskId, err)
+	}
+	if !allowed {
+		return &taskID, status.Errorf(codes.PermissionDenied, "[AssignTask] User: `%v` can't assign users to task with ID: %v", request.RequestorUsername, request.TaskId)
+	}
+
+	// Task is referenced by foreign key, so assignment of concurrently deleted task fails.
+	// Change of assignees is a change of task, so its `updated_at` is bumped unless user was already assigned
+	_, err = s.db.ExecContext(
+		ctx,
+		"WITH assigned AS ("+
+			"INSERT INTO task_assignees (task_id, username, assigned_by) VALUES ($1, $2, $3) ON CONFLICT (task_id, username) DO NOTHING RETURNING task_id"+
+			") UPDATE task_service_db SET updated_at = now() WHERE task_id IN (SELECT task_id FROM assigned)",
+		request.TaskId, request.Assignee, request.RequestorUsername,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return &taskID, status.Errorf(codes.NotFound, "[AssignTask] Task with ID: %v has been deleted", request.TaskId)
+	}
+	if err != nil {
+		return &taskID, status.Errorf(codes.Internal, "[AssignTask] Failed to assign user: `%v` to task with ID: %v. Error message: %v", request.Assignee, request.TaskId, err)
+	}
+	return &taskID, nil
+}
+
+func (s *Server) UnassignTask(ctx context.Context, request *task_servicepb.AssigneeRequest) (*task_servicepb.TaskID, error) {
+	taskID := task_servicepb.TaskID{Id: request.TaskId}
+
+	// Author of task and moderators can unassign anyone, other users only themselves.
+	// `updated_at` of task is bumped only if assignment is deleted, so updated rows count deleted assignments
+	result, err := s.db.ExecContext(
+		ctx,
+		"WITH unassigned AS ("+
+			"DELETE FROM task_assignees WHERE task_id = $1 AND username = $2 AND ($2 = $3 OR $4 OR EXISTS (SELECT 1 FROM task_service_db WHERE task_id = $1 AND creator_username = $3)) RETURNING task_id"+
+			") UPDATE task_service_db SET updated_at = now() WHERE task_id IN (SELECT task_id FROM unassigned)",
+		request.TaskId, request.Assignee, request.RequestorUsername, canModerate(request.RequestorRole),
+	)
+	if err != nil {
+		return &taskID, status.Errorf(codes.Internal, "[UnassignTask] Failed to unassign user: `%v` from task with ID: %v. Error message: %v", request.Assignee, request.TaskId, err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return &taskID, status.Errorf(codes.Internal, "[UnassignTask] Failed to count deleted assignments. Error message: %v", err)
+	}
+	if count != 1 {
+		return &taskID, status.Errorf(codes.NotFound, "[UnassignTask] User: `%v` is not assigned to task with ID: %v or requestor: `%v` can't unassign him", request.Assignee, request.TaskId, request.RequestorUsername)
+	}
+	return &taskID, nil
+}
@@ -0,0 +1,69 @@
+package task_service
+
+import (
+	"database/sql"
+	"time"
+
+	task_servicepb "task_service/proto"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Priority of tasks created without priority
+const defaultPriority = task_servicepb.TaskPriority_TASK_PRIORITY_P2
+
+// Columns of task which are read by `scanTask`
+const taskColumns = "task_id, title, description, status, creator_username, workspace_id, priority, due_at, created_at, updated_at, " + selectAssignees
+
+// Column with sorted assignees of task from `task_service_db`
+const selectAssignees = "ARRAY(SELECT username FROM task_assignees WHERE task_assignees.task_id = task_service_db.task_id ORDER BY username)"
+
+// PostgreSQL error code of violated foreign key, e.g. assignee added to task deleted meanwhile
+const foreignKeyViolation = "23503"
+
+// Priorities are stored in PostgreSQL as names of `TaskPriority` enum
+func priorityFromString(s string) task_servicepb.TaskPriority {
+	return task_servicepb.TaskPriority(task_servicepb.TaskPriority_value[s])
+}
+
+func isKnownPriority(priority task_servicepb.TaskPriority) bool {
+	_, ok := task_servicepb.TaskPriority_name[int32(priority)]
+	return ok
+}
+
+// Deadline for PostgreSQL. Task without deadline has NULL in `due_at`
+func nullTime(timestamp *timestamppb.Timestamp) sql.NullTime {
+	if timestamp == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: timestamp.AsTime(), Valid: true}
+}
+
+func timestampOrNil(value sql.NullTime) *timestamppb.Timestamp {
+	if !value.Valid {
+		return nil
+	}
+	return timestamppb.New(value.Time)
+}
+
+// Read task from row with `taskColumns`
+func scanTask(scan func(dest ...any) error) (*task_servicepb.Task, error) {
+	task := &task_servicepb.Task{Task: &task_servicepb.TaskContent{}}
+	var taskStatus, priority string
+	var dueAt sql.NullTime
+	var createdAt, updatedAt time.Time
+	err := scan(
+		&task.Id, &task.Task.Title, &task.Task.Description, &taskStatus, &task.Task.CreatorUsername, &task.Task.WorkspaceId,
+		&priority, &dueAt, &createdAt, &updatedAt, pq.Array(&task.Task.Assignees),
+	)
+	if err != nil {
+		return nil, err
+	}
+	task.Task.Status = statusFromString(taskStatus)
+	task.Task.Priority = priorityFromString(priority)
+	task.Task.DueAt = timestampOrNil(dueAt)
+	task.Task.CreatedAt = timestamppb.New(createdAt)
+	task.Task.UpdatedAt = timestamppb.New(updatedAt)
+	return task, nil
+}
@@ -0,0 +1,175 @@
+package task_service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	task_servicepb "task_service/proto"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Statuses are stored in PostgreSQL as names of `TaskStatus` enum
+func statusFromString(s string) task_servicepb.TaskStatus {
+	return task_servicepb.TaskStatus(task_servicepb.TaskStatus_value[s])
+}
+
+// Workflow which is used by workspaces without configured one and by tasks outside of workspaces
+//
+//	not taken -> in progress -> can be tested -> done, work can be returned back to the previous
+//	status and every unfinished task can be cancelled
+func DefaultWorkflow(workspaceID string) *task_servicepb.Workflow {
+	transition := func(from, to task_servicepb.TaskStatus) *task_servicepb.WorkflowTransition {
+		return &task_servicepb.WorkflowTransition{From: from, To: to}
+	}
+	return &task_servicepb.Workflow{
+		WorkspaceId:   workspaceID,
+		InitialStatus: task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN,
+		Statuses: []task_servicepb.TaskStatus{
+			task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN,
+			task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS,
+			task_servicepb.TaskStatus_TASK_STATUS_CAN_BE_TESTED,
+			task_servicepb.TaskStatus_TASK_STATUS_DONE,
+			task_servicepb.TaskStatus_TASK_STATUS_CANCELLED,
+		},
+		Transitions: []*task_servicepb.WorkflowTransition{
+			transition(task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN, task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS),
+			transition(task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS, task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN),
+			transition(task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS, task_servicepb.TaskStatus_TASK_STATUS_CAN_BE_TESTED),
+			transition(task_servicepb.TaskStatus_TASK_STATUS_CAN_BE_TESTED, task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS),
+			transition(task_servicepb.TaskStatus_TASK_STATUS_CAN_BE_TESTED, task_servicepb.TaskStatus_TASK_STATUS_DONE),
+			transition(task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN, task_servicepb.TaskStatus_TASK_STATUS_CANCELLED),
+			transition(task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS, task_servicepb.TaskStatus_TASK_STATUS_CANCELLED),
+			transition(task_servicepb.TaskStatus_TASK_STATUS_CAN_BE_TESTED, task_servicepb.TaskStatus_TASK_STATUS_CANCELLED),
+		},
+		TerminalStatuses: []task_servicepb.TaskStatus{
+			task_servicepb.TaskStatus_TASK_STATUS_DONE,
+			task_servicepb.TaskStatus_TASK_STATUS_CANCELLED,
+		},
+	}
+}
+
+// Names of statuses as they are stored in PostgreSQL
+func statusNames(statuses []task_servicepb.TaskStatus) []string {
+	names := []string{}
+	for _, status := range statuses {
+		names = append(names, status.String())
+	}
+	return names
+}
+
+func hasStatus(statuses []task_servicepb.TaskStatus, status task_servicepb.TaskStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Check that workflow is consistent: every status is known and used only once, initial, terminal
+// statuses and transitions use only statuses of workflow and nothing leads out of terminal statuses
+func validateWorkflow(workflow *task_servicepb.Workflow) error {
+	if len(workflow.Statuses) == 0 {
+		return fmt.Errorf("workflow should have at least one status")
+	}
+	seen := map[task_servicepb.TaskStatus]bool{}
+	for _, status := range workflow.Statuses {
+		if _, ok := task_servicepb.TaskStatus_name[int32(status)]; !ok || status == task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+			return fmt.Errorf("unknown status %v", status)
+		}
+		if seen[status] {
+			return fmt.Errorf("status %v is listed twice", status)
+		}
+		seen[status] = true
+	}
+
+	for _, status := range workflow.TerminalStatuses {
+		if !seen[status] {
+			return fmt.Errorf("terminal status %v is not a status of workflow", status)
+		}
+	}
+	if !seen[workflow.InitialStatus] {
+		return fmt.Errorf("initial status %v is not a status of workflow", workflow.InitialStatus)
+	}
+	if hasStatus(workflow.TerminalStatuses, workflow.InitialStatus) {
+		return fmt.Errorf("initial status %v should not be terminal", workflow.InitialStatus)
+	}
+
+	type edge struct{ from, to task_servicepb.TaskStatus }
+	edges := map[edge]bool{}
+	for _, transition := range workflow.Transitions {
+		if !seen[transition.From] || !seen[transition.To] {
+			return fmt.Errorf("transition from %v to %v uses status which is not a status of workflow", transition.From, transition.To)
+		}
+		if transition.From == transition.To {
+			return fmt.Errorf("transition from %v to itself is not needed", transition.From)
+		}
+		if hasStatus(workflow.TerminalStatuses, transition.From) {
+			return fmt.Errorf("transition from terminal status %v is not allowed", transition.From)
+		}
+		if edges[edge{transition.From, transition.To}] {
+			return fmt.Errorf("transition from %v to %v is listed twice", transition.From, transition.To)
+		}
+		edges[edge{transition.From, transition.To}] = true
+	}
+	return nil
+}
+
+// Check if task with status `from` can be moved to `to`
+func workflowAllows(workflow *task_servicepb.Workflow, from, to task_servicepb.TaskStatus) bool {
+	if hasStatus(workflow.TerminalStatuses, from) {
+		return false
+	}
+	for _, transition := range workflow.Transitions {
+		if transition.From == from && transition.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// First key of advisory locks of workflows, second one is hash of workspace id. Locks with two keys
+// don't overlap with the lock of migrations which has one key
+const workflowLockClass = 1
+
+// Get workflow of workspace inside transaction. Workflow of workspace is locked for share by advisory lock
+// until the end of transaction, so it can't be replaced until tasks changed by transaction are committed.
+// Row lock wouldn't be enough, because workspaces with default workflow have no row
+func getWorkflow(ctx context.Context, txn *sql.Tx, workspaceID string) (*task_servicepb.Workflow, error) {
+	_, err := txn.ExecContext(ctx, "SELECT pg_advisory_xact_lock_shared($1, hashtext($2))", workflowLockClass, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = txn.QueryRowContext(
+		ctx,
+		"SELECT workflow FROM task_workflows WHERE workspace_id = $1",
+		workspaceID,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return DefaultWorkflow(workspaceID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var workflow task_servicepb.Workflow
+	if err := protojson.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("workflow of workspace `%v` is corrupted: %w", workspaceID, err)
+	}
+	workflow.WorkspaceId = workspaceID
+	return &workflow, nil
+}
+
+// Save change of task's status into history
+func recordTransition(ctx context.Context, txn *sql.Tx, taskID int32, from, to task_servicepb.TaskStatus, username string) error {
+	_, err := txn.ExecContext(
+		ctx,
+		"INSERT INTO task_status_transitions (task_id, from_status, to_status, username) VALUES ($1, $2, $3, $4)",
+		taskID, from.String(), to.String(), username,
+	)
+	return err
+}
@@ -0,0 +1,133 @@
+package task_service
+
+import (
+	"strings"
+	"testing"
+
+	task_servicepb "task_service/proto"
+)
+
+const (
+	notTaken    = task_servicepb.TaskStatus_TASK_STATUS_NOT_TAKEN
+	inProgress  = task_servicepb.TaskStatus_TASK_STATUS_IN_PROGRESS
+	canBeTested = task_servicepb.TaskStatus_TASK_STATUS_CAN_BE_TESTED
+	done        = task_servicepb.TaskStatus_TASK_STATUS_DONE
+	cancelled   = task_servicepb.TaskStatus_TASK_STATUS_CANCELLED
+)
+
+func transition(from, to task_servicepb.TaskStatus) *task_servicepb.WorkflowTransition {
+	return &task_servicepb.WorkflowTransition{From: from, To: to}
+}
+
+// Small valid workflow, every case of `TestValidateWorkflow` breaks it in one place
+func simpleWorkflow(change func(workflow *task_servicepb.Workflow)) *task_servicepb.Workflow {
+	workflow := &task_servicepb.Workflow{
+		WorkspaceId:      "workspace",
+		InitialStatus:    notTaken,
+		Statuses:         []task_servicepb.TaskStatus{notTaken, inProgress, done},
+		Transitions:      []*task_servicepb.WorkflowTransition{transition(notTaken, inProgress), transition(inProgress, done)},
+		TerminalStatuses: []task_servicepb.TaskStatus{done},
+	}
+	if change != nil {
+		change(workflow)
+	}
+	return workflow
+}
+
+func TestValidateWorkflow(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		workflow *task_servicepb.Workflow
+		// Part of error message, empty if workflow is valid
+		err string
+	}{
+		{"default workflow", DefaultWorkflow("workspace"), ""},
+		{"simple workflow", simpleWorkflow(nil), ""},
+		{"without terminal statuses", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.TerminalStatuses = nil
+		}), ""},
+		{"without transitions", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Transitions = nil
+		}), ""},
+		{"no statuses", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Statuses = nil
+		}), "at least one status"},
+		{"unspecified status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Statuses = append(w.Statuses, task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED)
+		}), "unknown status"},
+		{"unknown status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Statuses = append(w.Statuses, task_servicepb.TaskStatus(100))
+		}), "unknown status"},
+		{"duplicated status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Statuses = append(w.Statuses, inProgress)
+		}), "listed twice"},
+		{"foreign terminal status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.TerminalStatuses = append(w.TerminalStatuses, cancelled)
+		}), "terminal status"},
+		{"foreign initial status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.InitialStatus = canBeTested
+		}), "initial status"},
+		{"terminal initial status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.InitialStatus = done
+		}), "should not be terminal"},
+		{"transition to foreign status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Transitions = append(w.Transitions, transition(inProgress, cancelled))
+		}), "not a status of workflow"},
+		{"transition to itself", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Transitions = append(w.Transitions, transition(inProgress, inProgress))
+		}), "to itself"},
+		{"transition from terminal status", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Transitions = append(w.Transitions, transition(done, inProgress))
+		}), "from terminal status"},
+		{"duplicated transition", simpleWorkflow(func(w *task_servicepb.Workflow) {
+			w.Transitions = append(w.Transitions, transition(notTaken, inProgress))
+		}), "listed twice"},
+	} {
+		err := validateWorkflow(test.workflow)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: expected valid workflow, got error: %v", test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestWorkflowAllows(t *testing.T) {
+	workflow := DefaultWorkflow("")
+	for _, test := range []struct {
+		from, to task_servicepb.TaskStatus
+		allowed  bool
+	}{
+		{notTaken, inProgress, true},
+		{inProgress, notTaken, true},
+		{inProgress, canBeTested, true},
+		{canBeTested, done, true},
+		{notTaken, cancelled, true},
+		{canBeTested, cancelled, true},
+		// Not listed transitions
+		{notTaken, done, false},
+		{notTaken, canBeTested, false},
+		{canBeTested, notTaken, false},
+		{notTaken, notTaken, false},
+		// Nothing leads out of terminal statuses
+		{done, inProgress, false},
+		{cancelled, notTaken, false},
+		{done, cancelled, false},
+		// Statuses which are not in workflow
+		{task_servicepb.TaskStatus_TASK_STATUS_UNSPECIFIED, notTaken, false},
+		{notTaken, task_servicepb.TaskStatus(100), false},
+	} {
+		if allowed := workflowAllows(workflow, test.from, test.to); allowed != test.allowed {
+			t.Errorf("transition from %v to %v: expected allowed = %v, got %v", test.from, test.to, test.allowed, allowed)
+		}
+	}
+
+	// Terminal status forbids transitions even if workflow lists them
+	workflow = simpleWorkflow(func(w *task_servicepb.Workflow) {
+		w.Transitions = append(w.Transitions, transition(done, inProgress))
+	})
+	if workflowAllows(workflow, done, inProgress) {
+		t.Errorf("transition from terminal status %v should not be allowed", done)
+	}
+}